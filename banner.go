@@ -0,0 +1,48 @@
+package ghast
+
+import (
+	"fmt"
+	"net"
+	"strings"
+	"text/tabwriter"
+)
+
+const banner = `
+   ________               __
+  / ____/ /_  ____ ______/ /_
+ / / __/ __ \/ __ '/ ___/ __/
+/ /_/ / / / / /_/ (__  ) /_
+\____/_/ /_/\__,_/____/\__/   v%s
+`
+
+// printStartupReport logs the banner, the listen address and, if enabled, the route table.
+func (g *Ghast) printStartupReport(addr net.Addr) {
+	logger := g.logger()
+	if !g.config.HideBanner {
+		logger.Info(fmt.Sprintf(banner, Version))
+	}
+
+	address := addr.String()
+	if g.config.HidePort {
+		if host, _, err := net.SplitHostPort(address); err == nil {
+			address = host
+		}
+	}
+	logger.Info("🌪️  Ghast server listening", "addr", address)
+
+	if g.config.PrintRoutes {
+		logger.Info("Registered routes:\n" + formatRouteTable(g.Routes()))
+	}
+}
+
+// formatRouteTable renders routes as an aligned table with method, path, handler and middleware count columns.
+func formatRouteTable(routes []RouteInfo) string {
+	var buf strings.Builder
+	tw := tabwriter.NewWriter(&buf, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "METHOD\tPATH\tHANDLER\tMIDDLEWARE")
+	for _, route := range routes {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%d\n", route.Method, route.Path, route.Handler, route.Middlewares)
+	}
+	tw.Flush()
+	return buf.String()
+}
@@ -0,0 +1,162 @@
+package benchmarks
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	_ "net/http/pprof"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/Leonard-Atorough/ghast"
+	"github.com/Leonard-Atorough/ghast/ghasttest"
+)
+
+var pprofAddr = flag.String("pprof.addr", "", "serve net/http/pprof on this address while benchmarks run")
+
+// routeCount is the size of the routing tables used by the routing benchmarks.
+const routeCount = 50
+
+func TestMain(m *testing.M) {
+	flag.Parse()
+	if *pprofAddr != "" {
+		go func() {
+			log.Printf("pprof listening on http://%s/debug/pprof/", *pprofAddr)
+			log.Println(http.ListenAndServe(*pprofAddr, nil))
+		}()
+	}
+	os.Exit(m.Run())
+}
+
+var noop = ghast.HandlerFunc(func(w ghast.ResponseWriter, r *ghast.Request) {})
+
+// staticRouter registers routeCount static routes such as /resource42/items.
+func staticRouter() ghast.Router {
+	router := ghast.NewRouter()
+	for i := range routeCount {
+		router.Get(fmt.Sprintf("/resource%d/items", i), noop)
+	}
+	return router
+}
+
+// paramRouter registers routeCount routes with two dynamic segments such as /resource42/:id/items/:itemId.
+func paramRouter() ghast.Router {
+	router := ghast.NewRouter()
+	for i := range routeCount {
+		router.Get(fmt.Sprintf("/resource%d/:id/items/:itemId", i), noop)
+	}
+	return router
+}
+
+func BenchmarkRouteStatic(b *testing.B) {
+	router := staticRouter()
+	path := fmt.Sprintf("/resource%d/items", routeCount-1)
+	b.ReportAllocs()
+	for b.Loop() {
+		if _, _, ok := router.Lookup(ghast.GET, path); !ok {
+			b.Fatal("route not matched")
+		}
+	}
+}
+
+func BenchmarkRouteParam(b *testing.B) {
+	router := paramRouter()
+	path := fmt.Sprintf("/resource%d/42/items/7", routeCount-1)
+	b.ReportAllocs()
+	for b.Loop() {
+		if _, _, ok := router.Lookup(ghast.GET, path); !ok {
+			b.Fatal("route not matched")
+		}
+	}
+}
+
+func BenchmarkRouteNotFound(b *testing.B) {
+	router := paramRouter()
+	b.ReportAllocs()
+	for b.Loop() {
+		if _, _, ok := router.Lookup(ghast.GET, "/missing/42"); ok {
+			b.Fatal("unexpected match")
+		}
+	}
+}
+
+func BenchmarkServeParam(b *testing.B) {
+	router := paramRouter()
+	req := ghasttest.NewRequest(ghast.GET, fmt.Sprintf("/resource%d/42/items/7", routeCount-1)).Build()
+	b.ReportAllocs()
+	for b.Loop() {
+		router.ServeHTTP(ghasttest.NewRecorder(), req)
+	}
+}
+
+func BenchmarkParseRequest(b *testing.B) {
+	raw := strings.Join([]string{
+		"GET /users/42?fields=name,email&sort=asc HTTP/1.1",
+		"Host: api.example.com",
+		"User-Agent: benchmark/1.0",
+		"Accept: application/json",
+		"Accept-Encoding: gzip, deflate",
+		"Authorization: Bearer abcdef0123456789",
+		"Connection: keep-alive",
+	}, ghast.CRLF)
+	b.SetBytes(int64(len(raw)))
+	b.ReportAllocs()
+	for b.Loop() {
+		if _, err := ghast.ParseRequest(raw); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+type user struct {
+	ID    int      `json:"id"`
+	Name  string   `json:"name"`
+	Email string   `json:"email"`
+	Tags  []string `json:"tags"`
+}
+
+func BenchmarkJSONResponse(b *testing.B) {
+	app := ghast.New()
+	users := make([]user, 20)
+	for i := range users {
+		users[i] = user{ID: i, Name: fmt.Sprintf("user%d", i), Email: fmt.Sprintf("user%d@example.com", i), Tags: []string{"a", "b"}}
+	}
+	app.Get("/users", ghast.HandlerFunc(func(w ghast.ResponseWriter, r *ghast.Request) {
+		w.JSON(200, users)
+	}))
+	b.ReportAllocs()
+	for b.Loop() {
+		if res := app.Test(&ghast.Request{Method: ghast.GET, Path: "/users"}); res.StatusCode != 200 {
+			b.Fatalf("unexpected status %d", res.StatusCode)
+		}
+	}
+}
+
+// BenchmarkRoundTrip measures a full request over loopback TCP: connect, write, parse, route, respond, close.
+func BenchmarkRoundTrip(b *testing.B) {
+	app := ghast.New(ghast.WithHideBanner())
+	app.Get("/users/:id", ghast.HandlerFunc(func(w ghast.ResponseWriter, r *ghast.Request) {
+		w.JSON(200, map[string]string{"id": r.Param("id")})
+	}))
+	srv := ghasttest.Start(b, app)
+	request := []byte("GET /users/42 HTTP/1.1\r\nHost: localhost\r\nConnection: close\r\n\r\n")
+
+	b.ReportAllocs()
+	for b.Loop() {
+		conn, err := net.Dial("tcp", srv.Addr)
+		if err != nil {
+			b.Fatal(err)
+		}
+		if _, err := conn.Write(request); err != nil {
+			b.Fatal(err)
+		}
+		if _, err := io.Copy(io.Discard, conn); err != nil {
+			b.Fatal(err)
+		}
+		conn.Close()
+	}
+}
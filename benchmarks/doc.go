@@ -0,0 +1,11 @@
+// Package benchmarks contains reproducible benchmarks for the ghast framework: routing over static and
+// parameterized tables, request parsing, JSON responses and full request round-trips over loopback TCP.
+//
+// Run them with:
+//
+//	go test ./benchmarks -run '^$' -bench . -benchmem -count 10 | tee new.txt
+//
+// and compare runs with benchstat (golang.org/x/perf/cmd/benchstat). Profiles are captured with the standard
+// test flags, e.g. -cpuprofile cpu.out -memprofile mem.out, and inspected with go tool pprof. For long runs,
+// -pprof.addr localhost:6060 also serves live profiles from net/http/pprof while the benchmarks execute.
+package benchmarks
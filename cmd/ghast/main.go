@@ -0,0 +1,95 @@
+// Command ghast scaffolds projects and components wired to the Ghast framework.
+//
+// Usage:
+//
+//	ghast new <app> [-module <module path>]
+//	ghast generate handler <name>
+//	ghast generate middleware <name>
+//	ghast generate router <name>
+//
+// "ghast new" creates a project with routers in their own package, configuration loaded from the
+// environment and graceful shutdown on SIGINT/SIGTERM. "ghast generate" adds a component to the project
+// in the current directory.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+)
+
+const usage = `Usage:
+  ghast new <app> [-module <module path>]   Create a new Ghast project in ./<app>
+  ghast generate handler <name>             Add a handler to internal/handlers
+  ghast generate middleware <name>          Add a middleware to internal/middleware
+  ghast generate router <name>              Add a router to internal/routes
+`
+
+func main() {
+	if err := run(os.Args[1:], os.Stdout); err != nil {
+		fmt.Fprintf(os.Stderr, "ghast: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// run executes the command line given in args, writing progress to out.
+func run(args []string, out io.Writer) error {
+	if len(args) == 0 {
+		fmt.Fprint(out, usage)
+		return fmt.Errorf("missing command")
+	}
+
+	switch args[0] {
+	case "new":
+		return runNew(args[1:], out)
+	case "generate", "g":
+		return runGenerate(args[1:], out)
+	case "help", "-h", "--help":
+		fmt.Fprint(out, usage)
+		return nil
+	default:
+		fmt.Fprint(out, usage)
+		return fmt.Errorf("unknown command %q", args[0])
+	}
+}
+
+// runNew implements "ghast new".
+func runNew(args []string, out io.Writer) error {
+	fs := flag.NewFlagSet("new", flag.ContinueOnError)
+	fs.SetOutput(out)
+	module := fs.String("module", "", "Go module path (defaults to the app name)")
+
+	// Allow the app name before or after flags: "ghast new myapp -module x" and "ghast new -module x myapp".
+	var name string
+	if len(args) > 0 && args[0] != "" && args[0][0] != '-' {
+		name, args = args[0], args[1:]
+	}
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if name == "" && fs.NArg() > 0 {
+		name = fs.Arg(0)
+	}
+	if name == "" {
+		return fmt.Errorf("usage: ghast new <app> [-module <module path>]")
+	}
+	if *module == "" {
+		*module = name
+	}
+
+	return newProject(name, projectData{AppName: name, Module: *module}, out)
+}
+
+// runGenerate implements "ghast generate".
+func runGenerate(args []string, out io.Writer) error {
+	if len(args) != 2 {
+		return fmt.Errorf("usage: ghast generate <handler|middleware|router> <name>")
+	}
+
+	module, err := readModulePath("go.mod")
+	if err != nil {
+		return fmt.Errorf("run generate from the project root: %w", err)
+	}
+	return generateComponent(".", args[0], componentData{Name: args[1], Module: module}, out)
+}
@@ -0,0 +1,181 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"embed"
+	"errors"
+	"fmt"
+	"go/format"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+	"unicode"
+
+	"github.com/Leonard-Atorough/ghast"
+)
+
+//go:embed all:templates
+var templates embed.FS
+
+// projectData is the data available to project templates.
+type projectData struct {
+	AppName      string // Directory and binary name, e.g. "billing"
+	Module       string // Go module path, e.g. "github.com/acme/billing"
+	GhastVersion string // Version of the framework the project depends on
+}
+
+// componentData is the data available to component templates.
+type componentData struct {
+	Name   string // Name as given on the command line, e.g. "list-users"
+	Module string // Go module path of the project
+}
+
+// Ident returns the exported Go identifier for the component name, e.g. "list-users" becomes "ListUsers".
+func (d componentData) Ident() string {
+	return exportedIdent(d.Name)
+}
+
+// components maps each generatable component kind to its template and output directory.
+var components = map[string]struct {
+	template string
+	dir      string
+}{
+	"handler":    {"templates/generate/handler.go.tmpl", "internal/handlers"},
+	"middleware": {"templates/generate/middleware.go.tmpl", "internal/middleware"},
+	"router":     {"templates/generate/router.go.tmpl", "internal/routes"},
+}
+
+// newProject renders the project templates into a new directory named after the app.
+func newProject(dir string, data projectData, out io.Writer) error {
+	if _, err := os.Stat(dir); err == nil {
+		return fmt.Errorf("%s already exists", dir)
+	}
+	data.GhastVersion = "v" + ghast.Version
+
+	return fs.WalkDir(templates, "templates/new", func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		rel := strings.TrimSuffix(strings.TrimPrefix(path, "templates/new/"), ".tmpl")
+		return renderFile(path, filepath.Join(dir, filepath.FromSlash(rel)), data, out)
+	})
+}
+
+// generateComponent renders a single component template into the project rooted at root.
+func generateComponent(root, kind string, data componentData, out io.Writer) error {
+	component, ok := components[kind]
+	if !ok {
+		return fmt.Errorf("unknown component %q (expected handler, middleware or router)", kind)
+	}
+	if data.Ident() == "" {
+		return fmt.Errorf("invalid %s name %q", kind, data.Name)
+	}
+
+	target := filepath.Join(root, component.dir, snakeCase(data.Name)+".go")
+	return renderFile(component.template, target, data, out)
+}
+
+// renderFile executes a template and writes the result to target, formatting Go sources.
+// Existing files are never overwritten.
+func renderFile(templatePath, target string, data any, out io.Writer) error {
+	if _, err := os.Stat(target); err == nil {
+		return fmt.Errorf("%s already exists", target)
+	}
+
+	tmpl, err := template.ParseFS(templates, templatePath)
+	if err != nil {
+		return err
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return fmt.Errorf("rendering %s: %w", templatePath, err)
+	}
+
+	content := buf.Bytes()
+	if strings.HasSuffix(target, ".go") {
+		if content, err = format.Source(content); err != nil {
+			return fmt.Errorf("formatting %s: %w", target, err)
+		}
+	}
+
+	if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+		return err
+	}
+	if err := os.WriteFile(target, content, 0o644); err != nil {
+		return err
+	}
+	fmt.Fprintf(out, "  create %s\n", filepath.ToSlash(target))
+	return nil
+}
+
+// readModulePath returns the module path declared in a go.mod file.
+func readModulePath(goMod string) (string, error) {
+	f, err := os.Open(goMod)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if module, ok := strings.CutPrefix(line, "module "); ok {
+			return strings.Trim(strings.TrimSpace(module), `"`), nil
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return "", err
+	}
+	return "", errors.New("no module directive in " + goMod)
+}
+
+// exportedIdent converts a name such as "list-users" or "list_users" to an exported identifier ("ListUsers").
+func exportedIdent(name string) string {
+	var b strings.Builder
+	for _, word := range splitWords(name) {
+		runes := []rune(word)
+		b.WriteRune(unicode.ToUpper(runes[0]))
+		b.WriteString(string(runes[1:]))
+	}
+	return b.String()
+}
+
+// snakeCase converts a name such as "ListUsers" or "list-users" to a file name ("list_users").
+func snakeCase(name string) string {
+	words := splitWords(name)
+	for i, word := range words {
+		words[i] = strings.ToLower(word)
+	}
+	return strings.Join(words, "_")
+}
+
+// splitWords splits a name on separators and lower-to-upper case transitions.
+func splitWords(name string) []string {
+	var words []string
+	var current []rune
+	flush := func() {
+		if len(current) > 0 {
+			words = append(words, string(current))
+			current = nil
+		}
+	}
+	var prev rune
+	for _, r := range name {
+		switch {
+		case !unicode.IsLetter(r) && !unicode.IsDigit(r):
+			flush()
+		case unicode.IsUpper(r) && unicode.IsLower(prev):
+			flush()
+			current = append(current, r)
+		default:
+			current = append(current, r)
+		}
+		prev = r
+	}
+	flush()
+	return words
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// TestNewProjectAndGenerate tests scaffolding a project and generating components into it
+func TestNewProjectAndGenerate(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "billing")
+
+	if err := newProject(dir, projectData{AppName: "billing", Module: "example.com/billing"}, io.Discard); err != nil {
+		t.Fatalf("new project failed: %v", err)
+	}
+
+	expected := []string{
+		".gitignore",
+		"go.mod",
+		"main.go",
+		"internal/config/config.go",
+		"internal/handlers/health.go",
+		"internal/routes/routes.go",
+	}
+	for _, file := range expected {
+		if _, err := os.Stat(filepath.Join(dir, file)); err != nil {
+			t.Errorf("expected %s to be created: %v", file, err)
+		}
+	}
+
+	module, err := readModulePath(filepath.Join(dir, "go.mod"))
+	if err != nil || module != "example.com/billing" {
+		t.Errorf("unexpected module path %q: %v", module, err)
+	}
+
+	data := componentData{Name: "list-invoices", Module: module}
+	for kind, file := range map[string]string{
+		"handler":    "internal/handlers/list_invoices.go",
+		"middleware": "internal/middleware/list_invoices.go",
+		"router":     "internal/routes/list_invoices.go",
+	} {
+		if err := generateComponent(dir, kind, data, io.Discard); err != nil {
+			t.Errorf("generate %s failed: %v", kind, err)
+			continue
+		}
+		content, err := os.ReadFile(filepath.Join(dir, file))
+		if err != nil {
+			t.Errorf("expected %s to be created: %v", file, err)
+			continue
+		}
+		if !strings.Contains(string(content), "ListInvoices") {
+			t.Errorf("%s does not use the exported identifier:\n%s", file, content)
+		}
+	}
+
+	if err := generateComponent(dir, "handler", data, io.Discard); err == nil {
+		t.Error("generating over an existing file should fail")
+	}
+	if err := newProject(dir, projectData{AppName: "billing", Module: "x"}, io.Discard); err == nil {
+		t.Error("creating a project in an existing directory should fail")
+	}
+}
+
+// TestNameConversions tests identifier and file name conversion of component names
+func TestNameConversions(t *testing.T) {
+	tests := []struct {
+		name, ident, file string
+	}{
+		{"users", "Users", "users"},
+		{"list-users", "ListUsers", "list_users"},
+		{"list_users", "ListUsers", "list_users"},
+		{"ListUsers", "ListUsers", "list_users"},
+		{"apiV2", "ApiV2", "api_v2"},
+	}
+
+	for _, tt := range tests {
+		if got := exportedIdent(tt.name); got != tt.ident {
+			t.Errorf("exportedIdent(%q) = %q, want %q", tt.name, got, tt.ident)
+		}
+		if got := snakeCase(tt.name); got != tt.file {
+			t.Errorf("snakeCase(%q) = %q, want %q", tt.name, got, tt.file)
+		}
+	}
+}
@@ -0,0 +1,15 @@
+package ghast
+
+// Server is the pre-0.5.0 name of the application type.
+//
+// Deprecated: Use Ghast instead.
+type Server = Ghast
+
+// NewRouter creates a router and mounts it under the given prefix, matching the pre-0.5.0 API.
+//
+// Deprecated: Create routers with the package-level NewRouter and mount them with Route.
+func (g *Ghast) NewRouter(prefix string) Router {
+	router := NewRouter()
+	g.Route(prefix, router)
+	return router
+}
@@ -0,0 +1,272 @@
+package ghast
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"html"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime/debug"
+	"slices"
+	"strings"
+	"time"
+)
+
+// devPollInterval is how often the development file watcher scans for changes.
+const devPollInterval = 500 * time.Millisecond
+
+// devWatchedExtensions lists the file types the development watcher reacts to.
+var devWatchedExtensions = []string{".go", ".html", ".tmpl", ".gohtml", ".css", ".js"}
+
+// devSkippedDirs lists directories the development watcher never descends into.
+var devSkippedDirs = []string{".git", "node_modules", "vendor", "testdata"}
+
+// Dev enables development mode, intended for local use only:
+//   - every request is logged with its status and duration
+//   - panics in handlers render an HTML page with the panic value and stack trace instead of crashing the connection
+//   - files under the working directory are watched; changes to templates and static assets (.html, .tmpl,
+//     .gohtml, .css, .js) trigger the OnReload hooks, and changes to .go files rebuild and restart the process
+//     (on Unix; elsewhere the change is logged and the server has to be restarted manually)
+//
+// Returns the app for chaining.
+func (g *Ghast) Dev() *Ghast {
+	if !g.dev {
+		g.dev = true
+		g.devStop = make(chan struct{})
+		g.devRestart = make(chan error, 1)
+	}
+	return g
+}
+
+// OnReload registers a hook invoked in development mode when watched non-Go files change, with the list of
+// changed paths. Use it to re-parse templates or clear asset caches. Returns the app for chaining.
+func (g *Ghast) OnReload(hook func(changed []string)) *Ghast {
+	g.reloadHooks = append(g.reloadHooks, hook)
+	return g
+}
+
+// devMiddleware wraps the application with verbose request logging and a rendered panic page.
+func devMiddleware(next Handler) Handler {
+	return HandlerFunc(func(w ResponseWriter, r *Request) {
+		start := time.Now()
+		sw := &statusWriter{ResponseWriter: w, statusCode: 200}
+
+		defer func() {
+			if err := recover(); err != nil {
+				stack := debug.Stack()
+				r.Logger().Error(fmt.Sprintf("[DEV] panic serving %s %s: %v\n%s", r.Method, r.Path, err, stack))
+				sw.HTML(500, renderPanicPage(r, err, stack))
+			}
+			r.Logger().Info(fmt.Sprintf("[DEV] %s %s -> %d (%v)", r.Method, r.Path, sw.statusCode, time.Since(start)))
+		}()
+
+		next.ServeHTTP(sw, r)
+	})
+}
+
+// statusWriter records the status code a handler sets so it can be logged.
+type statusWriter struct {
+	ResponseWriter
+	statusCode int
+}
+
+func (sw *statusWriter) Status(statusCode int) ResponseWriter {
+	sw.statusCode = statusCode
+	sw.ResponseWriter.Status(statusCode)
+	return sw
+}
+
+func (sw *statusWriter) responseStarted() bool {
+	return responseStarted(sw.ResponseWriter)
+}
+
+// Flush forwards to the wrapped writer so streaming handlers keep working in development mode.
+func (sw *statusWriter) Flush() error {
+	if f, ok := sw.ResponseWriter.(Flusher); ok {
+		return f.Flush()
+	}
+	return nil
+}
+
+func (sw *statusWriter) SetHeader(key, value string) ResponseWriter {
+	sw.ResponseWriter.SetHeader(key, value)
+	return sw
+}
+
+func (sw *statusWriter) JSON(statusCode int, data interface{}) error {
+	sw.statusCode = statusCode
+	return sw.ResponseWriter.JSON(statusCode, data)
+}
+
+func (sw *statusWriter) JSONPretty(statusCode int, data interface{}) error {
+	sw.statusCode = statusCode
+	return sw.ResponseWriter.JSONPretty(statusCode, data)
+}
+
+func (sw *statusWriter) HTML(statusCode int, html string) error {
+	sw.statusCode = statusCode
+	return sw.ResponseWriter.HTML(statusCode, html)
+}
+
+func (sw *statusWriter) Plain(statusCode int, text string) error {
+	sw.statusCode = statusCode
+	return sw.ResponseWriter.Plain(statusCode, text)
+}
+
+func (sw *statusWriter) Render(statusCode int, name string, data any) error {
+	sw.statusCode = statusCode
+	return sw.ResponseWriter.Render(statusCode, name, data)
+}
+
+// renderPanicPage renders the development error page shown when a handler panics.
+func renderPanicPage(r *Request, err any, stack []byte) string {
+	return fmt.Sprintf(`<!DOCTYPE html>
+<html>
+<head><title>Panic: %[1]s</title>
+<style>body{font-family:sans-serif;margin:2em}pre{background:#f4f4f4;padding:1em;overflow:auto}</style>
+</head>
+<body>
+<h1>500 Internal Server Error</h1>
+<p><strong>%[2]s %[3]s</strong> panicked:</p>
+<pre>%[1]s</pre>
+<h2>Stack trace</h2>
+<pre>%[4]s</pre>
+<p><em>This page is only shown in development mode.</em></p>
+</body>
+</html>`,
+		html.EscapeString(fmt.Sprint(err)),
+		html.EscapeString(r.Method),
+		html.EscapeString(r.Path),
+		html.EscapeString(string(stack)),
+	)
+}
+
+// watchFiles polls the working directory for changes until stop is closed. Non-Go changes run the reload
+// hooks; Go changes rebuild and restart the process.
+func (g *Ghast) watchFiles(stop <-chan struct{}) {
+	snapshot := scanWatchedFiles(".")
+	ticker := time.NewTicker(devPollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			return
+		case <-ticker.C:
+		}
+
+		current := scanWatchedFiles(".")
+		changed := diffSnapshots(snapshot, current)
+		snapshot = current
+		if len(changed) == 0 {
+			continue
+		}
+
+		if slices.ContainsFunc(changed, func(path string) bool { return strings.HasSuffix(path, ".go") }) {
+			g.logger().Info("[DEV] Go source changed, rebuilding...")
+			if err := g.rebuildAndRestart(); err != nil {
+				g.logger().Error("[DEV] Restart failed, still serving the previous build", "err", err)
+			}
+			continue
+		}
+
+		g.logger().Info("[DEV] Reloading after changes to " + strings.Join(changed, ", "))
+		for _, hook := range g.reloadHooks {
+			hook(changed)
+		}
+	}
+}
+
+// scanWatchedFiles returns the modification time of every watched file under root.
+func scanWatchedFiles(root string) map[string]time.Time {
+	files := make(map[string]time.Time)
+	filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return nil
+		}
+		if d.IsDir() {
+			if path != root && (strings.HasPrefix(d.Name(), ".") || slices.Contains(devSkippedDirs, d.Name())) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if !slices.Contains(devWatchedExtensions, filepath.Ext(path)) {
+			return nil
+		}
+		if info, err := d.Info(); err == nil {
+			files[path] = info.ModTime()
+		}
+		return nil
+	})
+	return files
+}
+
+// diffSnapshots returns the sorted paths that were added, removed or modified between two scans.
+func diffSnapshots(previous, current map[string]time.Time) []string {
+	var changed []string
+	for path, modTime := range current {
+		if prevModTime, ok := previous[path]; !ok || !prevModTime.Equal(modTime) {
+			changed = append(changed, path)
+		}
+	}
+	for path := range previous {
+		if _, ok := current[path]; !ok {
+			changed = append(changed, path)
+		}
+	}
+	slices.Sort(changed)
+	return changed
+}
+
+// errRestartUnsupported is reported by the development watcher on platforms without exec.
+var errRestartUnsupported = errors.New("automatic restart is not supported on this platform, restart the server manually")
+
+// rebuildAndRestart builds the main package in the working directory and, if the build succeeds, restarts the
+// process with the new binary. It returns an error, while the app is still serving, if the build fails or the
+// platform can't restart the process.
+func (g *Ghast) rebuildAndRestart() error {
+	if !restartSupported {
+		return errRestartUnsupported
+	}
+	// The binary of the previous rebuild, which this process may be running, is replaced rather than left behind
+	binary := filepath.Join(os.TempDir(), fmt.Sprintf("ghast-dev-%d", os.Getpid()))
+	os.Remove(binary)
+	build := exec.Command("go", "build", "-o", binary, ".")
+	build.Stdout = os.Stdout
+	build.Stderr = os.Stderr
+	if err := build.Run(); err != nil {
+		os.Remove(binary)
+		return fmt.Errorf("build failed: %w", err)
+	}
+	g.restart(binary)
+	return nil
+}
+
+// restart shuts the application down and replaces the current process with binary. Listen and Serve stay
+// blocked meanwhile, so a caller like log.Fatal(app.Listen(addr)) doesn't exit before the exec; if the exec
+// fails, they return its error.
+func (g *Ghast) restart(binary string) {
+	g.restarting.Store(true)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := g.Shutdown(ctx); err != nil {
+		g.logger().Error("[DEV] Error during shutdown before restart", "err", err)
+	}
+
+	g.logger().Info("[DEV] Restarting...")
+	err := restartProcess(binary) // Returns only on failure
+	os.Remove(binary)
+	g.devRestart <- fmt.Errorf("ghast: dev restart failed: %w", err)
+}
+
+// awaitDevRestart returns err from Listen or Serve, unless they returned because the development watcher shut the
+// app down to restart it; then it waits for the restart and returns its error should the exec fail.
+func (g *Ghast) awaitDevRestart(err error) error {
+	if !g.dev || !g.restarting.Load() {
+		return err
+	}
+	return <-g.devRestart
+}
@@ -0,0 +1,11 @@
+//go:build !unix
+
+package ghast
+
+// restartSupported reports whether restartProcess can replace the running process.
+const restartSupported = false
+
+// restartProcess is not supported on platforms without exec; the developer has to restart manually.
+func restartProcess(binary string) error {
+	return errRestartUnsupported
+}
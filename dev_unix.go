@@ -0,0 +1,16 @@
+//go:build unix
+
+package ghast
+
+import (
+	"os"
+	"syscall"
+)
+
+// restartSupported reports whether restartProcess can replace the running process.
+const restartSupported = true
+
+// restartProcess replaces the current process with the given binary, keeping arguments and environment.
+func restartProcess(binary string) error {
+	return syscall.Exec(binary, os.Args, os.Environ())
+}
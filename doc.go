@@ -0,0 +1,11 @@
+// Package ghast is a lightweight, Express-inspired HTTP framework built directly on TCP.
+//
+// Everything lives in this single package: the Ghast application, its Router, the underlying server and
+// the core middleware types. Ready-made middleware is provided by the ghast/middleware package.
+//
+//	app := ghast.New()
+//	app.Get("/", ghast.HandlerFunc(func(w ghast.ResponseWriter, r *ghast.Request) {
+//		w.Plain(200, "Hello, World!")
+//	}))
+//	app.Listen(":8080")
+package ghast
@@ -1,11 +1,54 @@
 package ghast
 
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
 // HTTPError represents an HTTP error with status code and message.
+// It implements the error interface, so error-returning handlers can return it directly to control
+// the status code sent to the client.
 type HTTPError struct {
 	StatusCode int    `json:"status"`
 	Message    string `json:"error"`
 }
 
+// NewHTTPError creates an HTTPError with the given status code and message.
+// If message is empty, the standard status text is used (e.g., "Not Found").
+func NewHTTPError(statusCode int, message string) *HTTPError {
+	if message == "" {
+		message = httpStatusText(statusCode)
+	}
+	return &HTTPError{StatusCode: statusCode, Message: message}
+}
+
+// Error implements the error interface.
+func (e *HTTPError) Error() string {
+	return fmt.Sprintf("%d: %s", e.StatusCode, e.Message)
+}
+
+// Problem is an RFC 9457 "problem details" error. Returning a Problem from an error-returning handler
+// sends it with the application/problem+json content type.
+type Problem struct {
+	Type     string `json:"type,omitempty"`     // URI reference identifying the problem type
+	Title    string `json:"title"`              // Short, human-readable summary of the problem type
+	Status   int    `json:"status"`             // HTTP status code
+	Detail   string `json:"detail,omitempty"`   // Explanation specific to this occurrence
+	Instance string `json:"instance,omitempty"` // URI reference identifying this occurrence
+}
+
+// Error implements the error interface.
+func (p *Problem) Error() string {
+	if p.Detail != "" {
+		return fmt.Sprintf("%d %s: %s", p.Status, p.Title, p.Detail)
+	}
+	return fmt.Sprintf("%d %s", p.Status, p.Title)
+}
+
+// ErrorHandler maps an error returned by a handler to a response.
+type ErrorHandler func(err error, w ResponseWriter, r *Request)
+
 // Error sends an error response as JSON with the given status code and message.
 func Error(rw ResponseWriter, statusCode int, message string) error {
 	errResp := HTTPError{
@@ -21,3 +64,58 @@ func ErrorString(rw ResponseWriter, statusCode int, message string) error {
 	_, err := rw.SendString(message)
 	return err
 }
+
+// WriteProblem sends a problem details response with the application/problem+json content type.
+func WriteProblem(rw ResponseWriter, problem *Problem) error {
+	data, err := json.Marshal(problem)
+	if err != nil {
+		return err
+	}
+	rw.Status(problem.Status).SetHeader("Content-Type", "application/problem+json")
+	_, err = rw.Send(data)
+	return err
+}
+
+// DefaultErrorHandler is the error handler used when none is configured with SetErrorHandler.
+// HTTPError and Problem errors (including wrapped ones) are sent with their own status code;
+// a ValidationError is sent as 422 Unprocessable Entity with the validator's message;
+// any other error results in a generic 500 response so internal details are not leaked to clients.
+func DefaultErrorHandler(err error, w ResponseWriter, r *Request) {
+	var httpErr *HTTPError
+	if errors.As(err, &httpErr) {
+		Error(w, httpErr.StatusCode, httpErr.Message)
+		return
+	}
+
+	var problem *Problem
+	if errors.As(err, &problem) {
+		WriteProblem(w, problem)
+		return
+	}
+
+	var validationErr *ValidationError
+	if errors.As(err, &validationErr) {
+		Error(w, 422, validationErr.Error())
+		return
+	}
+
+	Error(w, 500, httpStatusText(500))
+}
+
+// SetErrorHandler sets the function used to turn errors returned by HandlerE handlers into responses.
+// Mounted sub-applications without their own error handler use their parent's. Returns the app for chaining.
+func (g *Ghast) SetErrorHandler(handler ErrorHandler) *Ghast {
+	g.errorHandler = handler
+	return g
+}
+
+// handleError resolves the error handler for the application handling the request and invokes it.
+func handleError(err error, w ResponseWriter, r *Request) {
+	for app := r.app; app != nil; app = app.parent {
+		if app.errorHandler != nil {
+			app.errorHandler(err, w, r)
+			return
+		}
+	}
+	DefaultErrorHandler(err, w, r)
+}
@@ -41,7 +41,7 @@ func BasicExampleApp() {
 	// POST endpoint that echoes the request body
 	app.Post("/echo", ghast.HandlerFunc(func(w ghast.ResponseWriter, r *ghast.Request) {
 		w.JSON(200, map[string]string{
-			"received": r.Body,
+			"received": string(r.Body),
 		})
 	}))
 
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"net/http"
+	"sync"
+
+	"github.com/Leonard-Atorough/ghast"
+)
+
+const sessionCookie = "todo_session"
+
+// sessions maps random session tokens, stored in a cookie, to the signed-in user.
+type sessions struct {
+	mu     sync.Mutex
+	tokens map[string]string
+}
+
+func newSessions() *sessions {
+	return &sessions{tokens: make(map[string]string)}
+}
+
+func (s *sessions) create(user string) string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	token := hex.EncodeToString(b)
+	s.mu.Lock()
+	s.tokens[token] = user
+	s.mu.Unlock()
+	return token
+}
+
+func (s *sessions) user(token string) (string, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	user, ok := s.tokens[token]
+	return user, ok
+}
+
+func (s *sessions) destroy(token string) {
+	s.mu.Lock()
+	delete(s.tokens, token)
+	s.mu.Unlock()
+}
+
+// sessionToken returns the session token sent in the request's cookie.
+func sessionToken(r *ghast.Request) string {
+	cookies, err := http.ParseCookie(r.GetHeader("Cookie"))
+	if err != nil {
+		return ""
+	}
+	for _, cookie := range cookies {
+		if cookie.Name == sessionCookie {
+			return cookie.Value
+		}
+	}
+	return ""
+}
+
+type userKey struct{}
+
+// currentUser returns the user stored on the request by requireUser.
+func currentUser(r *ghast.Request) string {
+	user, _ := r.Context().Value(userKey{}).(string)
+	return user
+}
+
+// requireUser rejects requests without a valid session and stores the signed-in user on the request context.
+func requireUser(s *sessions) ghast.Middleware {
+	return func(next ghast.Handler) ghast.Handler {
+		return ghast.HandlerFunc(func(w ghast.ResponseWriter, r *ghast.Request) {
+			user, ok := s.user(sessionToken(r))
+			if !ok {
+				ghast.Error(w, 401, "sign in required")
+				return
+			}
+			next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), userKey{}, user)))
+		})
+	}
+}
+
+// credentials are the accepted username/password pairs. A real application would look up hashed passwords.
+var credentials = map[string]string{
+	"alice": "wonderland",
+	"bob":   "builder",
+}
+
+type loginRequest struct {
+	Username string `json:"username"`
+	Password string `json:"password"`
+}
+
+// login checks the credentials and starts a session.
+func login(s *sessions) ghast.HandlerE {
+	return func(w ghast.ResponseWriter, r *ghast.Request) error {
+		var body loginRequest
+		if err := r.JSON(&body); err != nil {
+			return ghast.NewHTTPError(400, "invalid JSON body")
+		}
+		password, ok := credentials[body.Username]
+		if !ok || subtle.ConstantTimeCompare([]byte(password), []byte(body.Password)) != 1 {
+			return ghast.NewHTTPError(401, "invalid username or password")
+		}
+		token := s.create(body.Username)
+		cookie := &http.Cookie{Name: sessionCookie, Value: token, Path: "/", HttpOnly: true, SameSite: http.SameSiteLaxMode}
+		w.SetHeader("Set-Cookie", cookie.String())
+		return w.JSON(200, map[string]string{"user": body.Username})
+	}
+}
+
+// logout ends the current session.
+func logout(s *sessions) ghast.HandlerE {
+	return func(w ghast.ResponseWriter, r *ghast.Request) error {
+		s.destroy(sessionToken(r))
+		cookie := &http.Cookie{Name: sessionCookie, Path: "/", MaxAge: -1}
+		w.SetHeader("Set-Cookie", cookie.String())
+		w.Status(204)
+		return nil
+	}
+}
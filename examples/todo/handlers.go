@@ -0,0 +1,121 @@
+package main
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+
+	"github.com/Leonard-Atorough/ghast"
+)
+
+// todoHandlers serves the todo API and the HTML list page.
+type todoHandlers struct {
+	store Store
+}
+
+type todoInput struct {
+	Title *string `json:"title"`
+	Done  *bool   `json:"done"`
+}
+
+func (h *todoHandlers) list(w ghast.ResponseWriter, r *ghast.Request) error {
+	todos, err := h.store.List(r.Context(), currentUser(r))
+	if err != nil {
+		return err
+	}
+	return w.JSON(200, todos)
+}
+
+func (h *todoHandlers) get(w ghast.ResponseWriter, r *ghast.Request) error {
+	id, err := todoID(r)
+	if err != nil {
+		return err
+	}
+	todo, err := h.store.Get(r.Context(), currentUser(r), id)
+	if err != nil {
+		return storeError(err)
+	}
+	return w.JSON(200, todo)
+}
+
+func (h *todoHandlers) create(w ghast.ResponseWriter, r *ghast.Request) error {
+	var input todoInput
+	if err := r.JSON(&input); err != nil {
+		return ghast.NewHTTPError(400, "invalid JSON body")
+	}
+	if input.Title == nil || strings.TrimSpace(*input.Title) == "" {
+		return ghast.NewHTTPError(422, "title is required")
+	}
+	todo, err := h.store.Create(r.Context(), Todo{Owner: currentUser(r), Title: strings.TrimSpace(*input.Title)})
+	if err != nil {
+		return err
+	}
+	w.SetHeader("Location", "/api/todos/"+strconv.Itoa(todo.ID))
+	return w.JSON(201, todo)
+}
+
+func (h *todoHandlers) update(w ghast.ResponseWriter, r *ghast.Request) error {
+	id, err := todoID(r)
+	if err != nil {
+		return err
+	}
+	var input todoInput
+	if err := r.JSON(&input); err != nil {
+		return ghast.NewHTTPError(400, "invalid JSON body")
+	}
+	todo, err := h.store.Get(r.Context(), currentUser(r), id)
+	if err != nil {
+		return storeError(err)
+	}
+	if input.Title != nil {
+		if strings.TrimSpace(*input.Title) == "" {
+			return ghast.NewHTTPError(422, "title cannot be empty")
+		}
+		todo.Title = strings.TrimSpace(*input.Title)
+	}
+	if input.Done != nil {
+		todo.Done = *input.Done
+	}
+	if todo, err = h.store.Update(r.Context(), todo); err != nil {
+		return storeError(err)
+	}
+	return w.JSON(200, todo)
+}
+
+func (h *todoHandlers) delete(w ghast.ResponseWriter, r *ghast.Request) error {
+	id, err := todoID(r)
+	if err != nil {
+		return err
+	}
+	if err := h.store.Delete(r.Context(), currentUser(r), id); err != nil {
+		return storeError(err)
+	}
+	w.Status(204)
+	return nil
+}
+
+// page renders the signed-in user's list as HTML.
+func (h *todoHandlers) page(w ghast.ResponseWriter, r *ghast.Request) error {
+	todos, err := h.store.List(r.Context(), currentUser(r))
+	if err != nil {
+		return err
+	}
+	return w.Render(200, "index.html", map[string]any{"User": currentUser(r), "Todos": todos})
+}
+
+// todoID parses the :id route parameter.
+func todoID(r *ghast.Request) (int, error) {
+	id, err := strconv.Atoi(r.Param("id"))
+	if err != nil || id <= 0 {
+		return 0, ghast.NewHTTPError(400, "invalid todo id")
+	}
+	return id, nil
+}
+
+// storeError maps store errors to HTTP errors.
+func storeError(err error) error {
+	if errors.Is(err, ErrNotFound) {
+		return ghast.NewHTTPError(404, err.Error())
+	}
+	return err
+}
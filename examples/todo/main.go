@@ -0,0 +1,77 @@
+// Command todo is a complete example application: a JSON todo API with cookie sessions, authentication
+// middleware, a page rendered from embedded templates, rate limiting, graceful shutdown and tests written with
+// ghasttest.
+//
+// Run it from the repository root:
+//
+//	go run ./examples/todo
+//
+//	curl -c jar -d '{"username":"alice","password":"wonderland"}' localhost:8080/login
+//	curl -b jar -d '{"title":"buy milk"}' localhost:8080/api/todos
+//	curl -b jar localhost:8080/api/todos
+//
+// Todos are kept in memory. To persist them, implement Store with database/sql and a SQLite driver (for
+// example modernc.org/sqlite) and pass it to newApp in place of newMemoryStore.
+package main
+
+import (
+	"context"
+	"embed"
+	"log"
+	"os"
+	"time"
+
+	"github.com/Leonard-Atorough/ghast"
+	"github.com/Leonard-Atorough/ghast/middleware"
+)
+
+//go:embed views
+var views embed.FS
+
+func main() {
+	addr := ":8080"
+	if port := os.Getenv("PORT"); port != "" {
+		addr = ":" + port
+	}
+
+	app := newApp(newMemoryStore(), newSessions())
+
+	if err := app.ListenAndWaitForSignals(addr); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// newApp wires the routes, middleware and lifecycle hooks around the given store and sessions.
+func newApp(store Store, s *sessions) *ghast.Ghast {
+	app := ghast.New(ghast.WithGracefulShutdownTimeout(10 * time.Second))
+	app.Use(middleware.RequestIDMiddleware(middleware.RequestIDOptions{}))
+	app.Use(middleware.RecoveryMiddleware(middleware.Options{Log: true}))
+
+	app.OnShutdown(func(ctx context.Context) error {
+		return store.Close()
+	})
+
+	renderer, err := ghast.NewTemplateRenderer("views", ghast.TemplateOptions{FS: views})
+	if err != nil {
+		log.Fatal(err)
+	}
+	app.SetRenderer(renderer)
+
+	todos := &todoHandlers{store: store}
+
+	app.Get("/health", ghast.HandlerFunc(func(w ghast.ResponseWriter, r *ghast.Request) {
+		w.JSON(200, map[string]string{"status": "ok"})
+	}))
+	app.Post("/login", login(s), middleware.RateLimitMiddleware(middleware.RateLimitOptions{RequestsPerMinute: 10}))
+	app.Post("/logout", logout(s))
+	app.Get("/", ghast.HandlerE(todos.page), requireUser(s))
+
+	api := app.Group("/api", requireUser(s))
+	api.Get("/todos", ghast.HandlerE(todos.list))
+	api.Post("/todos", ghast.HandlerE(todos.create))
+	api.Get("/todos/:id", ghast.HandlerE(todos.get))
+	api.Patch("/todos/:id", ghast.HandlerE(todos.update))
+	api.Delete("/todos/:id", ghast.HandlerE(todos.delete))
+
+	return app
+}
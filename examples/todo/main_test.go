@@ -0,0 +1,69 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/Leonard-Atorough/ghast/ghasttest"
+)
+
+// signIn logs in as user and returns a client that sends the session cookie with every request.
+func signIn(t *testing.T, client *ghasttest.Client, user, password string) *ghasttest.Client {
+	t.Helper()
+	res := client.Post("/login").WithJSON(map[string]string{"username": user, "password": password}).Do().ExpectStatus(200)
+	cookie, _, _ := strings.Cut(res.Header("Set-Cookie"), ";")
+	return client.WithHeader("Cookie", cookie)
+}
+
+func TestTodoCRUD(t *testing.T) {
+	app := newApp(newMemoryStore(), newSessions())
+	client := signIn(t, ghasttest.NewClient(t, app), "alice", "wonderland")
+
+	client.Post("/api/todos").WithJSON(map[string]string{"title": "buy milk"}).Do().
+		ExpectStatus(201).
+		ExpectHeader("Location", "/api/todos/1").
+		ExpectBodyContains(`"title":"buy milk"`)
+
+	client.Patch("/api/todos/1").WithJSON(map[string]bool{"done": true}).Do().
+		ExpectStatus(200).
+		ExpectBodyContains(`"done":true`)
+
+	var todos []Todo
+	client.Get("/api/todos").Do().ExpectStatus(200).DecodeJSON(&todos)
+	if len(todos) != 1 || todos[0].Title != "buy milk" || !todos[0].Done {
+		t.Errorf("unexpected todos: %+v", todos)
+	}
+
+	client.Get("/").Do().ExpectStatus(200).ExpectBodyContains("<s>buy milk</s>")
+
+	client.Delete("/api/todos/1").Do().ExpectStatus(204)
+	client.Get("/api/todos/1").Do().ExpectStatus(404)
+}
+
+func TestTodoValidation(t *testing.T) {
+	app := newApp(newMemoryStore(), newSessions())
+	client := signIn(t, ghasttest.NewClient(t, app), "alice", "wonderland")
+
+	client.Post("/api/todos").WithJSON(map[string]string{"title": "  "}).Do().ExpectStatus(422)
+	client.Post("/api/todos").WithBody("{").Do().ExpectStatus(400)
+	client.Get("/api/todos/abc").Do().ExpectStatus(400)
+}
+
+func TestTodoAuth(t *testing.T) {
+	app := newApp(newMemoryStore(), newSessions())
+
+	ghasttest.NewClient(t, app).Get("/api/todos").Do().ExpectStatus(401)
+	ghasttest.NewClient(t, app).Post("/login").
+		WithJSON(map[string]string{"username": "alice", "password": "wrong"}).Do().
+		ExpectStatus(401)
+
+	alice := signIn(t, ghasttest.NewClient(t, app), "alice", "wonderland")
+	bob := signIn(t, ghasttest.NewClient(t, app), "bob", "builder")
+
+	alice.Post("/api/todos").WithJSON(map[string]string{"title": "secret"}).Do().ExpectStatus(201)
+	bob.Get("/api/todos/1").Do().ExpectStatus(404)
+	bob.Get("/api/todos").Do().ExpectStatus(200).ExpectJSON([]any{})
+
+	alice.Post("/logout").Do().ExpectStatus(204)
+	alice.Get("/api/todos").Do().ExpectStatus(401)
+}
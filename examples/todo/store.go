@@ -0,0 +1,102 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sync"
+	"time"
+)
+
+// ErrNotFound is returned by a Store when a todo doesn't exist or belongs to another user.
+var ErrNotFound = errors.New("todo not found")
+
+// Todo is a single item on a user's list.
+type Todo struct {
+	ID        int       `json:"id"`
+	Owner     string    `json:"-"`
+	Title     string    `json:"title"`
+	Done      bool      `json:"done"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// Store persists todos. The example ships an in-memory implementation; a database/sql implementation backed
+// by SQLite (or any other database) satisfies the same interface and is swapped in from main.
+type Store interface {
+	List(ctx context.Context, owner string) ([]Todo, error)
+	Get(ctx context.Context, owner string, id int) (Todo, error)
+	Create(ctx context.Context, todo Todo) (Todo, error)
+	Update(ctx context.Context, todo Todo) (Todo, error)
+	Delete(ctx context.Context, owner string, id int) error
+	Close() error
+}
+
+// memoryStore is a Store that keeps todos in memory, for development and tests.
+type memoryStore struct {
+	mu     sync.Mutex
+	nextID int
+	todos  map[int]Todo
+}
+
+func newMemoryStore() *memoryStore {
+	return &memoryStore{nextID: 1, todos: make(map[int]Todo)}
+}
+
+func (s *memoryStore) List(ctx context.Context, owner string) ([]Todo, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	todos := []Todo{}
+	for _, todo := range s.todos {
+		if todo.Owner == owner {
+			todos = append(todos, todo)
+		}
+	}
+	slices.SortFunc(todos, func(a, b Todo) int { return a.ID - b.ID })
+	return todos, nil
+}
+
+func (s *memoryStore) Get(ctx context.Context, owner string, id int) (Todo, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	todo, ok := s.todos[id]
+	if !ok || todo.Owner != owner {
+		return Todo{}, ErrNotFound
+	}
+	return todo, nil
+}
+
+func (s *memoryStore) Create(ctx context.Context, todo Todo) (Todo, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	todo.ID = s.nextID
+	todo.CreatedAt = time.Now().UTC()
+	s.nextID++
+	s.todos[todo.ID] = todo
+	return todo, nil
+}
+
+func (s *memoryStore) Update(ctx context.Context, todo Todo) (Todo, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	existing, ok := s.todos[todo.ID]
+	if !ok || existing.Owner != todo.Owner {
+		return Todo{}, ErrNotFound
+	}
+	todo.CreatedAt = existing.CreatedAt
+	s.todos[todo.ID] = todo
+	return todo, nil
+}
+
+func (s *memoryStore) Delete(ctx context.Context, owner string, id int) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if todo, ok := s.todos[id]; !ok || todo.Owner != owner {
+		return ErrNotFound
+	}
+	delete(s.todos, id)
+	return nil
+}
+
+func (s *memoryStore) Close() error {
+	return nil
+}
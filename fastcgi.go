@@ -0,0 +1,105 @@
+package ghast
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/fcgi"
+	"sync"
+)
+
+// ListenFastCGI runs the start hooks and serves the application over FastCGI on the given TCP address, for
+// deployments behind a front-end such as nginx (fastcgi_pass) or Apache (mod_proxy_fcgi). The CGI parameters
+// sent by the front-end (REQUEST_METHOD, REQUEST_URI, HTTP_* headers, REMOTE_ADDR and so on) are translated into
+// ghast Requests. Like Listen, it blocks until Shutdown is called.
+//
+// Example:
+//
+//	// nginx: location / { include fastcgi_params; fastcgi_pass 127.0.0.1:9000; }
+//	log.Fatal(app.ListenFastCGI("127.0.0.1:9000"))
+func (g *Ghast) ListenFastCGI(addr string) error {
+	ln, err := g.server.listen(addr)
+	if err != nil {
+		return err
+	}
+	return g.ServeFastCGI(ln)
+}
+
+// ServeFastCGI is like ListenFastCGI but serves FastCGI connections accepted on ln, such as a Unix socket
+// listener shared with the front-end. The listener is closed when ServeFastCGI returns.
+func (g *Ghast) ServeFastCGI(ln net.Listener) error {
+	if err := g.runStartHooks(context.Background()); err != nil {
+		ln.Close()
+		return err
+	}
+	if g.dev {
+		go g.watchFiles(g.devStop)
+	}
+	handler := ToHTTP(g)
+	if limit := g.config.MaxRequestBodySize; limit > 0 {
+		handler = http.MaxBytesHandler(handler, int64(limit))
+	}
+	return g.server.serveFastCGI(ln, handler)
+}
+
+// serveFastCGI serves FastCGI connections accepted on ln with handler until Shutdown is called. Connections are
+// tracked like HTTP connections, so Shutdown waits for in-flight requests.
+func (s *server) serveFastCGI(ln net.Listener, handler http.Handler) error {
+	defer ln.Close()
+	if s.addr == "" {
+		s.addr = ln.Addr().String()
+	}
+
+	s.mu.Lock()
+	if s.isDone {
+		s.mu.Unlock()
+		return nil
+	}
+	s.listener = ln
+	s.mu.Unlock()
+
+	notifyReady()
+	if s.onListen != nil {
+		s.onListen(ln.Addr())
+	} else {
+		s.config.logger().Info("🌪️  Ghast FastCGI server listening", "addr", s.addr)
+	}
+
+	err := fcgi.Serve(&trackingListener{Listener: ln, server: s}, handler)
+	if s.shuttingDown() || errors.Is(err, net.ErrClosed) {
+		return nil
+	}
+	return err
+}
+
+// trackingListener registers accepted connections with the server so Shutdown can wait for them.
+type trackingListener struct {
+	net.Listener
+	server *server
+}
+
+func (l *trackingListener) Accept() (net.Conn, error) {
+	conn, err := l.Listener.Accept()
+	if err != nil {
+		return nil, err
+	}
+	if !l.server.trackConn(conn, true) {
+		conn.Close()
+		return nil, net.ErrClosed
+	}
+	return &trackedConn{Conn: conn, server: l.server}, nil
+}
+
+// trackedConn removes itself from the server's open connections when closed.
+type trackedConn struct {
+	net.Conn
+	server *server
+	once   sync.Once
+}
+
+func (c *trackedConn) Close() error {
+	err := c.Conn.Close()
+	c.once.Do(func() { c.server.trackConn(c.Conn, false) })
+	return err
+}
@@ -0,0 +1,94 @@
+package ghast
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"io"
+	"net"
+	"strings"
+	"testing"
+)
+
+// TestServeFastCGI tests that FastCGI parameters are translated into a ghast request and the response is
+// returned on the stdout stream
+func TestServeFastCGI(t *testing.T) {
+	app := New()
+	app.Post("/users/:id", HandlerFunc(func(w ResponseWriter, r *Request) {
+		w.JSON(201, map[string]string{"id": r.Param("id"), "q": r.Query("q"), "body": string(r.Body), "ip": r.ClientIP, "token": r.GetHeader("X-Token")})
+	}))
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen failed: %v", err)
+	}
+	app.server.onListen = func(net.Addr) {}
+	go app.ServeFastCGI(ln)
+	defer app.Shutdown(context.Background())
+
+	conn, err := net.Dial("tcp", ln.Addr().String())
+	if err != nil {
+		t.Fatalf("dial failed: %v", err)
+	}
+	defer conn.Close()
+
+	body := "hello"
+	writeFCGIRecord(conn, 1, []byte{0, 1, 0, 0, 0, 0, 0, 0}) // FCGI_BEGIN_REQUEST, responder role
+	writeFCGIRecord(conn, 4, fcgiParams(map[string]string{
+		"REQUEST_METHOD":  "POST",
+		"REQUEST_URI":     "/users/42?q=go",
+		"SERVER_PROTOCOL": "HTTP/1.1",
+		"HTTP_HOST":       "example.com",
+		"HTTP_X_TOKEN":    "secret",
+		"CONTENT_LENGTH":  "5",
+		"REMOTE_ADDR":     "203.0.113.9",
+		"REMOTE_PORT":     "5000",
+	}))
+	writeFCGIRecord(conn, 4, nil)
+	writeFCGIRecord(conn, 5, []byte(body))
+	writeFCGIRecord(conn, 5, nil)
+
+	var stdout bytes.Buffer
+	for {
+		header := make([]byte, 8)
+		if _, err := io.ReadFull(conn, header); err != nil {
+			t.Fatalf("read record failed: %v", err)
+		}
+		content := make([]byte, int(binary.BigEndian.Uint16(header[4:6]))+int(header[6]))
+		io.ReadFull(conn, content)
+		if header[1] == 3 { // FCGI_END_REQUEST
+			break
+		}
+		if header[1] == 6 { // FCGI_STDOUT
+			stdout.Write(content[:binary.BigEndian.Uint16(header[4:6])])
+		}
+	}
+
+	out := stdout.String()
+	if !strings.Contains(out, "Status: 201 Created") || !strings.Contains(out, "Content-Type: application/json") {
+		t.Errorf("unexpected response head: %q", out)
+	}
+	expected := `{"body":"hello","id":"42","ip":"203.0.113.9","q":"go","token":"secret"}`
+	if !strings.HasSuffix(out, expected) {
+		t.Errorf("expected body %s, got %q", expected, out)
+	}
+}
+
+// writeFCGIRecord writes a FastCGI record of the given type for request ID 1.
+func writeFCGIRecord(w io.Writer, recType byte, content []byte) {
+	header := []byte{1, recType, 0, 1, 0, 0, 0, 0}
+	binary.BigEndian.PutUint16(header[4:6], uint16(len(content)))
+	w.Write(append(header, content...))
+}
+
+// fcgiParams encodes FastCGI name-value pairs, all shorter than 128 bytes.
+func fcgiParams(params map[string]string) []byte {
+	var buf bytes.Buffer
+	for name, value := range params {
+		buf.WriteByte(byte(len(name)))
+		buf.WriteByte(byte(len(value)))
+		buf.WriteString(name)
+		buf.WriteString(value)
+	}
+	return buf.Bytes()
+}
@@ -0,0 +1,73 @@
+package ghast
+
+import (
+	"strings"
+	"testing"
+)
+
+// FuzzParseRequest checks that arbitrary request heads never panic and that accepted requests are well-formed
+func FuzzParseRequest(f *testing.F) {
+	f.Add("GET / HTTP/1.1")
+	f.Add("GET /users/42?fields=name&sort=asc HTTP/1.1\r\nHost: localhost\r\nAccept: */*")
+	f.Add("POST /users HTTP/1.1\r\nContent-Type: application/json\r\nContent-Length: 16")
+	f.Add("GET /?a HTTP/1.1")
+	f.Add("GET /?a=1&&b=2 HTTP/1.1")
+	f.Add("GET / HTTP/1.1\r\nHost:localhost")
+	f.Add("GET  /  HTTP/1.1")
+	f.Add("\r\n\r\n")
+
+	f.Fuzz(func(t *testing.T, raw string) {
+		req, err := ParseRequest(raw)
+		if err != nil {
+			return
+		}
+		if req.Method == "" || req.Version == "" {
+			t.Errorf("accepted request with empty method or version: %q", raw)
+		}
+		if strings.Contains(req.Path, "?") {
+			t.Errorf("query string left in path %q", req.Path)
+		}
+		if req.Headers == nil {
+			t.Errorf("accepted request with nil headers: %q", raw)
+		}
+	})
+}
+
+// FuzzParseHeaders checks that header parsing never panics and never accepts line breaks in names or values
+func FuzzParseHeaders(f *testing.F) {
+	f.Add("Host: localhost\r\nAccept: */*")
+	f.Add("Content-Length: 10")
+	f.Add("X-Empty: ")
+	f.Add("Bad Header: value")
+	f.Add(": no-name")
+
+	f.Fuzz(func(t *testing.T, raw string) {
+		headers, err := parseHeaders(strings.Split(raw, CRLF))
+		if err != nil {
+			return
+		}
+		for name, value := range headers {
+			if strings.ContainsAny(name, "\r\n: \t") || strings.ContainsAny(value, "\r\n") {
+				t.Errorf("accepted invalid header %q: %q", name, value)
+			}
+		}
+	})
+}
+
+// FuzzParseParams checks that query parsing never panics and returns a usable map
+func FuzzParseParams(f *testing.F) {
+	f.Add("a=1&b=2")
+	f.Add("a=1&a=2")
+	f.Add("empty=")
+	f.Add("=value")
+	f.Add("novalue")
+	f.Add("a=b=c")
+	f.Add("q=%20%zz")
+
+	f.Fuzz(func(t *testing.T, raw string) {
+		params, err := parseParams(raw)
+		if err == nil && params == nil {
+			t.Errorf("parseParams(%q) returned nil map without error", raw)
+		}
+	})
+}
@@ -1,8 +1,20 @@
 package ghast
 
 import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"os/signal"
+	"slices"
 	"sort"
 	"strings"
+	"sync"
+	"sync/atomic"
+	"syscall"
 )
 
 const Version = "0.5.0"
@@ -10,14 +22,50 @@ const Version = "0.5.0"
 type Ghast struct {
 	config     *serverConfig
 	rootRouter Router
-	routers    []routeGroup
+	routers    []routeGroup // Mounted routers and sub-applications, in registration order
 	server     *server
 
+	mountChains []mountChain // Mounted handlers wrapped in their middleware, longest prefix first
+	rootChain   Handler      // Root router wrapped in the app middleware
+
 	middlewares []Middleware
+
+	startHooks    []Hook
+	shutdownHooks []Hook
+
+	state appState // Shared dependencies registered with Set
+
+	errorHandler            ErrorHandler // Maps errors returned by HandlerE handlers to responses
+	notFoundHandler         Handler      // Handles requests that match no route
+	methodNotAllowedHandler Handler      // Handles requests whose path matches a route registered for other methods
+	renderer                Renderer     // Renders views for ResponseWriter.Render
+	validator               Validator    // Validates values for Request.Validate and Request.Bind
+
+	dev         bool                     // Development mode, enabled with Dev
+	reloadHooks []func(changed []string) // Hooks invoked by the development watcher, registered with OnReload
+	devStop     chan struct{}            // Closed on shutdown to stop the development watcher
+	devStopOnce sync.Once                // Ensures devStop is closed only once
+	devRestart  chan error               // Receives the error of a failed development restart, keeping Listen blocked until then
+	restarting  atomic.Bool              // Set once the development watcher shuts the app down to restart it
+
+	openAPIInfo  OpenAPIInfo          // Metadata of the generated OpenAPI document
+	operations   map[string]Operation // OpenAPI annotations keyed by "METHOD /path"
+	undocumented []string             // Documentation routes excluded from the generated document
+
+	versions      []string // API versions registered with Version, in registration order
+	versionHeader string   // Header used to select a version for unversioned paths, set with SetVersionHeader
+	acceptVersion bool     // Whether the Accept header selects a version for unversioned paths, set with SetVersionFromAccept
+
+	parent  *Ghast   // Application this app is mounted in, if any
+	mounted []*Ghast // Sub-applications mounted with Mount
 }
 
+// Hook is a lifecycle callback registered with OnStart or OnShutdown. Hooks receive a context that
+// carries any deadline imposed by the caller and should return promptly once it is done.
+type Hook func(ctx context.Context) error
+
 // New creates and returns a new Server instance, ready for route registration and listening.
-// This is the primary entry point for the Ghast framework.
+// This is the primary entry point for the Ghast framework. Options configure the underlying server.
 // Example usage:
 //
 //	app := ghast.New()
@@ -25,13 +73,22 @@ type Ghast struct {
 //	    w.SendString("Hello, World!")
 //	})
 //	app.Listen(":8080")
-func New() *Ghast {
-	return &Ghast{
-		config:      &serverConfig{},
-		rootRouter:  NewRouter(),
+func New(opts ...Option) *Ghast {
+	config := defaultServerConfig()
+	for _, opt := range opts {
+		opt(config)
+	}
+
+	g := &Ghast{
+		config:      config,
+		rootRouter:  NewRouterWithOptions(config.RouterOptions),
 		routers:     []routeGroup{},
 		middlewares: []Middleware{},
 	}
+	g.compileChains()
+	g.server = newServer(g, g.config)
+	g.server.onListen = g.printStartupReport
+	return g
 }
 
 // Router returns the root Router instance for direct route registration. This allows you to register routes directly on the main router without needing to create sub-routers or groups.
@@ -57,17 +114,87 @@ func (g *Ghast) Router() Router {
 //
 // The Route method takes a path prefix, a Router instance, and an optional list of middleware functions that will be applied to all routes within the mounted router. The mounted router's routes will be accessible under the specified path prefix.
 func (g *Ghast) Route(prefix string, router Router, middlewares ...Middleware) *Ghast {
-	rg := &routeGroup{
+	g.addRouteGroup(routeGroup{
 		prefix:      prefix,
 		middlewares: middlewares,
-		router:      router,
+		handler:     router,
+	})
+	return g
+}
+
+// Mount attaches a complete sub-application under a path prefix, so features can be built and shipped as
+// self-contained modules. Requests under the prefix pass through this app's middleware, then the optional
+// mount middleware, then the sub-application's own middleware and routers, with the prefix stripped.
+//
+// The sub-application falls back to this app for values registered with Set, and its lifecycle hooks run
+// when this app starts and shuts down. An application can only be mounted once.
+//
+// Example:
+//
+//	billing := ghast.New()
+//	billing.Use(billingAuth)
+//	billing.Get("/invoices", listInvoices)
+//
+//	app.Mount("/billing", billing) // GET /billing/invoices
+func (g *Ghast) Mount(prefix string, app *Ghast, middlewares ...Middleware) *Ghast {
+	if app == g {
+		panic("ghast: cannot mount an application inside itself")
+	}
+	if app.parent != nil {
+		panic("ghast: application is already mounted")
 	}
-	g.routers = append(g.routers, *rg)
+	app.parent = g
+	g.mounted = append(g.mounted, app)
+	g.addRouteGroup(routeGroup{
+		prefix:      prefix,
+		middlewares: middlewares,
+		handler:     app,
+	})
 	return g
 }
 
+// addRouteGroup registers a mounted router or sub-application and recompiles the dispatch chains.
+func (g *Ghast) addRouteGroup(rg routeGroup) {
+	g.routers = append(g.routers, rg)
+	g.compileChains()
+}
+
+// mountChain is a mounted router or sub-application composed with its mount and app middleware.
+type mountChain struct {
+	prefix  string
+	handler Handler
+}
+
+// compileChains composes the middleware chains used by dispatch, so requests reuse prebuilt handlers instead
+// of wrapping every layer again. It runs whenever middleware or mounts are registered. Mounts are ordered by
+// descending prefix length; mounts with equal-length prefixes keep their registration order.
+func (g *Ghast) compileChains() {
+	chains := make([]mountChain, len(g.routers))
+	for i, rg := range g.routers {
+		chains[i] = mountChain{
+			prefix:  rg.prefix,
+			handler: chainMiddleware(chainMiddleware(rg.handler, rg.middlewares), g.middlewares),
+		}
+	}
+	sort.SliceStable(chains, func(i, j int) bool {
+		return len(chains[i].prefix) > len(chains[j].prefix)
+	})
+	g.mountChains = chains
+	g.rootChain = chainMiddleware(g.rootRouter, g.middlewares)
+}
+
 func (g *Ghast) Use(middleware Middleware) *Ghast {
 	g.middlewares = append(g.middlewares, middleware)
+	g.compileChains()
+	return g
+}
+
+// UsePath adds middleware to the root router for the routes whose path matches pattern; see Router.UsePath.
+// Returns the server for chaining.
+//
+//	app.UsePath("/admin/*", requireAdmin)
+func (g *Ghast) UsePath(pattern string, middleware Middleware) *Ghast {
+	g.rootRouter.UsePath(pattern, middleware)
 	return g
 }
 
@@ -113,54 +240,387 @@ func (g *Ghast) Options(path string, handler Handler, middlewares ...Middleware)
 	return g
 }
 
+// Any registers a handler for every method on the root router at the entry point. Returns the server for chaining.
+func (g *Ghast) Any(path string, handler Handler, middlewares ...Middleware) *Ghast {
+	g.rootRouter.Any(path, handler, middlewares...)
+	return g
+}
+
+// Match registers a handler for each of the given methods on the root router at the entry point. Returns the
+// server for chaining.
+//
+//	app.Match([]string{ghast.GET, ghast.POST}, "/form", handleForm)
+func (g *Ghast) Match(methods []string, path string, handler Handler, middlewares ...Middleware) *Ghast {
+	g.rootRouter.Match(methods, path, handler, middlewares...)
+	return g
+}
+
+// Resource registers the RESTful routes of a controller on the root router (see Router.Resource). Returns the
+// server for chaining.
+//
+//	app.Resource("/users", &UserController{db: db})
+func (g *Ghast) Resource(path string, controller any, middlewares ...Middleware) *Ghast {
+	g.rootRouter.Resource(path, controller, middlewares...)
+	return g
+}
+
+// Redirect registers a route on the root router redirecting requests for from to to with the given status code
+// (see Router.Redirect). Returns the server for chaining.
+//
+//	app.Redirect("/blog/:slug", "/articles/:slug", 301)
+func (g *Ghast) Redirect(from, to string, code int) *Ghast {
+	g.rootRouter.Redirect(from, to, code)
+	return g
+}
+
+// Host routes requests whose Host header matches pattern to router instead of the root router's own routes,
+// so one listener can serve several virtual hosts. Mounted routers and sub-applications are matched first.
+// Returns the server for chaining.
+//
+//	app.Host("api.example.com", apiRouter)
+//	app.Host("*.tenants.example.com", tenantRouter)
+func (g *Ghast) Host(pattern string, router Router) *Ghast {
+	g.rootRouter.Host(pattern, router)
+	return g
+}
+
+// NotFound sets the handler invoked when a request matches no route, in the root router and in every
+// mounted router or sub-application (unless the sub-application sets its own). Returns the app for chaining.
+func (g *Ghast) NotFound(handler Handler) *Ghast {
+	g.notFoundHandler = handler
+	return g
+}
+
+// MethodNotAllowed sets the handler invoked when a request's path matches a route but its method does not.
+// The Allow header listing the registered methods is set before the handler runs. Like NotFound, it applies
+// across the root router and all mounted routers. Returns the app for chaining.
+func (g *Ghast) MethodNotAllowed(handler Handler) *Ghast {
+	g.methodNotAllowedHandler = handler
+	return g
+}
+
+// OnStart registers a hook that runs before the server starts accepting connections. Hooks run in
+// registration order; if one returns an error, Listen returns that error without opening a listener.
+// Use this to initialize database pools, warm caches or start background workers.
+func (g *Ghast) OnStart(hook Hook) *Ghast {
+	g.startHooks = append(g.startHooks, hook)
+	return g
+}
+
+// OnShutdown registers a hook that runs when the application is shut down via Shutdown.
+// Hooks run in reverse registration order (like deferred calls), so resources are torn down in the
+// opposite order to which they were set up. Every hook runs even if an earlier one fails.
+func (g *Ghast) OnShutdown(hook Hook) *Ghast {
+	g.shutdownHooks = append(g.shutdownHooks, hook)
+	return g
+}
+
+// Listen runs the start hooks and serves HTTP on the given address until Shutdown is called. The address is a
+// TCP address such as ":8080", or a Unix domain socket path prefixed with "unix:", such as
+// "unix:/var/run/ghast.sock", for serving a local reverse proxy or sidecar. A stale socket file is replaced,
+// and the socket file is removed on shutdown; its permissions can be set with WithUnixSocketMode.
 func (g *Ghast) Listen(addr string) error {
-	if g.server == nil {
-		g.server = newServer(g, g.config)
+	if err := g.runStartHooks(context.Background()); err != nil {
+		return err
+	}
+	if g.dev {
+		go g.watchFiles(g.devStop)
 	}
-	return g.server.Listen(addr)
+	return g.awaitDevRestart(g.server.Listen(addr))
 }
 
-func (g *Ghast) handleRequest(rw ResponseWriter, req *Request) {
-	var prefixes []string
-	for _, rg := range g.routers {
-		prefixes = append(prefixes, rg.prefix)
+// Serve runs the start hooks and then serves connections accepted on ln until Shutdown is called, like Listen
+// but with a listener created by the caller (e.g. on a random port, one inherited through systemd socket
+// activation with net.FileListener, one from a TLS library, or an in-memory one handing over net.Pipe conns).
+// The listener is closed when Serve returns.
+func (g *Ghast) Serve(ln net.Listener) error {
+	if err := g.runStartHooks(context.Background()); err != nil {
+		ln.Close()
+		return err
 	}
-	sort.Slice(prefixes, func(i, j int) bool {
-		return len(prefixes[i]) > len(prefixes[j])
-	})
+	if g.dev {
+		go g.watchFiles(g.devStop)
+	}
+	return g.awaitDevRestart(g.server.Serve(ln))
+}
 
-	var matchedRouter Router = nil
-	var matchedPrefix string
-	for _, prefix := range prefixes {
-		if strings.HasPrefix(req.Path, prefix) && (prefix == "/" || len(req.Path) == len(prefix) || req.Path[len(prefix)] == '/') {
-			for _, rg := range g.routers {
-				if rg.prefix == prefix {
-					matchedRouter = rg.router
-					matchedPrefix = prefix
-					break
-				}
+// ServeConn serves HTTP requests read from conn until the client closes it or asks to close it, then closes
+// conn. It blocks while serving. Start hooks are not run, so ServeConn is suited to custom listeners and to
+// in-memory connections in tests (see the ghasttest package). Shutdown waits for connections served this way.
+func (g *Ghast) ServeConn(conn net.Conn) {
+	g.server.handleConnection(conn)
+}
+
+// Shutdown gracefully shuts the application down: the server stops accepting new connections, closes idle
+// keep-alive connections and waits for the requests in flight to finish, then the registered OnShutdown hooks
+// run. The context bounds the whole operation, and the server's wait is further bounded by the graceful
+// shutdown timeout (30 seconds by default); when either is done, remaining connections are force-closed and
+// the context error is reported. Errors from the server and from every hook are joined into the returned
+// error, which is also passed to the OnShutdownError callback of the server configuration.
+//
+// Example:
+//
+//	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+//	defer cancel()
+//	if err := app.Shutdown(ctx); err != nil {
+//	    log.Printf("shutdown: %v", err)
+//	}
+func (g *Ghast) Shutdown(ctx context.Context) error {
+	var errs []error
+	if g.dev {
+		g.devStopOnce.Do(func() { close(g.devStop) })
+	}
+	if err := g.server.Shutdown(ctx); err != nil {
+		errs = append(errs, err)
+	}
+	if err := g.runShutdownHooks(ctx); err != nil {
+		errs = append(errs, err)
+	}
+	err := errors.Join(errs...)
+	if err != nil && g.config.OnShutdownError != nil {
+		g.config.OnShutdownError(err)
+	}
+	return err
+}
+
+// ListenAndWaitForSignals serves on addr like Listen until one of the given signals arrives (os.Interrupt and
+// SIGTERM if none are given), then shuts the application down gracefully with Shutdown, bounded by the graceful
+// shutdown timeout set with WithGracefulShutdownTimeout. On Unix, SIGUSR2 restarts the application with Restart
+// instead, handing its listener over to a new process. It returns the error from listening, or from shutting
+// down. Once shutdown starts, the signals get their default behavior back, so a second Ctrl-C stops the process
+// at once.
+//
+// Example:
+//
+//	if err := app.ListenAndWaitForSignals(":8080"); err != nil {
+//	    log.Fatal(err)
+//	}
+func (g *Ghast) ListenAndWaitForSignals(addr string, signals ...os.Signal) error {
+	if len(signals) == 0 {
+		signals = []os.Signal{os.Interrupt, syscall.SIGTERM}
+	}
+	received := make(chan os.Signal, 1)
+	signal.Notify(received, signals...)
+	defer signal.Stop(received)
+	restart := make(chan os.Signal, 1)
+	if restartSignal != nil {
+		signal.Notify(restart, restartSignal)
+		defer signal.Stop(restart)
+	}
+
+	served := make(chan error, 1)
+	go func() { served <- g.Listen(addr) }()
+
+	for {
+		select {
+		case err := <-served:
+			return err
+		case sig := <-restart:
+			g.logger().Info("Restarting", "signal", sig.String())
+			err := g.Restart()
+			if err != nil && !g.server.shuttingDown() {
+				// The new process didn't start: keep serving
+				g.logger().Error("Restart failed", "err", err)
+				continue
 			}
-			break
+			return errors.Join(<-served, err)
+		case sig := <-received:
+			signal.Stop(received)
+			g.logger().Info("Shutting down", "signal", sig.String())
+			err := g.Shutdown(context.Background())
+			return errors.Join(<-served, err)
+		}
+	}
+}
+
+// runStartHooks runs the OnStart hooks in registration order, followed by those of mounted
+// sub-applications, stopping at the first failure.
+func (g *Ghast) runStartHooks(ctx context.Context) error {
+	for i, hook := range g.startHooks {
+		if err := hook(ctx); err != nil {
+			return fmt.Errorf("ghast: start hook %d failed: %w", i, err)
+		}
+	}
+	for _, app := range g.mounted {
+		if err := app.runStartHooks(ctx); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// runShutdownHooks runs the OnShutdown hooks of mounted sub-applications, then this app's hooks in
+// reverse registration order, and joins their errors.
+func (g *Ghast) runShutdownHooks(ctx context.Context) error {
+	var errs []error
+	for i := len(g.mounted) - 1; i >= 0; i-- {
+		if err := g.mounted[i].runShutdownHooks(ctx); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	for i := len(g.shutdownHooks) - 1; i >= 0; i-- {
+		if err := g.shutdownHooks[i](ctx); err != nil {
+			errs = append(errs, fmt.Errorf("ghast: shutdown hook %d failed: %w", i, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Test runs a request through the full application pipeline (mounted routers, middleware and routing)
+// without opening a socket, and returns the response exactly as it would have been written to the client.
+// This is the foundation for fast handler tests:
+//
+//	res := app.Test(&ghast.Request{Method: "GET", Path: "/users/42?verbose=true"})
+//	if res.StatusCode != 200 {
+//	    t.Fatalf("unexpected status %d", res.StatusCode)
+//	}
+//
+// Missing Headers are initialized, a query string in Path is parsed into Queries and the path is cleaned, as
+// the server would.
+func (g *Ghast) Test(req *Request) *Response {
+	if req.Headers == nil {
+		req.Headers = make(map[string]string)
+	}
+	canonicalizeHeaders(req.Headers)
+	if path, rawQuery, found := strings.Cut(req.Path, "?"); found {
+		req.Path = path
+		if req.Queries == nil {
+			req.Queries, _ = parseParams(rawQuery)
+			req.rawQuery = rawQuery
 		}
 	}
+	if req.Version == "" {
+		req.Version = HTTPVersion
+	}
+
+	var buf bytes.Buffer
+	rw := newResponseWriter(&buf)
+	rw.(*responseWriter).req = req
+	if err := req.cleanPath(); err != nil {
+		rw.Status(400).SetHeader("Connection", "close").SetHeader("Content-Type", "text/plain")
+		rw.SendString("400 Bad Request")
+	} else {
+		g.handleRequest(rw, req)
+	}
+	rw.(*responseWriter).finish()
+
+	res, err := parseResponse(buf.Bytes())
+	if err != nil {
+		// The response writer always produces a well-formed head, so this only happens if a
+		// handler bypassed it; surface the raw output rather than losing it.
+		return &Response{StatusCode: 500, Status: "500 " + httpStatusText(500), Headers: map[string]string{}, Body: buf.String()}
+	}
+	return res
+}
+
+// Routes returns a description of every route the application serves, including grouped routes and the
+// routes of mounted routers and sub-applications (with their mount prefix applied). Middleware counts include
+// app-level and mount-level middleware.
+func (g *Ghast) Routes() []RouteInfo {
+	routes := g.rootRouter.Routes()
+	for i := range routes {
+		routes[i].Middlewares += len(g.middlewares)
+	}
+
+	for _, rg := range g.routers {
+		var mounted []RouteInfo
+		switch h := rg.handler.(type) {
+		case *Ghast:
+			mounted = h.Routes()
+		case Router:
+			mounted = h.Routes()
+		}
+		for _, route := range mounted {
+			route.Path = joinPaths(rg.prefix, route.Path)
+			route.Mount = joinPaths(rg.prefix, route.Mount)
+			route.Middlewares += len(rg.middlewares) + len(g.middlewares)
+			routes = append(routes, route)
+		}
+	}
+
+	sortRoutes(routes)
+	return routes
+}
+
+// DumpRoutes writes every route the application serves, as returned by Routes, and the prefixes of its mounted
+// routers and sub-applications to w as an indented JSON document.
+//
+//	f, _ := os.Create("routes.json")
+//	app.DumpRoutes(f)
+func (g *Ghast) DumpRoutes(w io.Writer) error {
+	mounts := g.mountPrefixes()
+	slices.Sort(mounts)
+	return dumpRoutes(w, routeTable{Routes: g.Routes(), Mounts: mounts})
+}
 
-	if matchedRouter != nil {
-		// Strip the prefix from the path before passing to the router
-		originalPath := req.Path
-		if matchedPrefix != "/" {
-			req.Path = strings.TrimPrefix(req.Path, matchedPrefix)
-			if req.Path == "" {
-				req.Path = "/"
+// mountPrefixes returns the prefixes of the routers and sub-applications mounted on the application, including
+// those mounted inside sub-applications.
+func (g *Ghast) mountPrefixes() []string {
+	var prefixes []string
+	for _, rg := range g.routers {
+		prefix := joinPaths("", rg.prefix)
+		prefixes = append(prefixes, prefix)
+		if app, ok := rg.handler.(*Ghast); ok {
+			for _, nested := range app.mountPrefixes() {
+				prefixes = append(prefixes, joinPaths(prefix, nested))
 			}
 		}
+	}
+	return prefixes
+}
+
+// ServeHTTP implements the Handler interface, dispatching the request through the application's
+// mounted routers, middleware and root router. This is what allows one app to be mounted in another.
+func (g *Ghast) ServeHTTP(rw ResponseWriter, req *Request) {
+	g.handleRequest(rw, req)
+}
 
-		routerWithMiddleware := chainMiddleware(matchedRouter, g.middlewares)
-		routerWithMiddleware.ServeHTTP(rw, req)
+// serverMethods is the Allow header sent in response to OPTIONS *.
+const serverMethods = "GET, HEAD, POST, PUT, PATCH, DELETE, OPTIONS"
 
-		req.Path = originalPath // Restore original path for logging or debugging
+// handleRequest is the entry point used by the server. It answers OPTIONS * itself, and in development mode
+// the whole dispatch is wrapped with request logging and the panic page.
+func (g *Ghast) handleRequest(rw ResponseWriter, req *Request) {
+	if req.Path == "*" {
+		// OPTIONS * asks about the server as a whole rather than a resource
+		rw.SetHeader("Allow", serverMethods).SetHeader("Content-Length", "0").Status(200)
+		return
+	}
+	if g.dev {
+		devMiddleware(HandlerFunc(g.dispatch)).ServeHTTP(rw, req)
+		return
+	}
+	g.dispatch(rw, req)
+}
+
+// dispatch routes the request to the matching mounted router or sub-application, or to the root router.
+func (g *Ghast) dispatch(rw ResponseWriter, req *Request) {
+	previousApp := req.app
+	req.app = g
+	defer func() { req.app = previousApp }()
+
+	g.selectVersion(req)
+
+	for _, mc := range g.mountChains {
+		prefix := mc.prefix
+		if strings.HasPrefix(req.Path, prefix) && (prefix == "/" || len(req.Path) == len(prefix) || req.Path[len(prefix)] == '/') {
+			// Strip the prefix from the path before passing to the router
+			originalPath, originalMountPath := req.Path, req.mountPath
+			if prefix != "/" {
+				req.Path = strings.TrimPrefix(req.Path, prefix)
+				if req.Path == "" {
+					req.Path = "/"
+				}
+				req.mountPath += prefix
+			}
+
+			mc.handler.ServeHTTP(rw, req)
+
+			req.Path, req.mountPath = originalPath, originalMountPath // Restore original path for logging or debugging
+			return
+		}
 	}
 
 	// Fall back to root router if no prefix matched
-	routerWithMiddleware := chainMiddleware(g.rootRouter, g.middlewares)
-	routerWithMiddleware.ServeHTTP(rw, req)
+	g.rootChain.ServeHTTP(rw, req)
 }
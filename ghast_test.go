@@ -2,7 +2,16 @@ package ghast
 
 import (
 	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
 	"net"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
 	"testing"
 	"time"
 )
@@ -44,70 +53,6 @@ func (m *MockConnection) SetWriteDeadline(t time.Time) error {
 	return nil
 }
 
-// TestRouterGet tests that GET routes are registered and matched correctly
-func TestRouterGet(t *testing.T) {
-	router := NewRouter()
-	handlerCalled := false
-
-	handler := HandlerFunc(func(w ResponseWriter, r *Request) {
-		handlerCalled = true
-	})
-
-	router.Get("/test", handler)
-
-	// Manually verify route was registered
-	// (This requires accessing private router struct - in real tests would use interface methods)
-	mockConn := &MockConnection{}
-	rw := newResponseWriter(mockConn)
-	req := &Request{Method: "GET", Path: "/test", Headers: make(map[string]string)}
-
-	router.ServeHTTP(rw, req)
-
-	if !handlerCalled {
-		t.Error("GET handler was not called")
-	}
-}
-
-// TestRouterPost tests that POST routes are registered
-func TestRouterPost(t *testing.T) {
-	router := NewRouter()
-	handlerCalled := false
-
-	handler := HandlerFunc(func(w ResponseWriter, r *Request) {
-		handlerCalled = true
-	})
-
-	router.Post("/create", handler)
-
-	mockConn := &MockConnection{}
-	rw := newResponseWriter(mockConn)
-	req := &Request{Method: "POST", Path: "/create", Headers: make(map[string]string)}
-
-	router.ServeHTTP(rw, req)
-
-	if !handlerCalled {
-		t.Error("POST handler was not called")
-	}
-}
-
-// TestRouter404 tests that non-existent routes return 404
-func TestRouter404(t *testing.T) {
-	router := NewRouter()
-
-	mockConn := &MockConnection{}
-	rw := newResponseWriter(mockConn)
-	req := &Request{Method: "GET", Path: "/nonexistent", Headers: make(map[string]string)}
-
-	router.ServeHTTP(rw, req)
-
-	// Verify status code was set to 404
-	// We can check by looking at what was written
-	output := mockConn.writeBuffer.String()
-	if !bytes.Contains([]byte(output), []byte("404")) {
-		t.Error("404 response not found in output")
-	}
-}
-
 // TestMiddlewareChaining tests that middleware is applied in correct order
 func TestMiddlewareChaining(t *testing.T) {
 	callOrder := []string{}
@@ -182,7 +127,7 @@ func TestRequestJSON(t *testing.T) {
 	}
 
 	req := &Request{
-		Body: `{"name":"bob","age":30}`,
+		Body: []byte(`{"name":"bob","age":30}`),
 	}
 
 	var data testData
@@ -251,3 +196,1120 @@ func TestHandlerFunc(t *testing.T) {
 		t.Error("HandlerFunc did not call the underlying function")
 	}
 }
+
+// TestStartHookErrorAbortsListen tests that a failing OnStart hook prevents the server from listening
+func TestStartHookErrorAbortsListen(t *testing.T) {
+	app := New()
+	hookErr := errors.New("database unavailable")
+	secondCalled := false
+
+	app.OnStart(func(ctx context.Context) error {
+		return hookErr
+	})
+	app.OnStart(func(ctx context.Context) error {
+		secondCalled = true
+		return nil
+	})
+
+	err := app.Listen("127.0.0.1:0")
+
+	if !errors.Is(err, hookErr) {
+		t.Fatalf("expected start hook error, got %v", err)
+	}
+	if secondCalled {
+		t.Error("hooks after a failing start hook should not run")
+	}
+}
+
+// TestShutdownHooksRunInReverseOrder tests that shutdown hooks run LIFO and their errors are joined
+func TestShutdownHooksRunInReverseOrder(t *testing.T) {
+	app := New()
+	callOrder := []string{}
+	errFirst := errors.New("first failed")
+	errSecond := errors.New("second failed")
+
+	app.OnShutdown(func(ctx context.Context) error {
+		callOrder = append(callOrder, "first")
+		return errFirst
+	})
+	app.OnShutdown(func(ctx context.Context) error {
+		callOrder = append(callOrder, "second")
+		return errSecond
+	})
+
+	err := app.Shutdown(context.Background())
+
+	if len(callOrder) != 2 || callOrder[0] != "second" || callOrder[1] != "first" {
+		t.Errorf("unexpected shutdown hook order: %v", callOrder)
+	}
+	if !errors.Is(err, errFirst) || !errors.Is(err, errSecond) {
+		t.Errorf("expected both hook errors to be reported, got %v", err)
+	}
+}
+
+// TestShutdownStopsListen tests that Shutdown makes a running Listen call return
+func TestShutdownStopsListen(t *testing.T) {
+	app := New()
+	started := make(chan struct{})
+	app.OnStart(func(ctx context.Context) error {
+		close(started)
+		return nil
+	})
+
+	done := make(chan error, 1)
+	go func() {
+		done <- app.Listen("127.0.0.1:0")
+	}()
+
+	<-started
+	if err := app.Shutdown(context.Background()); err != nil {
+		t.Fatalf("Shutdown returned error: %v", err)
+	}
+
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("Listen returned error after shutdown: %v", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Listen did not return after Shutdown")
+	}
+}
+
+// TestAppTest tests that app.Test runs the routing pipeline and returns the parsed response
+func TestAppTest(t *testing.T) {
+	app := New()
+	app.Get("/users/:id", HandlerFunc(func(w ResponseWriter, r *Request) {
+		w.JSON(200, map[string]string{"id": r.Param("id"), "verbose": r.Query("verbose")})
+	}))
+
+	res := app.Test(&Request{Method: "GET", Path: "/users/42?verbose=true"})
+
+	if res.StatusCode != 200 {
+		t.Errorf("expected status 200, got %d", res.StatusCode)
+	}
+	if res.Header("content-type") != "application/json" {
+		t.Errorf("expected application/json content type, got %q", res.Header("Content-Type"))
+	}
+	if res.Body != `{"id":"42","verbose":"true"}` {
+		t.Errorf("unexpected body: %s", res.Body)
+	}
+}
+
+// TestAppTestMountedRouter tests that a mounted router handles the request exactly once
+func TestAppTestMountedRouter(t *testing.T) {
+	app := New()
+	users := NewRouter()
+	users.Get("/:id", HandlerFunc(func(w ResponseWriter, r *Request) {
+		w.Plain(200, "user "+r.Param("id"))
+	}))
+	app.Route("/users", users)
+
+	res := app.Test(&Request{Method: "GET", Path: "/users/7"})
+
+	if res.StatusCode != 200 || res.Body != "user 7" {
+		t.Errorf("unexpected response: %d %q", res.StatusCode, res.Body)
+	}
+}
+
+// TestAppTestStatusOnly tests that a response without a body still writes its status line
+func TestAppTestStatusOnly(t *testing.T) {
+	app := New()
+	app.Delete("/items/:id", HandlerFunc(func(w ResponseWriter, r *Request) {
+		w.Status(204)
+	}))
+
+	res := app.Test(&Request{Method: "DELETE", Path: "/items/1"})
+
+	if res.StatusCode != 204 || res.Status != "204 No Content" {
+		t.Errorf("expected 204 No Content, got %q", res.Status)
+	}
+}
+
+// TestAppGroupNested tests that nested groups combine prefixes and middleware
+func TestAppGroupNested(t *testing.T) {
+	app := New()
+	callOrder := []string{}
+
+	tag := func(name string) Middleware {
+		return func(next Handler) Handler {
+			return HandlerFunc(func(w ResponseWriter, r *Request) {
+				callOrder = append(callOrder, name)
+				next.ServeHTTP(w, r)
+			})
+		}
+	}
+
+	api := app.Group("/api", tag("api"))
+	v1 := api.Group("/v1", tag("v1"))
+	v1.Get("/users/:id", HandlerFunc(func(w ResponseWriter, r *Request) {
+		w.Plain(200, "user "+r.Param("id"))
+	}))
+	api.Get("/", HandlerFunc(func(w ResponseWriter, r *Request) {
+		w.Plain(200, "api root")
+	}))
+
+	res := app.Test(&Request{Method: "GET", Path: "/api/v1/users/5"})
+	if res.StatusCode != 200 || res.Body != "user 5" {
+		t.Errorf("unexpected nested group response: %d %q", res.StatusCode, res.Body)
+	}
+	if len(callOrder) != 2 {
+		t.Errorf("expected both group middleware to run, got %v", callOrder)
+	}
+
+	res = app.Test(&Request{Method: "GET", Path: "/api"})
+	if res.StatusCode != 200 || res.Body != "api root" {
+		t.Errorf("unexpected group root response: %d %q", res.StatusCode, res.Body)
+	}
+}
+
+// TestJoinPaths tests joining group prefixes and route paths
+func TestJoinPaths(t *testing.T) {
+	tests := []struct {
+		prefix, path, want string
+	}{
+		{"", "/", "/"},
+		{"", "users", "/users"},
+		{"/api", "/users", "/api/users"},
+		{"/api/", "users", "/api/users"},
+		{"/api", "/", "/api"},
+		{"/api", "", "/api"},
+		{"api", "/v1", "/api/v1"},
+		{"api", "", "/api"},
+	}
+
+	for _, tt := range tests {
+		if got := joinPaths(tt.prefix, tt.path); got != tt.want {
+			t.Errorf("joinPaths(%q, %q) = %q, want %q", tt.prefix, tt.path, got, tt.want)
+		}
+	}
+}
+
+// TestAppStateLookup tests that values registered with Set are reachable from the request
+func TestAppStateLookup(t *testing.T) {
+	type store struct{ name string }
+
+	app := New()
+	app.Set("store", &store{name: "primary"})
+	app.Set("limit", 10)
+
+	var found *store
+	var missingOK, wrongTypeOK bool
+	app.Get("/", HandlerFunc(func(w ResponseWriter, r *Request) {
+		found = MustGet[*store](r, "store")
+		_, missingOK = Lookup[string](r, "missing")
+		_, wrongTypeOK = Lookup[string](r, "limit")
+	}))
+
+	app.Test(&Request{Method: "GET", Path: "/"})
+
+	if found == nil || found.name != "primary" {
+		t.Errorf("expected registered store, got %v", found)
+	}
+	if missingOK {
+		t.Error("Lookup should report missing keys as not found")
+	}
+	if wrongTypeOK {
+		t.Error("Lookup should report values of the wrong type as not found")
+	}
+}
+
+// TestMustGetPanicsOnMissingKey tests that MustGet panics when a dependency is not registered
+func TestMustGetPanicsOnMissingKey(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("expected MustGet to panic for a missing key")
+		}
+	}()
+
+	req := &Request{app: New()}
+	MustGet[int](req, "missing")
+}
+
+// TestAppMount tests that a mounted sub-application composes middleware, state and routing
+func TestAppMount(t *testing.T) {
+	callOrder := []string{}
+	tag := func(name string) Middleware {
+		return func(next Handler) Handler {
+			return HandlerFunc(func(w ResponseWriter, r *Request) {
+				callOrder = append(callOrder, name)
+				next.ServeHTTP(w, r)
+			})
+		}
+	}
+
+	billing := New()
+	billing.Use(tag("billing"))
+	billing.Set("currency", "EUR")
+	billing.Get("/invoices/:id", HandlerFunc(func(w ResponseWriter, r *Request) {
+		w.Plain(200, r.Param("id")+" "+MustGet[string](r, "currency")+" "+MustGet[string](r, "region"))
+	}))
+
+	app := New()
+	app.Use(tag("app"))
+	app.Set("region", "eu-west")
+	app.Mount("/billing", billing, tag("mount"))
+
+	res := app.Test(&Request{Method: "GET", Path: "/billing/invoices/9"})
+
+	if res.StatusCode != 200 || res.Body != "9 EUR eu-west" {
+		t.Errorf("unexpected mounted response: %d %q", res.StatusCode, res.Body)
+	}
+
+	expected := []string{"app", "mount", "billing"}
+	if len(callOrder) != len(expected) {
+		t.Fatalf("unexpected middleware order: %v", callOrder)
+	}
+	for i, v := range expected {
+		if callOrder[i] != v {
+			t.Errorf("middleware order mismatch at %d: got %s, want %s", i, callOrder[i], v)
+		}
+	}
+}
+
+// TestAppMountHooks tests that lifecycle hooks of mounted applications run with the parent's
+func TestAppMountHooks(t *testing.T) {
+	callOrder := []string{}
+	hook := func(name string) Hook {
+		return func(ctx context.Context) error {
+			callOrder = append(callOrder, name)
+			return nil
+		}
+	}
+
+	sub := New()
+	sub.OnStart(hook("sub-start")).OnShutdown(hook("sub-stop"))
+
+	app := New()
+	app.OnStart(hook("app-start")).OnShutdown(hook("app-stop"))
+	app.Mount("/sub", sub)
+
+	if err := app.runStartHooks(context.Background()); err != nil {
+		t.Fatalf("start hooks failed: %v", err)
+	}
+	if err := app.Shutdown(context.Background()); err != nil {
+		t.Fatalf("shutdown failed: %v", err)
+	}
+
+	expected := []string{"app-start", "sub-start", "sub-stop", "app-stop"}
+	if len(callOrder) != len(expected) {
+		t.Fatalf("unexpected hook order: %v", callOrder)
+	}
+	for i, v := range expected {
+		if callOrder[i] != v {
+			t.Errorf("hook order mismatch at %d: got %s, want %s", i, callOrder[i], v)
+		}
+	}
+}
+
+// TestHandlerEDefaultErrorHandler tests how the default error handler maps returned errors
+func TestHandlerEDefaultErrorHandler(t *testing.T) {
+	app := New()
+	app.Get("/http-error", HandlerE(func(w ResponseWriter, r *Request) error {
+		return NewHTTPError(404, "user not found")
+	}))
+	app.Get("/problem", HandlerE(func(w ResponseWriter, r *Request) error {
+		return &Problem{Title: "Out of credit", Status: 403, Detail: "Balance is 30"}
+	}))
+	app.Get("/internal", HandlerE(func(w ResponseWriter, r *Request) error {
+		return errors.New("connection refused")
+	}))
+
+	tests := []struct {
+		path        string
+		status      int
+		contentType string
+		body        string
+	}{
+		{"/http-error", 404, "application/json", `{"status":404,"error":"user not found"}`},
+		{"/problem", 403, "application/problem+json", `{"title":"Out of credit","status":403,"detail":"Balance is 30"}`},
+		{"/internal", 500, "application/json", `{"status":500,"error":"Internal Server Error"}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			res := app.Test(&Request{Method: "GET", Path: tt.path})
+			if res.StatusCode != tt.status {
+				t.Errorf("expected status %d, got %d", tt.status, res.StatusCode)
+			}
+			if res.Header("Content-Type") != tt.contentType {
+				t.Errorf("expected content type %s, got %s", tt.contentType, res.Header("Content-Type"))
+			}
+			if res.Body != tt.body {
+				t.Errorf("unexpected body: %s", res.Body)
+			}
+		})
+	}
+}
+
+// TestHandlerEErrorAfterWrite tests that an error returned after the response started is logged, not sent
+func TestHandlerEErrorAfterWrite(t *testing.T) {
+	var logs bytes.Buffer
+	app := New()
+	app.SetLogger(slog.New(slog.NewTextHandler(&logs, nil)))
+	app.Get("/partial", HandlerE(func(w ResponseWriter, r *Request) error {
+		w.JSON(200, map[string]string{"status": "ok"})
+		return errors.New("audit log unavailable")
+	}))
+
+	res := app.Test(&Request{Method: "GET", Path: "/partial"})
+	if res.StatusCode != 200 || res.Body != `{"status":"ok"}` {
+		t.Errorf("expected only the handler's response, got %d %q", res.StatusCode, res.Body)
+	}
+	if !strings.Contains(logs.String(), "audit log unavailable") {
+		t.Errorf("expected the error to be logged, got %q", logs.String())
+	}
+}
+
+// TestSetErrorHandlerInheritedByMountedApp tests that a mounted app uses its parent's error handler
+func TestSetErrorHandlerInheritedByMountedApp(t *testing.T) {
+	var handled error
+	app := New()
+	app.SetErrorHandler(func(err error, w ResponseWriter, r *Request) {
+		handled = err
+		w.Plain(418, "custom")
+	})
+
+	sub := New()
+	sub.Get("/fail", HandlerE(func(w ResponseWriter, r *Request) error {
+		return errors.New("boom")
+	}))
+	app.Mount("/sub", sub)
+
+	res := app.Test(&Request{Method: "GET", Path: "/sub/fail"})
+
+	if handled == nil || handled.Error() != "boom" {
+		t.Errorf("expected custom error handler to receive error, got %v", handled)
+	}
+	if res.StatusCode != 418 || res.Body != "custom" {
+		t.Errorf("unexpected response: %d %q", res.StatusCode, res.Body)
+	}
+}
+
+// TestEAdapterComposesWithMiddleware tests that handlers adapted with E work with Handler-based middleware
+func TestEAdapterComposesWithMiddleware(t *testing.T) {
+	app := New()
+	tagged := func(next Handler) Handler {
+		return HandlerFunc(func(w ResponseWriter, r *Request) {
+			w.SetHeader("X-Wrapped", "yes")
+			next.ServeHTTP(w, r)
+		})
+	}
+	var getUser HandlerFuncE = func(w ResponseWriter, r *Request) error {
+		return NewHTTPError(404, "user not found")
+	}
+	app.Get("/users/:id", tagged(E(getUser)))
+
+	res := app.Test(&Request{Method: "GET", Path: "/users/7"})
+
+	if res.StatusCode != 404 {
+		t.Errorf("expected 404 from error handler, got %d", res.StatusCode)
+	}
+	if res.Header("X-Wrapped") != "yes" {
+		t.Error("expected middleware to wrap the adapted handler")
+	}
+}
+
+// TestTrailingSlashRedirectInMount tests that trailing slash redirects keep the mount prefix and the query string
+func TestTrailingSlashRedirectInMount(t *testing.T) {
+	app := New(WithRouterOptions(RouterOptions{TrailingSlash: TrailingSlashRedirect}))
+	app.Get("/about", HandlerFunc(func(w ResponseWriter, r *Request) {}))
+
+	users := NewRouterWithOptions(RouterOptions{TrailingSlash: TrailingSlashRedirect})
+	users.Get("/:id", HandlerFunc(func(w ResponseWriter, r *Request) {}))
+	app.Route("/users", users)
+
+	res := app.Test(&Request{Method: "GET", Path: "/about/"})
+	if res.StatusCode != 301 || res.Header("Location") != "/about" {
+		t.Errorf("expected a redirect to /about, got %d %q", res.StatusCode, res.Header("Location"))
+	}
+
+	res = app.Test(&Request{Method: "GET", Path: "/users/1/?tab=posts"})
+	if res.StatusCode != 301 || res.Header("Location") != "/users/1?tab=posts" {
+		t.Errorf("expected a redirect to /users/1?tab=posts, got %d %q", res.StatusCode, res.Header("Location"))
+	}
+
+	// The query string is passed on as sent, without encoding it again
+	res = app.Test(&Request{Method: "GET", Path: "/users/1/?q=a%20b&tags=x&tags=y"})
+	if res.StatusCode != 301 || res.Header("Location") != "/users/1?q=a%20b&tags=x&tags=y" {
+		t.Errorf("expected a redirect to /users/1?q=a%%20b&tags=x&tags=y, got %d %q", res.StatusCode, res.Header("Location"))
+	}
+}
+
+// TestAutoHead tests that HEAD requests are served by the GET route with the body discarded, unless disabled
+func TestAutoHead(t *testing.T) {
+	app := New()
+	app.Get("/greeting", HandlerFunc(func(w ResponseWriter, r *Request) {
+		w.SetHeader("X-Greeting", "yes")
+		w.Plain(200, "hello")
+	}))
+
+	res := app.Test(&Request{Method: HEAD, Path: "/greeting"})
+	if res.StatusCode != 200 || res.Body != "" {
+		t.Errorf("expected 200 without a body, got %d %q", res.StatusCode, res.Body)
+	}
+	if res.Header("X-Greeting") != "yes" || res.Header("Content-Length") != "5" {
+		t.Errorf("expected the GET response headers, got %v", res.Headers)
+	}
+
+	strict := New(WithRouterOptions(RouterOptions{DisableAutoHead: true}))
+	strict.Get("/greeting", HandlerFunc(func(w ResponseWriter, r *Request) {}))
+	res = strict.Test(&Request{Method: HEAD, Path: "/greeting"})
+	if res.StatusCode != 405 || res.Header("Allow") != "GET" {
+		t.Errorf("expected 405 allowing only GET, got %d %q", res.StatusCode, res.Header("Allow"))
+	}
+}
+
+// TestAppCustomNotFoundAndMethodNotAllowed tests that app-level handlers apply to root and mounted routers
+func TestAppCustomNotFoundAndMethodNotAllowed(t *testing.T) {
+	app := New()
+	app.NotFound(HandlerFunc(func(w ResponseWriter, r *Request) {
+		Error(w, 404, "no such route: "+r.Path)
+	}))
+	app.MethodNotAllowed(HandlerFunc(func(w ResponseWriter, r *Request) {
+		Error(w, 405, "method not allowed")
+	}))
+
+	users := NewRouter()
+	users.Get("/:id", HandlerFunc(func(w ResponseWriter, r *Request) {}))
+	app.Route("/users", users)
+
+	res := app.Test(&Request{Method: "GET", Path: "/missing"})
+	if res.StatusCode != 404 || res.Body != `{"status":404,"error":"no such route: /missing"}` {
+		t.Errorf("unexpected root 404 response: %d %s", res.StatusCode, res.Body)
+	}
+
+	res = app.Test(&Request{Method: "GET", Path: "/users/1/posts"})
+	if res.StatusCode != 404 || res.Header("Content-Type") != "application/json" {
+		t.Errorf("mounted router did not use custom 404 handler: %d %s", res.StatusCode, res.Body)
+	}
+
+	res = app.Test(&Request{Method: "DELETE", Path: "/users/1"})
+	if res.StatusCode != 405 || res.Body != `{"status":405,"error":"method not allowed"}` {
+		t.Errorf("unexpected 405 response: %d %s", res.StatusCode, res.Body)
+	}
+	if res.Header("Allow") != "GET, HEAD" {
+		t.Errorf("expected Allow header GET, HEAD, got %q", res.Header("Allow"))
+	}
+}
+
+func listUsersHandler(w ResponseWriter, r *Request) {}
+
+// TestAppRoutes tests that route introspection covers root, grouped and mounted routes
+func TestAppRoutes(t *testing.T) {
+	noop := func(next Handler) Handler { return next }
+
+	app := New()
+	app.Use(noop)
+	app.Get("/health", HandlerFunc(func(w ResponseWriter, r *Request) {}))
+	app.Group("/api", noop).Get("/users", HandlerFunc(listUsersHandler))
+
+	users := NewRouter()
+	users.Get("/:id", HandlerFunc(func(w ResponseWriter, r *Request) {}), noop)
+	app.Route("/users", users, noop)
+
+	routes := app.Routes()
+
+	expected := []struct {
+		method, path string
+		middlewares  int
+	}{
+		{"GET", "/api/users", 2},
+		{"GET", "/health", 1},
+		{"GET", "/users/:id", 3},
+	}
+	if len(routes) != len(expected) {
+		t.Fatalf("expected %d routes, got %+v", len(expected), routes)
+	}
+	for i, want := range expected {
+		got := routes[i]
+		if got.Method != want.method || got.Path != want.path || got.Middlewares != want.middlewares {
+			t.Errorf("route %d: got %s %s (%d middleware), want %s %s (%d middleware)",
+				i, got.Method, got.Path, got.Middlewares, want.method, want.path, want.middlewares)
+		}
+	}
+	if routes[0].Handler != "github.com/Leonard-Atorough/ghast.listUsersHandler" {
+		t.Errorf("unexpected handler name: %s", routes[0].Handler)
+	}
+	if len(routes[2].Params) != 1 || routes[2].Params[0] != "id" {
+		t.Errorf("unexpected params: %v", routes[2].Params)
+	}
+}
+
+// TestAppDumpRoutes tests that the route table is written as JSON with mount prefixes
+func TestAppDumpRoutes(t *testing.T) {
+	handler := HandlerFunc(func(w ResponseWriter, r *Request) {})
+	admin := New()
+	admin.Get("/stats", handler)
+	users := NewRouter()
+	users.Get("/:id", handler)
+
+	app := New()
+	app.Get("/health", handler)
+	app.Route("/users", users)
+	app.Mount("/admin", admin)
+
+	var buf bytes.Buffer
+	if err := app.DumpRoutes(&buf); err != nil {
+		t.Fatal(err)
+	}
+	var table struct {
+		Routes []RouteInfo `json:"routes"`
+		Mounts []string    `json:"mounts"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &table); err != nil {
+		t.Fatalf("invalid JSON %q: %v", buf.String(), err)
+	}
+
+	var got []string
+	for _, route := range table.Routes {
+		got = append(got, route.Method+" "+route.Path+" "+route.Mount)
+	}
+	want := []string{"GET /admin/stats /admin", "GET /health ", "GET /users/:id /users"}
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("expected routes %q, got %q", want, got)
+	}
+	if strings.Join(table.Mounts, ",") != "/admin,/users" {
+		t.Errorf("expected mounts [/admin /users], got %v", table.Mounts)
+	}
+
+	buf.Reset()
+	if err := NewRouter().DumpRoutes(&buf); err != nil || buf.String() != "{\n  \"routes\": []\n}\n" {
+		t.Errorf("unexpected JSON for an empty router: %q, %v", buf.String(), err)
+	}
+}
+
+// TestFormatRouteTable tests the startup route table layout
+func TestFormatRouteTable(t *testing.T) {
+	table := formatRouteTable([]RouteInfo{
+		{Method: "GET", Path: "/users/:id", Handler: "main.getUser", Middlewares: 2},
+		{Method: "DELETE", Path: "/users/:id", Handler: "main.deleteUser", Middlewares: 0},
+	})
+
+	expected := "METHOD  PATH        HANDLER          MIDDLEWARE\n" +
+		"GET     /users/:id  main.getUser     2\n" +
+		"DELETE  /users/:id  main.deleteUser  0\n"
+	if table != expected {
+		t.Errorf("unexpected route table:\n%s", table)
+	}
+}
+
+// TestDevModePanicPage tests that development mode renders panics as an HTML error page
+func TestDevModePanicPage(t *testing.T) {
+	app := New().Dev()
+	app.Get("/boom", HandlerFunc(func(w ResponseWriter, r *Request) {
+		panic("something <broke>")
+	}))
+
+	res := app.Test(&Request{Method: "GET", Path: "/boom"})
+
+	if res.StatusCode != 500 {
+		t.Errorf("expected status 500, got %d", res.StatusCode)
+	}
+	if res.Header("Content-Type") != "text/html" {
+		t.Errorf("expected HTML panic page, got %s", res.Header("Content-Type"))
+	}
+	if !bytes.Contains([]byte(res.Body), []byte("something &lt;broke&gt;")) {
+		t.Errorf("panic value not rendered (escaped) in page: %s", res.Body)
+	}
+}
+
+// TestDevRestartKeepsListenBlocked tests that Listen doesn't return when the development watcher shuts the app
+// down to restart it, only once the restart fails
+func TestDevRestartKeepsListenBlocked(t *testing.T) {
+	app := New(WithHideBanner()).Dev()
+	listening := make(chan struct{})
+	app.server.onListen = func(net.Addr) { close(listening) }
+
+	done := make(chan error, 1)
+	go func() { done <- app.Listen("127.0.0.1:0") }()
+	<-listening
+	binary := filepath.Join(t.TempDir(), "not-a-binary")
+	os.WriteFile(binary, []byte("not a binary"), 0o644)
+	app.restart(binary)
+
+	select {
+	case err := <-done:
+		if err == nil || !strings.Contains(err.Error(), "dev restart failed") {
+			t.Errorf("expected the restart error from Listen, got %v", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("expected Listen to return once the restart failed")
+	}
+	if _, err := os.Stat(binary); !os.IsNotExist(err) {
+		t.Errorf("expected the binary to be removed after the failed restart, got %v", err)
+	}
+}
+
+// TestDiffSnapshots tests detection of added, modified and removed files
+func TestDiffSnapshots(t *testing.T) {
+	now := time.Now()
+	previous := map[string]time.Time{
+		"main.go":         now,
+		"views/home.tmpl": now,
+		"static/app.css":  now,
+	}
+	current := map[string]time.Time{
+		"main.go":         now,
+		"views/home.tmpl": now.Add(time.Second),
+		"static/new.js":   now,
+	}
+
+	changed := diffSnapshots(previous, current)
+
+	expected := []string{"static/app.css", "static/new.js", "views/home.tmpl"}
+	if len(changed) != len(expected) {
+		t.Fatalf("expected %v, got %v", expected, changed)
+	}
+	for i, path := range expected {
+		if changed[i] != path {
+			t.Errorf("change %d: got %s, want %s", i, changed[i], path)
+		}
+	}
+}
+
+// TestAppVersionLifecycleHeaders tests that deprecated versions announce deprecation and sunset
+func TestAppVersionLifecycleHeaders(t *testing.T) {
+	app := New()
+	v1 := app.Version("v1", VersionOptions{
+		Deprecated: time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC),
+		Sunset:     time.Date(2026, 7, 1, 0, 0, 0, 0, time.UTC),
+		Link:       "https://example.com/migrate",
+	})
+	v1.Get("/users", HandlerFunc(func(w ResponseWriter, r *Request) { w.Plain(200, "v1") }))
+	app.Version("v2").Get("/users", HandlerFunc(func(w ResponseWriter, r *Request) { w.Plain(200, "v2") }))
+
+	res := app.Test(&Request{Method: "GET", Path: "/v1/users"})
+	if res.Body != "v1" {
+		t.Errorf("unexpected v1 body: %s", res.Body)
+	}
+	if res.Header("Deprecation") != "@1767225600" {
+		t.Errorf("unexpected Deprecation header: %q", res.Header("Deprecation"))
+	}
+	if res.Header("Sunset") != "Wed, 01 Jul 2026 00:00:00 GMT" {
+		t.Errorf("unexpected Sunset header: %q", res.Header("Sunset"))
+	}
+	if res.Header("Link") != `<https://example.com/migrate>; rel="deprecation"` {
+		t.Errorf("unexpected Link header: %q", res.Header("Link"))
+	}
+
+	res = app.Test(&Request{Method: "GET", Path: "/v2/users"})
+	if res.Body != "v2" || res.Header("Deprecation") != "" {
+		t.Errorf("v2 should not be deprecated: %q %q", res.Body, res.Header("Deprecation"))
+	}
+}
+
+// TestAppVersionHeaderSelection tests selecting a version from a request header
+func TestAppVersionHeaderSelection(t *testing.T) {
+	app := New().SetVersionHeader("API-Version")
+	app.Version("v1").Get("/users", HandlerFunc(func(w ResponseWriter, r *Request) { w.Plain(200, "v1") }))
+	app.Version("v2").Get("/users", HandlerFunc(func(w ResponseWriter, r *Request) { w.Plain(200, "v2") }))
+
+	tests := []struct {
+		name    string
+		path    string
+		version string
+		want    string
+	}{
+		{"header", "/users", "v1", "v1"},
+		{"version number", "/users", "1", "v1"},
+		{"latest by default", "/users", "", "v2"},
+		{"unknown version", "/users", "v9", "v2"},
+		{"path prefix wins", "/v1/users", "v2", "v1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			headers := map[string]string{}
+			if tt.version != "" {
+				headers["API-Version"] = tt.version
+			}
+			res := app.Test(&Request{Method: "GET", Path: tt.path, Headers: headers})
+			if res.Body != tt.want {
+				t.Errorf("expected %s, got %d %q", tt.want, res.StatusCode, res.Body)
+			}
+		})
+	}
+}
+
+// TestAppVersionLatestIsHighest tests that unversioned requests go to the highest version, not the last registered
+func TestAppVersionLatestIsHighest(t *testing.T) {
+	app := New().SetVersionHeader("API-Version")
+	for _, version := range []string{"v2", "v10", "v9"} {
+		app.Version(version).Get("/users", HandlerFunc(func(w ResponseWriter, r *Request) { w.Plain(200, version) }))
+	}
+
+	res := app.Test(&Request{Method: "GET", Path: "/users"})
+	if res.Body != "v10" {
+		t.Errorf("expected v10, got %d %q", res.StatusCode, res.Body)
+	}
+}
+
+// TestCompareVersions tests the ordering used to find the latest version
+func TestCompareVersions(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"v1", "v2", -1},
+		{"v10", "v9", 1},
+		{"V2", "v2", 0},
+		{"1.10", "1.9", 1},
+		{"v1", "v1.1", -1},
+		{"2024-06-01", "2023-12-31", 1},
+	}
+	for _, tt := range tests {
+		if got := compareVersions(tt.a, tt.b); got != tt.want {
+			t.Errorf("compareVersions(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+// TestAppVersionAcceptSelection tests selecting a version from the Accept header and mounting version routers
+func TestAppVersionAcceptSelection(t *testing.T) {
+	v1 := NewRouter()
+	v1.Get("/users", HandlerFunc(func(w ResponseWriter, r *Request) { w.Plain(200, "v1") }))
+	app := New().SetVersionFromAccept()
+	app.MountVersion("v1", v1, VersionOptions{Link: "https://example.com/migrate"})
+	app.Version("v2").Get("/users", HandlerFunc(func(w ResponseWriter, r *Request) { w.Plain(200, "v2") }))
+
+	tests := []struct {
+		name   string
+		path   string
+		accept string
+		want   string
+	}{
+		{"version parameter", "/users", "application/json; version=1", "v1"},
+		{"vendor media type", "/users", "text/html, application/vnd.example.v1+json", "v1"},
+		{"unknown version", "/users", "application/vnd.example.v9+json", "v2"},
+		{"latest by default", "/users", "", "v2"},
+		{"path prefix wins", "/v1/users", "application/json; version=v2", "v1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			headers := map[string]string{}
+			if tt.accept != "" {
+				headers["Accept"] = tt.accept
+			}
+			res := app.Test(&Request{Method: "GET", Path: tt.path, Headers: headers})
+			if res.Body != tt.want {
+				t.Errorf("expected %s, got %d %q", tt.want, res.StatusCode, res.Body)
+			}
+			if tt.want == "v1" && res.Header("Link") == "" {
+				t.Error("expected the mounted version to announce its lifecycle")
+			}
+		})
+	}
+}
+
+// TestAppSSE tests that SSE routes write event-stream headers and well-formed events
+func TestAppSSE(t *testing.T) {
+	app := New()
+	app.SSE("/events", func(s *SSEStream, r *Request) {
+		if s.LastEventID() != "41" {
+			t.Errorf("expected Last-Event-ID 41, got %q", s.LastEventID())
+		}
+		s.Send(SSEEvent{ID: "42", Event: "update", Data: "line one\nline two"})
+		s.SendData("done")
+	}, SSEOptions{Retry: 3 * time.Second})
+
+	res := app.Test(&Request{Method: GET, Path: "/events", Headers: map[string]string{"Last-Event-ID": "41"}})
+
+	if res.Header("Content-Type") != "text/event-stream" {
+		t.Errorf("expected text/event-stream, got %q", res.Header("Content-Type"))
+	}
+	if res.Header("Cache-Control") != "no-cache" {
+		t.Errorf("expected Cache-Control no-cache, got %q", res.Header("Cache-Control"))
+	}
+	expected := "retry: 3000\n\n" +
+		"id: 42\nevent: update\ndata: line one\ndata: line two\n\n" +
+		"data: done\n\n"
+	if res.Body != expected {
+		t.Errorf("unexpected stream body:\n%q\nwant:\n%q", res.Body, expected)
+	}
+}
+
+// TestAppSSELineBreaks tests that line breaks in event fields can't inject fields of their own
+func TestAppSSELineBreaks(t *testing.T) {
+	app := New()
+	app.SSE("/events", func(s *SSEStream, r *Request) {
+		s.Send(SSEEvent{ID: "1\ndata: injected", Event: "update\r\nretry: 1", Data: "a\r\nb\rc"})
+	})
+
+	res := app.Test(&Request{Method: GET, Path: "/events"})
+
+	expected := "id: 1 data: injected\nevent: update  retry: 1\ndata: a\ndata: b\ndata: c\n\n"
+	if res.Body != expected {
+		t.Errorf("unexpected stream body:\n%q\nwant:\n%q", res.Body, expected)
+	}
+}
+
+// TestAppSSEHeartbeat tests that idle streams send heartbeat comments and refuse writes after the handler returns
+func TestAppSSEHeartbeat(t *testing.T) {
+	app := New()
+	var stream *SSEStream
+	app.SSE("/events", func(s *SSEStream, r *Request) {
+		stream = s
+		time.Sleep(60 * time.Millisecond)
+	}, SSEOptions{Heartbeat: 10 * time.Millisecond})
+
+	res := app.Test(&Request{Method: GET, Path: "/events"})
+
+	if !strings.Contains(res.Body, ": heartbeat\n\n") {
+		t.Errorf("expected heartbeat comments, got %q", res.Body)
+	}
+	if err := stream.SendData("late"); !errors.Is(err, ErrStreamClosed) {
+		t.Errorf("expected ErrStreamClosed after handler returned, got %v", err)
+	}
+}
+
+// TestAppSSEClientDisconnect tests that the stream context is canceled when the client goes away
+func TestAppSSEClientDisconnect(t *testing.T) {
+	app := New()
+	done := make(chan struct{})
+	app.SSE("/events", func(s *SSEStream, r *Request) {
+		<-s.Context().Done()
+		close(done)
+	}, SSEOptions{Heartbeat: 10 * time.Millisecond})
+	addr := startTestApp(t, app)
+	defer app.Shutdown(context.Background())
+
+	conn, err := net.Dial("tcp", addr)
+	if err != nil {
+		t.Fatalf("dial failed: %v", err)
+	}
+	conn.Write([]byte("GET /events HTTP/1.1\r\nHost: localhost\r\n\r\n"))
+	conn.Read(make([]byte, 512))
+	conn.Close()
+
+	select {
+	case <-done:
+	case <-time.After(2 * time.Second):
+		t.Fatal("stream context was not canceled after the client disconnected")
+	}
+}
+
+// TestRequestClone tests that a cloned request doesn't share maps with the original
+func TestRequestClone(t *testing.T) {
+	req := &Request{
+		Method:  "GET",
+		Path:    "/users/1",
+		Headers: map[string]string{"X-Token": "a"},
+		Params:  map[string]string{"id": "1"},
+	}
+
+	clone := req.Clone()
+	req.Headers["X-Token"] = "b"
+	req.Params["id"] = "2"
+
+	if clone.Headers["X-Token"] != "a" || clone.Params["id"] != "1" || clone.Path != "/users/1" {
+		t.Errorf("clone changed with the original: %+v", clone)
+	}
+	if clone.Queries != nil {
+		t.Errorf("expected nil queries to stay nil, got %v", clone.Queries)
+	}
+}
+
+// TestReleasedRequestIsReset tests that pooled requests don't carry state into the next request
+func TestReleasedRequestIsReset(t *testing.T) {
+	req := acquireRequest()
+	if err := parseRequestInto(req, "GET /users/1?tab=posts HTTP/1.1\r\nX-Token: a"); err != nil {
+		t.Fatalf("parse failed: %v", err)
+	}
+	req.Params["id"] = "1"
+	req.Body = []byte("body")
+	releaseRequest(req)
+
+	if req.Method != "" || req.Path != "" || req.Body != nil || req.ctx != nil {
+		t.Errorf("released request not reset: %+v", req)
+	}
+	if len(req.Headers) != 0 || len(req.Queries) != 0 || len(req.Params) != 0 {
+		t.Errorf("released request maps not cleared: %v %v %v", req.Headers, req.Queries, req.Params)
+	}
+
+	rw := acquireResponseWriter(&bytes.Buffer{})
+	rw.Status(404).SetHeader("X-Leak", "yes")
+	rw.finish()
+	releaseResponseWriter(rw)
+	rw = acquireResponseWriter(&bytes.Buffer{})
+	if rw.statusCode != 200 || rw.written || len(rw.headers) != 0 {
+		t.Errorf("pooled response writer not reset: %+v", rw)
+	}
+}
+
+// TestRouteLongestPrefixWins tests that mounted routers are matched by the longest prefix, whatever the registration order
+func TestRouteLongestPrefixWins(t *testing.T) {
+	app := New()
+	api := NewRouter()
+	api.Get("/v1/users", HandlerFunc(func(w ResponseWriter, r *Request) { w.Plain(200, "api") }))
+	v1 := NewRouter()
+	v1.Get("/users", HandlerFunc(func(w ResponseWriter, r *Request) { w.Plain(200, "v1") }))
+	apiV2 := NewRouter()
+	apiV2.Get("/users", HandlerFunc(func(w ResponseWriter, r *Request) { w.Plain(200, "v2") }))
+	app.Route("/api", api)
+	app.Route("/api/v1", v1)
+	app.Route("/api/v2", apiV2)
+
+	if res := app.Test(&Request{Method: "GET", Path: "/api/v1/users"}); res.Body != "v1" {
+		t.Errorf("expected /api/v1 router, got %q", res.Body)
+	}
+	if res := app.Test(&Request{Method: "GET", Path: "/api/v2/users"}); res.Body != "v2" {
+		t.Errorf("expected /api/v2 router, got %q", res.Body)
+	}
+	if res := app.Test(&Request{Method: "GET", Path: "/api/v10/users"}); res.StatusCode != 404 {
+		t.Errorf("expected /api router to 404 for /v10/users, got %d %q", res.StatusCode, res.Body)
+	}
+}
+
+// TestMiddlewareChainsComposedOnce tests that middleware wraps handlers at registration rather than per request
+func TestMiddlewareChainsComposedOnce(t *testing.T) {
+	app := New()
+	wraps := 0
+	counting := func(next Handler) Handler {
+		wraps++
+		return HandlerFunc(func(w ResponseWriter, r *Request) {
+			w.SetHeader("X-Counted", "yes")
+			next.ServeHTTP(w, r)
+		})
+	}
+
+	users := NewRouter()
+	users.Get("/", HandlerFunc(func(w ResponseWriter, r *Request) { w.Plain(200, "users") }))
+	app.Route("/users", users)
+	app.Use(counting) // Registered after the mount, still applies to it
+	registered := wraps
+
+	for range 3 {
+		res := app.Test(&Request{Method: "GET", Path: "/users"})
+		if res.Header("X-Counted") != "yes" {
+			t.Fatal("expected app middleware to wrap the mounted router")
+		}
+	}
+	if wraps != registered {
+		t.Errorf("expected no wrapping per request, middleware wrapped %d more times", wraps-registered)
+	}
+}
+
+// TestHeadersCanonicalizedAtParse tests that header names are canonicalized so lookups are case-insensitive
+func TestHeadersCanonicalizedAtParse(t *testing.T) {
+	req, err := ParseRequest("GET / HTTP/1.1\r\ncontent-type: application/json\r\nX-API-KEY: secret")
+	if err != nil {
+		t.Fatalf("parse failed: %v", err)
+	}
+
+	if req.Headers["Content-Type"] != "application/json" || req.Headers["X-Api-Key"] != "secret" {
+		t.Errorf("expected canonical header keys, got %v", req.Headers)
+	}
+	if req.GetHeader("CONTENT-TYPE") != "application/json" || req.GetHeader("x-api-key") != "secret" {
+		t.Error("expected case-insensitive lookups")
+	}
+
+	handBuilt := &Request{Headers: map[string]string{"Last-Event-ID": "7"}}
+	if handBuilt.GetHeader("Last-Event-ID") != "7" {
+		t.Error("expected exact lookups on hand-built requests")
+	}
+}
+
+// TestJSONContentLengthAndStreaming tests that small JSON payloads get a Content-Length and large ones are streamed
+func TestJSONContentLengthAndStreaming(t *testing.T) {
+	app := New()
+	app.Get("/small", HandlerFunc(func(w ResponseWriter, r *Request) {
+		w.JSON(201, map[string]string{"name": "alice"})
+	}))
+	app.Get("/large", HandlerFunc(func(w ResponseWriter, r *Request) {
+		w.JSON(200, map[string]string{"data": strings.Repeat("x", jsonLengthThreshold)})
+	}))
+
+	res := app.Test(&Request{Method: GET, Path: "/small"})
+	if res.StatusCode != 201 || res.Body != `{"name":"alice"}` {
+		t.Errorf("unexpected small response: %d %q", res.StatusCode, res.Body)
+	}
+	if res.Header("Content-Length") != strconv.Itoa(len(res.Body)) || res.Header("Connection") == "close" {
+		t.Errorf("expected Content-Length on a persistent connection, got headers %v", res.Headers)
+	}
+
+	res = app.Test(&Request{Method: GET, Path: "/large"})
+	if res.StatusCode != 200 || res.Header("Content-Type") != "application/json" {
+		t.Errorf("unexpected large response: %d %v", res.StatusCode, res.Headers)
+	}
+	if res.Header("Content-Length") != "" || res.Header("Connection") != "close" {
+		t.Errorf("expected streamed response without Content-Length, got headers %v", res.Headers)
+	}
+	if len(res.Body) != jsonLengthThreshold+len(`{"data":""}`)+1 {
+		t.Errorf("unexpected large body length %d", len(res.Body))
+	}
+}
+
+// TestSetLogger tests that the startup report and Request.Logger use the configured logger, including in mounted apps
+func TestSetLogger(t *testing.T) {
+	var buf bytes.Buffer
+	app := New(WithHideBanner())
+	app.SetLogger(slog.New(slog.NewTextHandler(&buf, nil)))
+
+	sub := New()
+	sub.Get("/hello", HandlerFunc(func(w ResponseWriter, r *Request) {
+		r.Logger().Info("hello from handler")
+		w.SendString("ok")
+	}))
+	app.Mount("/sub", sub)
+
+	app.printStartupReport(&net.TCPAddr{IP: net.IPv4(127, 0, 0, 1), Port: 8080})
+	app.Test(&Request{Method: GET, Path: "/sub/hello"})
+
+	out := buf.String()
+	if !strings.Contains(out, "Ghast server listening") || !strings.Contains(out, "addr=127.0.0.1:8080") {
+		t.Errorf("expected the listen address in the configured logger, got %q", out)
+	}
+	if !strings.Contains(out, `msg="hello from handler"`) {
+		t.Errorf("expected the mounted app's handler to log through the parent's logger, got %q", out)
+	}
+
+	if (&Request{}).Logger() != slog.Default() {
+		t.Error("expected requests outside an app to use slog.Default")
+	}
+}
+
+// TestListenAndWaitForSignals tests that the application shuts down once a signal arrives, running its hooks
+func TestListenAndWaitForSignals(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("signals can't be sent to the current process on Windows")
+	}
+	app := New(WithGracefulShutdownTimeout(time.Second))
+	listening := make(chan struct{})
+	app.server.onListen = func(net.Addr) { close(listening) }
+	shutDown := false
+	app.OnShutdown(func(ctx context.Context) error {
+		shutDown = true
+		return nil
+	})
+
+	done := make(chan error, 1)
+	go func() { done <- app.ListenAndWaitForSignals("127.0.0.1:0", os.Interrupt) }()
+	<-listening
+	process, _ := os.FindProcess(os.Getpid())
+	process.Signal(os.Interrupt)
+
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("expected a clean shutdown, got %v", err)
+		}
+		if !shutDown {
+			t.Error("expected the shutdown hooks to run")
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("expected the application to shut down after the signal")
+	}
+}
+
+// TestRedirectRawQuery tests that redirects pass the query string on as sent, without encoding it again
+func TestRedirectRawQuery(t *testing.T) {
+	app := New()
+	app.Redirect("/old", "/new", 301)
+
+	res := app.Test(&Request{Method: "GET", Path: "/old?q=a%20b&tags=x&tags=y"})
+	if res.StatusCode != 301 || res.Header("Location") != "/new?q=a%20b&tags=x&tags=y" {
+		t.Errorf("expected a redirect to /new?q=a%%20b&tags=x&tags=y, got %d %q", res.StatusCode, res.Header("Location"))
+	}
+}
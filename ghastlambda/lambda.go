@@ -0,0 +1,233 @@
+// Package ghastlambda runs ghast applications as AWS Lambda functions behind API Gateway (REST and HTTP APIs)
+// or an Application Load Balancer, so the same application code can be deployed as a function or as a
+// long-lived server.
+//
+// The handler returned by Handler has the signature expected by the aws-lambda-go runtime, so this package
+// doesn't depend on it:
+//
+//	func main() {
+//	    app := ghast.New()
+//	    app.Get("/users/:id", getUser)
+//	    lambda.Start(ghastlambda.Handler(app))
+//	}
+package ghastlambda
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"fmt"
+	"mime"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/Leonard-Atorough/ghast"
+)
+
+// Event is an incoming Lambda event. It covers the API Gateway REST API (payload format 1.0), API Gateway HTTP
+// API (payload format 2.0) and Application Load Balancer formats; the fields that are set identify the format.
+type Event struct {
+	Version string `json:"version"` // "2.0" for HTTP API events
+
+	// REST API and ALB events
+	HTTPMethod                      string              `json:"httpMethod"`
+	Path                            string              `json:"path"`
+	QueryStringParameters           map[string]string   `json:"queryStringParameters"`
+	MultiValueQueryStringParameters map[string][]string `json:"multiValueQueryStringParameters"`
+	MultiValueHeaders               map[string][]string `json:"multiValueHeaders"`
+
+	// HTTP API events
+	RawPath        string   `json:"rawPath"`
+	RawQueryString string   `json:"rawQueryString"`
+	Cookies        []string `json:"cookies"`
+
+	Headers         map[string]string `json:"headers"`
+	RequestContext  RequestContext    `json:"requestContext"`
+	Body            string            `json:"body"`
+	IsBase64Encoded bool              `json:"isBase64Encoded"`
+}
+
+// RequestContext holds the parts of an event's request context used to build the request.
+type RequestContext struct {
+	HTTP struct {
+		Method   string `json:"method"`
+		SourceIP string `json:"sourceIp"`
+	} `json:"http"` // HTTP API events
+	Identity struct {
+		SourceIP string `json:"sourceIp"`
+	} `json:"identity"` // REST API events
+	ELB *struct {
+		TargetGroupArn string `json:"targetGroupArn"`
+	} `json:"elb,omitempty"` // ALB events
+}
+
+// Response is the Lambda response, in the format matching the event it answers.
+type Response struct {
+	StatusCode        int                 `json:"statusCode"`
+	StatusDescription string              `json:"statusDescription,omitempty"` // ALB only
+	Headers           map[string]string   `json:"headers,omitempty"`
+	MultiValueHeaders map[string][]string `json:"multiValueHeaders,omitempty"` // When the event used multi-value headers
+	Cookies           []string            `json:"cookies,omitempty"`           // HTTP API only
+	Body              string              `json:"body"`
+	IsBase64Encoded   bool                `json:"isBase64Encoded"`
+}
+
+// Handler returns a Lambda handler that converts each event into a ghast Request, serves it with h (usually a
+// *ghast.Ghast) and collects the response into the Lambda response format. Binary response bodies are base64
+// encoded.
+func Handler(h ghast.Handler) func(context.Context, Event) (*Response, error) {
+	httpHandler := ghast.ToHTTP(h)
+	return func(ctx context.Context, event Event) (*Response, error) {
+		req, err := newRequest(ctx, event)
+		if err != nil {
+			return nil, err
+		}
+		rec := &responseBuffer{header: make(http.Header), statusCode: http.StatusOK}
+		httpHandler.ServeHTTP(rec, req)
+		return newResponse(event, rec), nil
+	}
+}
+
+// newRequest builds the net/http request for an event; ghast.ToHTTP translates it into a ghast Request.
+func newRequest(ctx context.Context, event Event) (*http.Request, error) {
+	method, path, rawQuery, clientIP := event.HTTPMethod, event.Path, "", event.RequestContext.Identity.SourceIP
+	if event.Version == "2.0" {
+		method, path, rawQuery = event.RequestContext.HTTP.Method, event.RawPath, event.RawQueryString
+		clientIP = event.RequestContext.HTTP.SourceIP
+	} else {
+		rawQuery = encodeQuery(event)
+	}
+
+	body := []byte(event.Body)
+	if event.IsBase64Encoded {
+		decoded, err := base64.StdEncoding.DecodeString(event.Body)
+		if err != nil {
+			return nil, fmt.Errorf("ghastlambda: decode request body: %w", err)
+		}
+		body = decoded
+	}
+
+	target := path
+	if rawQuery != "" {
+		target += "?" + rawQuery
+	}
+	req, err := http.NewRequestWithContext(ctx, method, target, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("ghastlambda: build request: %w", err)
+	}
+	for key, values := range event.MultiValueHeaders {
+		for _, value := range values {
+			req.Header.Add(key, value)
+		}
+	}
+	for key, value := range event.Headers {
+		if _, ok := event.MultiValueHeaders[key]; !ok {
+			req.Header.Set(key, value)
+		}
+	}
+	if len(event.Cookies) > 0 {
+		req.Header.Set("Cookie", strings.Join(event.Cookies, "; "))
+	}
+	req.Host = req.Header.Get("Host")
+	if clientIP == "" {
+		// ALB events carry the client address only in X-Forwarded-For; the first entry is the client
+		clientIP, _, _ = strings.Cut(req.Header.Get("X-Forwarded-For"), ",")
+	}
+	req.RemoteAddr = strings.TrimSpace(clientIP)
+	return req, nil
+}
+
+// encodeQuery builds the raw query string of a REST API or ALB event. ALB events carry query values still
+// URL-encoded, while API Gateway decodes them.
+func encodeQuery(event Event) string {
+	values := url.Values{}
+	unescape := func(s string) string {
+		if event.RequestContext.ELB == nil {
+			return s
+		}
+		if decoded, err := url.QueryUnescape(s); err == nil {
+			return decoded
+		}
+		return s
+	}
+	if len(event.MultiValueQueryStringParameters) > 0 {
+		for key, vals := range event.MultiValueQueryStringParameters {
+			for _, v := range vals {
+				values.Add(unescape(key), unescape(v))
+			}
+		}
+	} else {
+		for key, v := range event.QueryStringParameters {
+			values.Set(unescape(key), unescape(v))
+		}
+	}
+	return values.Encode()
+}
+
+// newResponse converts the recorded response into the format matching event.
+func newResponse(event Event, rec *responseBuffer) *Response {
+	res := &Response{StatusCode: rec.statusCode}
+	if event.RequestContext.ELB != nil {
+		res.StatusDescription = fmt.Sprintf("%d %s", rec.statusCode, ghast.StatusText(rec.statusCode))
+	}
+
+	if event.Version == "2.0" {
+		res.Cookies = rec.header.Values("Set-Cookie")
+		rec.header.Del("Set-Cookie")
+	}
+	if event.MultiValueHeaders != nil {
+		res.MultiValueHeaders = rec.header
+	} else {
+		res.Headers = make(map[string]string, len(rec.header))
+		for key, values := range rec.header {
+			res.Headers[key] = strings.Join(values, ", ")
+		}
+	}
+
+	if isText(rec.header.Get("Content-Type")) {
+		res.Body = rec.body.String()
+	} else {
+		res.Body = base64.StdEncoding.EncodeToString(rec.body.Bytes())
+		res.IsBase64Encoded = true
+	}
+	return res
+}
+
+// isText reports whether a body of the given content type can be returned without base64 encoding.
+func isText(contentType string) bool {
+	if contentType == "" {
+		return true
+	}
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	return strings.HasPrefix(mediaType, "text/") || strings.HasSuffix(mediaType, "json") ||
+		strings.HasSuffix(mediaType, "xml") || mediaType == "application/javascript" ||
+		mediaType == "application/x-www-form-urlencoded"
+}
+
+// responseBuffer is the http.ResponseWriter that collects a response for the Lambda runtime.
+type responseBuffer struct {
+	header      http.Header
+	statusCode  int
+	wroteHeader bool
+	body        bytes.Buffer
+}
+
+func (b *responseBuffer) Header() http.Header {
+	return b.header
+}
+
+func (b *responseBuffer) WriteHeader(statusCode int) {
+	if !b.wroteHeader {
+		b.statusCode = statusCode
+		b.wroteHeader = true
+	}
+}
+
+func (b *responseBuffer) Write(p []byte) (int, error) {
+	b.wroteHeader = true
+	return b.body.Write(p)
+}
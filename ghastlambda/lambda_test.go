@@ -0,0 +1,120 @@
+package ghastlambda
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"testing"
+
+	"github.com/Leonard-Atorough/ghast"
+)
+
+func newTestApp() *ghast.Ghast {
+	app := ghast.New()
+	app.Post("/users/:id", ghast.HandlerFunc(func(w ghast.ResponseWriter, r *ghast.Request) {
+		w.SetHeader("Set-Cookie", "session=abc")
+		w.JSON(201, map[string]string{"id": r.Param("id"), "q": r.Query("q"), "body": string(r.Body), "ip": r.ClientIP, "token": r.GetHeader("X-Token")})
+	}))
+	app.Get("/logo.png", ghast.HandlerFunc(func(w ghast.ResponseWriter, r *ghast.Request) {
+		w.SetHeader("Content-Type", "image/png")
+		w.Send([]byte{0x89, 'P', 'N', 'G'})
+	}))
+	return app
+}
+
+func decodeEvent(t *testing.T, raw string) Event {
+	t.Helper()
+	var event Event
+	if err := json.Unmarshal([]byte(raw), &event); err != nil {
+		t.Fatalf("decode event: %v", err)
+	}
+	return event
+}
+
+// expectedBody is the response of the test application. Query values reach handlers as encoded in the query
+// string, as they do when served by the ghast server.
+const expectedBody = `{"body":"hello","id":"42","ip":"203.0.113.9","q":"a+b","token":"secret"}`
+
+// TestHandlerRESTAPIEvent tests a payload format 1.0 event with a base64 encoded body
+func TestHandlerRESTAPIEvent(t *testing.T) {
+	event := decodeEvent(t, `{
+		"httpMethod": "POST",
+		"path": "/users/42",
+		"queryStringParameters": {"q": "a b"},
+		"headers": {"X-Token": "secret", "Host": "api.example.com"},
+		"requestContext": {"identity": {"sourceIp": "203.0.113.9"}},
+		"body": "`+base64.StdEncoding.EncodeToString([]byte("hello"))+`",
+		"isBase64Encoded": true
+	}`)
+
+	res, err := Handler(newTestApp())(context.Background(), event)
+	if err != nil {
+		t.Fatalf("handler failed: %v", err)
+	}
+	if res.StatusCode != 201 || res.Body != expectedBody || res.IsBase64Encoded {
+		t.Errorf("unexpected response: %+v", res)
+	}
+	if res.Headers["Content-Type"] != "application/json" || res.Headers["Set-Cookie"] != "session=abc" {
+		t.Errorf("unexpected headers: %v", res.Headers)
+	}
+}
+
+// TestHandlerHTTPAPIEvent tests a payload format 2.0 event, whose cookies are returned separately
+func TestHandlerHTTPAPIEvent(t *testing.T) {
+	event := decodeEvent(t, `{
+		"version": "2.0",
+		"rawPath": "/users/42",
+		"rawQueryString": "q=a+b",
+		"cookies": ["theme=dark"],
+		"headers": {"x-token": "secret"},
+		"requestContext": {"http": {"method": "POST", "sourceIp": "203.0.113.9"}},
+		"body": "hello"
+	}`)
+
+	res, err := Handler(newTestApp())(context.Background(), event)
+	if err != nil {
+		t.Fatalf("handler failed: %v", err)
+	}
+	if res.StatusCode != 201 || res.Body != expectedBody {
+		t.Errorf("unexpected response: %+v", res)
+	}
+	if len(res.Cookies) != 1 || res.Cookies[0] != "session=abc" || res.Headers["Set-Cookie"] != "" {
+		t.Errorf("expected Set-Cookie in cookies, got cookies %v headers %v", res.Cookies, res.Headers)
+	}
+}
+
+// TestHandlerALBEvent tests an ALB event with multi-value headers and an encoded query string
+func TestHandlerALBEvent(t *testing.T) {
+	event := decodeEvent(t, `{
+		"httpMethod": "POST",
+		"path": "/users/42",
+		"multiValueQueryStringParameters": {"q": ["a%20b"]},
+		"multiValueHeaders": {"x-token": ["secret"], "x-forwarded-for": ["203.0.113.9, 10.0.0.1"]},
+		"requestContext": {"elb": {"targetGroupArn": "arn:aws:elasticloadbalancing:tg"}},
+		"body": "hello"
+	}`)
+
+	res, err := Handler(newTestApp())(context.Background(), event)
+	if err != nil {
+		t.Fatalf("handler failed: %v", err)
+	}
+	if res.StatusCode != 201 || res.StatusDescription != "201 Created" || res.Body != expectedBody {
+		t.Errorf("unexpected response: %+v", res)
+	}
+	if got := res.MultiValueHeaders["Content-Type"]; len(got) != 1 || got[0] != "application/json" {
+		t.Errorf("expected multi-value headers, got %v", res.MultiValueHeaders)
+	}
+}
+
+// TestHandlerBinaryResponse tests that non-text bodies are base64 encoded
+func TestHandlerBinaryResponse(t *testing.T) {
+	event := decodeEvent(t, `{"httpMethod": "GET", "path": "/logo.png"}`)
+
+	res, err := Handler(newTestApp())(context.Background(), event)
+	if err != nil {
+		t.Fatalf("handler failed: %v", err)
+	}
+	if !res.IsBase64Encoded || res.Body != base64.StdEncoding.EncodeToString([]byte{0x89, 'P', 'N', 'G'}) {
+		t.Errorf("expected base64 encoded body, got %+v", res)
+	}
+}
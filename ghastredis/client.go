@@ -0,0 +1,249 @@
+// Package ghastredis provides Redis-backed stores for ghast applications that run as several instances: a
+// key/value Store with expiry, suited to caches, which implements middleware.SessionStore and
+// middleware.RateLimitStore so sessions and rate limits are shared by every instance.
+//
+// The package speaks the Redis protocol (RESP) directly, so it adds no dependencies.
+//
+// Example:
+//
+//	client := ghastredis.NewClient(ghastredis.Options{Addr: "localhost:6379"})
+//	defer client.Close()
+//
+//	store := ghastredis.NewStore(client, "myapp:")
+//	app.Use(middleware.SessionMiddleware(middleware.SessionOptions{Store: store}))
+//	app.Use(middleware.RateLimitMiddleware(middleware.RateLimitOptions{RequestsPerMinute: 100, Store: store}))
+package ghastredis
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	defaultPoolSize    = 10
+	defaultDialTimeout = 5 * time.Second
+	defaultTimeout     = 3 * time.Second
+)
+
+// ErrClosed is returned by operations on a closed Client.
+var ErrClosed = errors.New("ghastredis: client closed")
+
+// Options configures a Client.
+type Options struct {
+	Addr        string        // Server address (default: "localhost:6379")
+	Password    string        // Optional: Password sent with AUTH on each new connection
+	DB          int           // Optional: Database selected on each new connection
+	PoolSize    int           // Optional: Maximum number of idle connections kept for reuse (default: 10)
+	DialTimeout time.Duration // Optional: Timeout for establishing a connection (default: 5 seconds)
+	Timeout     time.Duration // Optional: Timeout for a command's round trip when the context has no deadline (default: 3 seconds)
+}
+
+// Error is an error reply from the Redis server.
+type Error string
+
+func (e Error) Error() string {
+	return "ghastredis: " + string(e)
+}
+
+// Client is a Redis client with a pool of connections, safe for concurrent use.
+type Client struct {
+	options Options
+
+	mu     sync.Mutex
+	idle   []*conn
+	closed bool
+}
+
+// NewClient creates a client. Connections are opened as needed, so NewClient doesn't fail if the server is
+// unreachable; the first command does.
+func NewClient(options Options) *Client {
+	if options.Addr == "" {
+		options.Addr = "localhost:6379"
+	}
+	if options.PoolSize <= 0 {
+		options.PoolSize = defaultPoolSize
+	}
+	if options.DialTimeout <= 0 {
+		options.DialTimeout = defaultDialTimeout
+	}
+	if options.Timeout <= 0 {
+		options.Timeout = defaultTimeout
+	}
+	return &Client{options: options}
+}
+
+// Close closes the idle connections. Commands in progress finish, and their connections are closed when they
+// are returned to the pool.
+func (c *Client) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.closed = true
+	var errs []error
+	for _, cn := range c.idle {
+		if err := cn.Close(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	c.idle = nil
+	return errors.Join(errs...)
+}
+
+// Do sends a command and returns its reply: a string for simple and bulk strings, an int64 for integers, nil
+// for a nil reply, a []any for arrays, whose elements may include Error values, or an Error for error replies. The context's deadline bounds the
+// round trip, or Options.Timeout if it has none, so an unresponsive server can't block the caller.
+func (c *Client) Do(ctx context.Context, args ...string) (any, error) {
+	cn, err := c.get(ctx)
+	if err != nil {
+		return nil, err
+	}
+	cn.SetDeadline(c.deadline(ctx))
+
+	reply, err := cn.do(args...)
+	var redisErr Error
+	if err != nil && !errors.As(err, &redisErr) {
+		cn.Close() // The connection may be mid-reply, so it can't be reused
+		return nil, err
+	}
+	c.put(cn)
+	return reply, err
+}
+
+// get returns an idle connection or dials a new one.
+func (c *Client) get(ctx context.Context) (*conn, error) {
+	c.mu.Lock()
+	if c.closed {
+		c.mu.Unlock()
+		return nil, ErrClosed
+	}
+	if n := len(c.idle); n > 0 {
+		cn := c.idle[n-1]
+		c.idle = c.idle[:n-1]
+		c.mu.Unlock()
+		return cn, nil
+	}
+	c.mu.Unlock()
+
+	dialer := net.Dialer{Timeout: c.options.DialTimeout}
+	nc, err := dialer.DialContext(ctx, "tcp", c.options.Addr)
+	if err != nil {
+		return nil, fmt.Errorf("ghastredis: dial %s: %w", c.options.Addr, err)
+	}
+	cn := &conn{Conn: nc, r: bufio.NewReader(nc), w: bufio.NewWriter(nc)}
+	cn.SetDeadline(c.deadline(ctx))
+	if c.options.Password != "" {
+		if _, err := cn.do("AUTH", c.options.Password); err != nil {
+			cn.Close()
+			return nil, err
+		}
+	}
+	if c.options.DB != 0 {
+		if _, err := cn.do("SELECT", strconv.Itoa(c.options.DB)); err != nil {
+			cn.Close()
+			return nil, err
+		}
+	}
+	return cn, nil
+}
+
+// deadline returns the context's deadline, or Options.Timeout from now if it has none.
+func (c *Client) deadline(ctx context.Context) time.Time {
+	if deadline, ok := ctx.Deadline(); ok {
+		return deadline
+	}
+	return time.Now().Add(c.options.Timeout)
+}
+
+// put returns a connection to the pool, closing it if the pool is full or the client is closed.
+func (c *Client) put(cn *conn) {
+	cn.SetDeadline(time.Time{})
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.closed || len(c.idle) >= c.options.PoolSize {
+		cn.Close()
+		return
+	}
+	c.idle = append(c.idle, cn)
+}
+
+// conn is a single connection to the server.
+type conn struct {
+	net.Conn
+	r *bufio.Reader
+	w *bufio.Writer
+}
+
+// do writes a command as a RESP array of bulk strings and reads the reply.
+func (cn *conn) do(args ...string) (any, error) {
+	fmt.Fprintf(cn.w, "*%d\r\n", len(args))
+	for _, arg := range args {
+		fmt.Fprintf(cn.w, "$%d\r\n%s\r\n", len(arg), arg)
+	}
+	if err := cn.w.Flush(); err != nil {
+		return nil, err
+	}
+	return readReply(cn.r)
+}
+
+// readReply reads a single RESP reply.
+func readReply(r *bufio.Reader) (any, error) {
+	line, err := r.ReadString('\n')
+	if err != nil {
+		return nil, err
+	}
+	if len(line) < 3 || line[len(line)-2] != '\r' {
+		return nil, fmt.Errorf("ghastredis: malformed reply %q", line)
+	}
+	kind, payload := line[0], line[1:len(line)-2]
+
+	switch kind {
+	case '+':
+		return payload, nil
+	case '-':
+		return nil, Error(payload)
+	case ':':
+		return strconv.ParseInt(payload, 10, 64)
+	case '$':
+		n, err := strconv.Atoi(payload)
+		if err != nil {
+			return nil, fmt.Errorf("ghastredis: malformed bulk length %q", payload)
+		}
+		if n < 0 {
+			return nil, nil
+		}
+		buf := make([]byte, n+2)
+		if _, err := io.ReadFull(r, buf); err != nil {
+			return nil, err
+		}
+		return string(buf[:n]), nil
+	case '*':
+		n, err := strconv.Atoi(payload)
+		if err != nil {
+			return nil, fmt.Errorf("ghastredis: malformed array length %q", payload)
+		}
+		if n < 0 {
+			return nil, nil
+		}
+		// Every element is read, even after an error reply, so the connection is left at the next reply. Error
+		// replies, such as failed commands in a transaction, are kept as Error elements.
+		items := make([]any, n)
+		for i := range items {
+			item, err := readReply(r)
+			var redisErr Error
+			if errors.As(err, &redisErr) {
+				item = redisErr
+			} else if err != nil {
+				return nil, err
+			}
+			items[i] = item
+		}
+		return items, nil
+	}
+	return nil, fmt.Errorf("ghastredis: unknown reply type %q", kind)
+}
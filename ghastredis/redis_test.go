@@ -0,0 +1,368 @@
+package ghastredis
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/Leonard-Atorough/ghast/middleware"
+)
+
+var (
+	_ middleware.SessionStore   = (*Store)(nil)
+	_ middleware.RateLimitStore = (*Store)(nil)
+)
+
+// fakeRedis is an in-process server implementing the handful of commands the package sends.
+type fakeRedis struct {
+	mu       sync.Mutex
+	values   map[string]string
+	expires  map[string]time.Time
+	password string
+	dials    int
+}
+
+func startFakeRedis(t *testing.T, password string) (*fakeRedis, string) {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+
+	f := &fakeRedis{values: map[string]string{}, expires: map[string]time.Time{}, password: password}
+	go func() {
+		for {
+			c, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			f.mu.Lock()
+			f.dials++
+			f.mu.Unlock()
+			go f.serve(c)
+		}
+	}()
+	return f, ln.Addr().String()
+}
+
+func (f *fakeRedis) serve(c net.Conn) {
+	defer c.Close()
+	r := bufio.NewReader(c)
+	authed := f.password == ""
+	for {
+		reply, err := readReply(r)
+		if err != nil {
+			return
+		}
+		items, _ := reply.([]any)
+		args := make([]string, len(items))
+		for i, item := range items {
+			args[i], _ = item.(string)
+		}
+		if len(args) == 0 {
+			return
+		}
+
+		cmd := strings.ToUpper(args[0])
+		if cmd == "AUTH" {
+			if len(args) == 2 && args[1] == f.password {
+				authed = true
+				fmt.Fprint(c, "+OK\r\n")
+			} else {
+				fmt.Fprint(c, "-WRONGPASS invalid password\r\n")
+			}
+			continue
+		}
+		if !authed {
+			fmt.Fprint(c, "-NOAUTH Authentication required.\r\n")
+			continue
+		}
+		fmt.Fprint(c, f.exec(cmd, args[1:]))
+	}
+}
+
+func (f *fakeRedis) exec(cmd string, args []string) string {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for key, at := range f.expires {
+		if !time.Now().Before(at) {
+			delete(f.values, key)
+			delete(f.expires, key)
+		}
+	}
+
+	switch cmd {
+	case "SELECT":
+		return "+OK\r\n"
+	case "GET":
+		value, ok := f.values[args[0]]
+		if !ok {
+			return "$-1\r\n"
+		}
+		return fmt.Sprintf("$%d\r\n%s\r\n", len(value), value)
+	case "SET":
+		key, value := args[0], args[1]
+		var ttl time.Duration
+		nx := false
+		for i := 2; i < len(args); i++ {
+			switch strings.ToUpper(args[i]) {
+			case "NX":
+				nx = true
+			case "PX":
+				ms, _ := strconv.Atoi(args[i+1])
+				ttl = time.Duration(ms) * time.Millisecond
+				i++
+			}
+		}
+		if _, exists := f.values[key]; nx && exists {
+			return "$-1\r\n"
+		}
+		f.values[key] = value
+		delete(f.expires, key)
+		if ttl > 0 {
+			f.expires[key] = time.Now().Add(ttl)
+		}
+		return "+OK\r\n"
+	case "DEL":
+		_, exists := f.values[args[0]]
+		delete(f.values, args[0])
+		delete(f.expires, args[0])
+		if exists {
+			return ":1\r\n"
+		}
+		return ":0\r\n"
+	case "EVAL":
+		if args[0] != incrementScript || args[1] != "1" {
+			return "-ERR unknown script\r\n"
+		}
+		reply := f.incr(args[2])
+		if reply == ":1\r\n" {
+			ms, _ := strconv.Atoi(args[3])
+			f.expires[args[2]] = time.Now().Add(time.Duration(ms) * time.Millisecond)
+		}
+		return reply
+	case "INCR":
+		return f.incr(args[0])
+	case "EXEC":
+		return "*3\r\n+OK\r\n-WRONGTYPE Operation against a key holding the wrong kind of value\r\n:2\r\n"
+	}
+	return fmt.Sprintf("-ERR unknown command '%s'\r\n", cmd)
+}
+
+// incr increments the integer stored under key. The caller holds f.mu.
+func (f *fakeRedis) incr(key string) string {
+	n := 0
+	if value, ok := f.values[key]; ok {
+		var err error
+		if n, err = strconv.Atoi(value); err != nil {
+			return "-ERR value is not an integer or out of range\r\n"
+		}
+	}
+	n++
+	f.values[key] = strconv.Itoa(n)
+	return fmt.Sprintf(":%d\r\n", n)
+}
+
+func TestStoreGetSetDelete(t *testing.T) {
+	_, addr := startFakeRedis(t, "")
+	client := NewClient(Options{Addr: addr})
+	defer client.Close()
+	store := NewStore(client, "app:")
+	ctx := context.Background()
+
+	if _, found, err := store.Get(ctx, "session"); err != nil || found {
+		t.Fatalf("Get missing key = found %v, err %v", found, err)
+	}
+	if err := store.Set(ctx, "session", []byte("alice\r\nbinary\x00"), 0); err != nil {
+		t.Fatal(err)
+	}
+	value, found, err := store.Get(ctx, "session")
+	if err != nil || !found || string(value) != "alice\r\nbinary\x00" {
+		t.Fatalf("Get = %q, %v, %v", value, found, err)
+	}
+	if err := store.Delete(ctx, "session"); err != nil {
+		t.Fatal(err)
+	}
+	if _, found, _ := store.Get(ctx, "session"); found {
+		t.Error("expected key to be deleted")
+	}
+}
+
+func TestStoreSetExpires(t *testing.T) {
+	_, addr := startFakeRedis(t, "")
+	client := NewClient(Options{Addr: addr})
+	defer client.Close()
+	store := NewStore(client, "")
+	ctx := context.Background()
+
+	if err := store.Set(ctx, "k", []byte("v"), 20*time.Millisecond); err != nil {
+		t.Fatal(err)
+	}
+	time.Sleep(40 * time.Millisecond)
+	if _, found, _ := store.Get(ctx, "k"); found {
+		t.Error("expected key to expire")
+	}
+}
+
+func TestStoreIncrement(t *testing.T) {
+	f, addr := startFakeRedis(t, "")
+	client := NewClient(Options{Addr: addr})
+	defer client.Close()
+	store := NewStore(client, "rl:")
+	ctx := context.Background()
+
+	for want := 1; want <= 3; want++ {
+		got, err := store.Increment(ctx, "10.0.0.1", 30*time.Millisecond)
+		if err != nil || got != want {
+			t.Fatalf("Increment = %d, %v; want %d", got, err, want)
+		}
+	}
+	f.mu.Lock()
+	_, hasExpiry := f.expires["rl:10.0.0.1"]
+	f.mu.Unlock()
+	if !hasExpiry {
+		t.Fatal("expected the counter to expire with the window")
+	}
+
+	time.Sleep(50 * time.Millisecond)
+	if got, _ := store.Increment(ctx, "10.0.0.1", 30*time.Millisecond); got != 1 {
+		t.Errorf("count after window = %d, want 1", got)
+	}
+}
+
+func TestClientReusesConnections(t *testing.T) {
+	f, addr := startFakeRedis(t, "")
+	client := NewClient(Options{Addr: addr})
+	defer client.Close()
+	store := NewStore(client, "")
+
+	for i := 0; i < 5; i++ {
+		if err := store.Set(context.Background(), "k", []byte("v"), 0); err != nil {
+			t.Fatal(err)
+		}
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.dials != 1 {
+		t.Errorf("dials = %d, want 1", f.dials)
+	}
+}
+
+func TestClientAuth(t *testing.T) {
+	_, addr := startFakeRedis(t, "secret")
+
+	client := NewClient(Options{Addr: addr, Password: "secret", DB: 2})
+	defer client.Close()
+	if err := NewStore(client, "").Set(context.Background(), "k", []byte("v"), 0); err != nil {
+		t.Fatalf("authenticated Set: %v", err)
+	}
+
+	bad := NewClient(Options{Addr: addr, Password: "wrong"})
+	defer bad.Close()
+	_, err := bad.Do(context.Background(), "GET", "k")
+	var redisErr Error
+	if !errors.As(err, &redisErr) || !strings.HasPrefix(string(redisErr), "WRONGPASS") {
+		t.Errorf("err = %v, want WRONGPASS error", err)
+	}
+}
+
+func TestClientErrorReplyKeepsConnection(t *testing.T) {
+	f, addr := startFakeRedis(t, "")
+	client := NewClient(Options{Addr: addr})
+	defer client.Close()
+
+	_, err := client.Do(context.Background(), "NOPE")
+	var redisErr Error
+	if !errors.As(err, &redisErr) {
+		t.Fatalf("err = %v, want Error", err)
+	}
+	if _, err := client.Do(context.Background(), "GET", "k"); err != nil {
+		t.Fatal(err)
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.dials != 1 {
+		t.Errorf("dials = %d, want 1", f.dials)
+	}
+}
+
+func TestClientArrayWithErrorReply(t *testing.T) {
+	f, addr := startFakeRedis(t, "")
+	client := NewClient(Options{Addr: addr})
+	defer client.Close()
+
+	reply, err := client.Do(context.Background(), "EXEC")
+	if err != nil {
+		t.Fatal(err)
+	}
+	items, ok := reply.([]any)
+	if !ok || len(items) != 3 {
+		t.Fatalf("reply = %#v, want 3 elements", reply)
+	}
+	if items[0] != "OK" || items[2] != int64(2) {
+		t.Errorf("items = %#v", items)
+	}
+	if _, ok := items[1].(Error); !ok {
+		t.Errorf("items[1] = %#v, want Error", items[1])
+	}
+
+	if reply, err := client.Do(context.Background(), "GET", "k"); err != nil || reply != nil {
+		t.Fatalf("GET after array = %#v, %v; want nil reply", reply, err)
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.dials != 1 {
+		t.Errorf("dials = %d, want 1", f.dials)
+	}
+}
+
+func TestClientTimeout(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	go func() {
+		for {
+			c, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			defer c.Close() // Never replies
+		}
+	}()
+
+	client := NewClient(Options{Addr: ln.Addr().String(), Timeout: 50 * time.Millisecond})
+	defer client.Close()
+	done := make(chan error, 1)
+	go func() {
+		_, err := client.Do(context.Background(), "GET", "k")
+		done <- err
+	}()
+	select {
+	case err := <-done:
+		if !errors.Is(err, os.ErrDeadlineExceeded) {
+			t.Errorf("err = %v, want deadline exceeded", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Do without a context deadline did not time out")
+	}
+}
+
+func TestClientClosed(t *testing.T) {
+	_, addr := startFakeRedis(t, "")
+	client := NewClient(Options{Addr: addr})
+	client.Close()
+	if _, err := client.Do(context.Background(), "GET", "k"); !errors.Is(err, ErrClosed) {
+		t.Errorf("err = %v, want ErrClosed", err)
+	}
+}
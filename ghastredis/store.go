@@ -0,0 +1,73 @@
+package ghastredis
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// Store is a key/value store with per-key expiry kept in Redis, for state shared between instances of an
+// application such as sessions and cached responses. It implements middleware.SessionStore and
+// middleware.RateLimitStore.
+type Store struct {
+	client *Client
+	prefix string // Prepended to every key, so several applications can share a database
+}
+
+// NewStore creates a store that keeps its keys, prefixed with prefix, in the client's database.
+func NewStore(client *Client, prefix string) *Store {
+	return &Store{client: client, prefix: prefix}
+}
+
+// Get returns the value stored under key and whether it exists.
+func (s *Store) Get(ctx context.Context, key string) ([]byte, bool, error) {
+	reply, err := s.client.Do(ctx, "GET", s.prefix+key)
+	if err != nil {
+		return nil, false, err
+	}
+	if reply == nil {
+		return nil, false, nil
+	}
+	value, ok := reply.(string)
+	if !ok {
+		return nil, false, fmt.Errorf("ghastredis: unexpected GET reply %T", reply)
+	}
+	return []byte(value), true, nil
+}
+
+// Set stores value under key. A positive ttl expires the key after that duration; otherwise it never expires.
+func (s *Store) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	args := []string{"SET", s.prefix + key, string(value)}
+	if ttl > 0 {
+		args = append(args, "PX", strconv.FormatInt(ttl.Milliseconds(), 10))
+	}
+	_, err := s.client.Do(ctx, args...)
+	return err
+}
+
+// Delete removes key. Deleting a missing key is not an error.
+func (s *Store) Delete(ctx context.Context, key string) error {
+	_, err := s.client.Do(ctx, "DEL", s.prefix+key)
+	return err
+}
+
+// incrementScript increments a counter and starts its expiry when the increment created it. Running both in one
+// script keeps them atomic, so a counter can't be left without an expiry or have it reset mid-window.
+const incrementScript = `local n = redis.call('INCR', KEYS[1])
+if n == 1 then redis.call('PEXPIRE', KEYS[1], ARGV[1]) end
+return n`
+
+// Increment implements middleware.RateLimitStore. The first increment of a key sets the window as its expiry,
+// and later increments keep it, so the count resets when the window ends.
+func (s *Store) Increment(ctx context.Context, key string, window time.Duration) (int, error) {
+	reply, err := s.client.Do(ctx, "EVAL", incrementScript, "1", s.prefix+key, strconv.FormatInt(window.Milliseconds(), 10))
+	if err != nil {
+		return 0, err
+	}
+	count, ok := reply.(int64)
+	if !ok {
+		return 0, fmt.Errorf("ghastredis: unexpected EVAL reply %T", reply)
+	}
+	return int(count), nil
+}
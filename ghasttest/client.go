@@ -0,0 +1,210 @@
+package ghasttest
+
+import (
+	"encoding/json"
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/Leonard-Atorough/ghast"
+)
+
+// Client sends requests through a ghast application in-process, running the full middleware chain, and
+// reports failed expectations on the test it was created with.
+//
+// Example:
+//
+//	client := ghasttest.NewClient(t, app)
+//	client.Get("/users/1").WithHeader("Authorization", token).Do().
+//	    ExpectStatus(200).
+//	    ExpectJSON(map[string]any{"id": "1", "name": "alice"})
+type Client struct {
+	t       testing.TB
+	app     *ghast.Ghast
+	headers map[string]string // Headers sent with every request
+}
+
+// ClientRequest builds a single request sent by a Client.
+type ClientRequest struct {
+	client *Client
+	req    *ghast.Request
+	query  url.Values
+}
+
+// Result is the response to a request sent by a Client. Its Expect methods report mismatches with t.Errorf and
+// return the result, so expectations can be chained.
+type Result struct {
+	*ghast.Response
+	t testing.TB
+}
+
+// NewClient creates a client that sends requests to app and reports failures on t.
+func NewClient(t testing.TB, app *ghast.Ghast) *Client {
+	return &Client{
+		t:       t,
+		app:     app,
+		headers: make(map[string]string),
+	}
+}
+
+// WithHeader sets a header sent with every request made by the client, such as an authorization token.
+func (c *Client) WithHeader(key, value string) *Client {
+	c.headers[key] = value
+	return c
+}
+
+// Request starts building a request with the given method and path. The path may include a query string.
+func (c *Client) Request(method, path string) *ClientRequest {
+	headers := make(map[string]string, len(c.headers))
+	for k, v := range c.headers {
+		headers[k] = v
+	}
+	return &ClientRequest{
+		client: c,
+		req:    &ghast.Request{Method: method, Path: path, Headers: headers},
+		query:  url.Values{},
+	}
+}
+
+// Get starts building a GET request.
+func (c *Client) Get(path string) *ClientRequest {
+	return c.Request(ghast.GET, path)
+}
+
+// Post starts building a POST request.
+func (c *Client) Post(path string) *ClientRequest {
+	return c.Request(ghast.POST, path)
+}
+
+// Put starts building a PUT request.
+func (c *Client) Put(path string) *ClientRequest {
+	return c.Request(ghast.PUT, path)
+}
+
+// Delete starts building a DELETE request.
+func (c *Client) Delete(path string) *ClientRequest {
+	return c.Request(ghast.DELETE, path)
+}
+
+// Patch starts building a PATCH request.
+func (c *Client) Patch(path string) *ClientRequest {
+	return c.Request(ghast.PATCH, path)
+}
+
+// Head starts building a HEAD request.
+func (c *Client) Head(path string) *ClientRequest {
+	return c.Request(ghast.HEAD, path)
+}
+
+// Options starts building an OPTIONS request.
+func (c *Client) Options(path string) *ClientRequest {
+	return c.Request(ghast.OPTIONS, path)
+}
+
+// WithHeader sets a request header.
+func (b *ClientRequest) WithHeader(key, value string) *ClientRequest {
+	b.req.Headers[key] = value
+	return b
+}
+
+// WithQuery adds a query parameter.
+func (b *ClientRequest) WithQuery(key, value string) *ClientRequest {
+	b.query.Add(key, value)
+	return b
+}
+
+// WithBody sets the raw request body.
+func (b *ClientRequest) WithBody(body string) *ClientRequest {
+	b.req.Body = []byte(body)
+	return b
+}
+
+// WithJSON sets the request body to v marshaled as JSON, with the application/json content type.
+func (b *ClientRequest) WithJSON(v any) *ClientRequest {
+	b.client.t.Helper()
+	data, err := json.Marshal(v)
+	if err != nil {
+		b.client.t.Fatalf("ghasttest: marshal request body: %v", err)
+	}
+	b.req.Body = data
+	b.req.Headers["Content-Type"] = "application/json"
+	return b
+}
+
+// Do sends the request through the application and returns the result.
+func (b *ClientRequest) Do() *Result {
+	if len(b.query) > 0 {
+		sep := "?"
+		if strings.Contains(b.req.Path, "?") {
+			sep = "&"
+		}
+		b.req.Path += sep + b.query.Encode()
+	}
+	return &Result{Response: b.client.app.Test(b.req), t: b.client.t}
+}
+
+// ExpectStatus checks the response status code.
+func (r *Result) ExpectStatus(code int) *Result {
+	r.t.Helper()
+	if r.StatusCode != code {
+		r.t.Errorf("expected status %d, got %d (body: %q)", code, r.StatusCode, r.Body)
+	}
+	return r
+}
+
+// ExpectHeader checks a response header value.
+func (r *Result) ExpectHeader(key, value string) *Result {
+	r.t.Helper()
+	if got := r.Header(key); got != value {
+		r.t.Errorf("expected header %s to be %q, got %q", key, value, got)
+	}
+	return r
+}
+
+// ExpectBody checks that the response body equals body.
+func (r *Result) ExpectBody(body string) *Result {
+	r.t.Helper()
+	if r.Body != body {
+		r.t.Errorf("expected body %q, got %q", body, r.Body)
+	}
+	return r
+}
+
+// ExpectBodyContains checks that the response body contains substr.
+func (r *Result) ExpectBodyContains(substr string) *Result {
+	r.t.Helper()
+	if !strings.Contains(r.Body, substr) {
+		r.t.Errorf("expected body to contain %q, got %q", substr, r.Body)
+	}
+	return r
+}
+
+// ExpectJSON checks that the response body is JSON equal to expected. Both sides are compared as decoded JSON,
+// so key order and whitespace don't matter and expected may be a struct, map or slice.
+func (r *Result) ExpectJSON(expected any) *Result {
+	r.t.Helper()
+	data, err := json.Marshal(expected)
+	if err != nil {
+		r.t.Fatalf("ghasttest: marshal expected JSON: %v", err)
+	}
+	var want, got any
+	json.Unmarshal(data, &want)
+	if err := json.Unmarshal([]byte(r.Body), &got); err != nil {
+		r.t.Errorf("expected JSON body, got %q: %v", r.Body, err)
+		return r
+	}
+	if !reflect.DeepEqual(want, got) {
+		r.t.Errorf("expected JSON %s, got %s", data, r.Body)
+	}
+	return r
+}
+
+// DecodeJSON unmarshals the response body into v, failing the test if it isn't valid JSON.
+func (r *Result) DecodeJSON(v any) *Result {
+	r.t.Helper()
+	if err := json.Unmarshal([]byte(r.Body), v); err != nil {
+		r.t.Fatalf("ghasttest: decode response body %q: %v", r.Body, err)
+	}
+	return r
+}
@@ -0,0 +1,85 @@
+package ghasttest
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/Leonard-Atorough/ghast"
+)
+
+// recordingTB captures failures reported by expectations so tests can check them.
+type recordingTB struct {
+	testing.TB
+	failures []string
+}
+
+func (r *recordingTB) Helper() {}
+
+func (r *recordingTB) Errorf(format string, args ...any) {
+	r.failures = append(r.failures, fmt.Sprintf(format, args...))
+}
+
+func newUsersApp() *ghast.Ghast {
+	app := ghast.New()
+	app.Use(func(next ghast.Handler) ghast.Handler {
+		return ghast.HandlerFunc(func(w ghast.ResponseWriter, r *ghast.Request) {
+			if r.GetHeader("Authorization") != "secret" {
+				ghast.Error(w, 401, "unauthorized")
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	})
+	app.Get("/users/:id", ghast.HandlerFunc(func(w ghast.ResponseWriter, r *ghast.Request) {
+		w.JSON(200, map[string]string{"id": r.Param("id"), "fields": r.Query("fields")})
+	}))
+	app.Post("/users", ghast.HandlerFunc(func(w ghast.ResponseWriter, r *ghast.Request) {
+		var user map[string]string
+		r.JSON(&user)
+		w.SetHeader("Location", "/users/"+user["name"])
+		w.JSON(201, user)
+	}))
+	return app
+}
+
+// TestClientRunsMiddlewareChain tests that client requests pass through app middleware
+func TestClientRunsMiddlewareChain(t *testing.T) {
+	client := NewClient(t, newUsersApp())
+
+	client.Get("/users/1").Do().ExpectStatus(401)
+
+	client.Get("/users/1").WithHeader("Authorization", "secret").WithQuery("fields", "name").Do().
+		ExpectStatus(200).
+		ExpectHeader("content-type", "application/json").
+		ExpectJSON(map[string]string{"fields": "name", "id": "1"})
+}
+
+// TestClientJSONBodyAndDefaultHeaders tests JSON request bodies and client-wide headers
+func TestClientJSONBodyAndDefaultHeaders(t *testing.T) {
+	client := NewClient(t, newUsersApp()).WithHeader("Authorization", "secret")
+
+	var created map[string]string
+	client.Post("/users").WithJSON(map[string]string{"name": "alice"}).Do().
+		ExpectStatus(201).
+		ExpectHeader("Location", "/users/alice").
+		DecodeJSON(&created)
+
+	if created["name"] != "alice" {
+		t.Errorf("unexpected decoded body: %v", created)
+	}
+}
+
+// TestClientReportsFailedExpectations tests that mismatches are reported on the test
+func TestClientReportsFailedExpectations(t *testing.T) {
+	tb := &recordingTB{TB: t}
+	client := NewClient(tb, newUsersApp())
+
+	client.Get("/users/1").Do().
+		ExpectStatus(200).
+		ExpectBodyContains("forbidden").
+		ExpectJSON(map[string]any{"status": 401})
+
+	if len(tb.failures) != 3 {
+		t.Errorf("expected 3 failures, got %d: %v", len(tb.failures), tb.failures)
+	}
+}
@@ -0,0 +1,217 @@
+package ghasttest
+
+import (
+	"bytes"
+	"io"
+	"net"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/Leonard-Atorough/ghast"
+)
+
+// Conn is one end of an in-memory connection created by NewConnPair. Unlike net.Pipe, writes are buffered and
+// never block, so a test can write a whole request before reading the response without deadlocking the server.
+// Read deadlines are honored, which makes timeout behavior deterministic.
+type Conn struct {
+	in     *pipeBuffer // Data written by the peer
+	out    *pipeBuffer // Data written by this end
+	local  net.Addr
+	remote net.Addr
+
+	mu            sync.Mutex
+	readDeadline  time.Time
+	writeDeadline time.Time
+	closed        bool
+}
+
+// NewConnPair returns two connected in-memory connections. Data written to one can be read from the other.
+// The client end's address is 127.0.0.1:49152 and the server end's is 127.0.0.1:8080, so handlers see a
+// loopback ClientIP.
+func NewConnPair() (client, server *Conn) {
+	clientAddr := &net.TCPAddr{IP: net.IPv4(127, 0, 0, 1), Port: 49152}
+	serverAddr := &net.TCPAddr{IP: net.IPv4(127, 0, 0, 1), Port: 8080}
+	toServer := newPipeBuffer()
+	toClient := newPipeBuffer()
+
+	client = &Conn{in: toClient, out: toServer, local: clientAddr, remote: serverAddr}
+	server = &Conn{in: toServer, out: toClient, local: serverAddr, remote: clientAddr}
+	return client, server
+}
+
+// Dial connects to app over an in-memory connection. The app serves the connection in the background until
+// either end closes it.
+//
+// Example:
+//
+//	conn := ghasttest.Dial(app)
+//	defer conn.Close()
+//	conn.Write([]byte("GET /health HTTP/1.1\r\nHost: localhost\r\nConnection: keep-alive\r\n\r\n"))
+func Dial(app *ghast.Ghast) *Conn {
+	client, server := NewConnPair()
+	go app.ServeConn(server)
+	return client
+}
+
+// Read reads data written by the peer, blocking until data arrives, the peer closes its end or the read
+// deadline passes.
+func (c *Conn) Read(b []byte) (int, error) {
+	if c.isClosed() {
+		return 0, net.ErrClosed
+	}
+	return c.in.read(b, c.getReadDeadline)
+}
+
+// Write buffers data for the peer to read. It fails if either end has been closed or the write deadline has
+// passed.
+func (c *Conn) Write(b []byte) (int, error) {
+	if c.isClosed() {
+		return 0, net.ErrClosed
+	}
+	c.mu.Lock()
+	deadline := c.writeDeadline
+	c.mu.Unlock()
+	if !deadline.IsZero() && !time.Now().Before(deadline) {
+		return 0, os.ErrDeadlineExceeded
+	}
+	return c.out.write(b)
+}
+
+// Close closes the connection. The peer reads any data already written and then io.EOF, and its writes fail.
+func (c *Conn) Close() error {
+	c.mu.Lock()
+	if c.closed {
+		c.mu.Unlock()
+		return net.ErrClosed
+	}
+	c.closed = true
+	c.mu.Unlock()
+
+	c.out.close()
+	c.in.close()
+	return nil
+}
+
+// LocalAddr returns the address of this end of the connection.
+func (c *Conn) LocalAddr() net.Addr {
+	return c.local
+}
+
+// RemoteAddr returns the address of the peer.
+func (c *Conn) RemoteAddr() net.Addr {
+	return c.remote
+}
+
+// SetDeadline sets both the read and write deadlines.
+func (c *Conn) SetDeadline(t time.Time) error {
+	c.SetReadDeadline(t)
+	return c.SetWriteDeadline(t)
+}
+
+// SetReadDeadline sets the deadline for current and future reads. A zero value disables the deadline.
+func (c *Conn) SetReadDeadline(t time.Time) error {
+	c.mu.Lock()
+	c.readDeadline = t
+	c.mu.Unlock()
+	c.in.wake() // Blocked readers re-check the new deadline
+	return nil
+}
+
+// SetWriteDeadline sets the deadline for future writes. A zero value disables the deadline.
+func (c *Conn) SetWriteDeadline(t time.Time) error {
+	c.mu.Lock()
+	c.writeDeadline = t
+	c.mu.Unlock()
+	return nil
+}
+
+func (c *Conn) getReadDeadline() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.readDeadline
+}
+
+func (c *Conn) isClosed() bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.closed
+}
+
+// pipeBuffer is an unbounded buffer carrying data in one direction of a Conn pair.
+type pipeBuffer struct {
+	mu     sync.Mutex
+	data   bytes.Buffer
+	closed bool
+	signal chan struct{} // Closed and replaced whenever data arrives, the buffer closes or a deadline changes
+}
+
+func newPipeBuffer() *pipeBuffer {
+	return &pipeBuffer{signal: make(chan struct{})}
+}
+
+// read blocks until data is available, the buffer is closed or the deadline returned by deadline passes.
+func (p *pipeBuffer) read(b []byte, deadline func() time.Time) (int, error) {
+	for {
+		p.mu.Lock()
+		if p.data.Len() > 0 {
+			n, _ := p.data.Read(b)
+			p.mu.Unlock()
+			return n, nil
+		}
+		if p.closed {
+			p.mu.Unlock()
+			return 0, io.EOF
+		}
+		signal := p.signal
+		p.mu.Unlock()
+
+		d := deadline()
+		if d.IsZero() {
+			<-signal
+			continue
+		}
+		wait := time.Until(d)
+		if wait <= 0 {
+			return 0, os.ErrDeadlineExceeded
+		}
+		timer := time.NewTimer(wait)
+		select {
+		case <-signal:
+			timer.Stop()
+		case <-timer.C:
+			return 0, os.ErrDeadlineExceeded
+		}
+	}
+}
+
+func (p *pipeBuffer) write(b []byte) (int, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.closed {
+		return 0, io.ErrClosedPipe
+	}
+	n, _ := p.data.Write(b)
+	p.notifyLocked()
+	return n, nil
+}
+
+func (p *pipeBuffer) close() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if !p.closed {
+		p.closed = true
+		p.notifyLocked()
+	}
+}
+
+func (p *pipeBuffer) wake() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.notifyLocked()
+}
+
+func (p *pipeBuffer) notifyLocked() {
+	close(p.signal)
+	p.signal = make(chan struct{})
+}
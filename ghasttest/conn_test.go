@@ -0,0 +1,92 @@
+package ghasttest
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Leonard-Atorough/ghast"
+)
+
+// TestDialKeepAlive tests that several requests can be served over one kept-alive in-memory connection
+func TestDialKeepAlive(t *testing.T) {
+	app := ghast.New()
+	app.Get("/ip", ghast.HandlerFunc(func(w ghast.ResponseWriter, r *ghast.Request) {
+		w.Plain(200, r.ClientIP)
+	}))
+
+	conn := Dial(app)
+	defer conn.Close()
+	conn.Write([]byte("GET /ip HTTP/1.1\r\nHost: localhost\r\nConnection: keep-alive\r\n\r\n"))
+	conn.Write([]byte("GET /ip HTTP/1.1\r\nHost: localhost\r\nConnection: keep-alive\r\n\r\n"))
+
+	reader := bufio.NewReader(conn)
+	conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	for i := range 2 {
+		status, err := reader.ReadString('\n')
+		if err != nil {
+			t.Fatalf("response %d: %v", i+1, err)
+		}
+		if !strings.HasPrefix(status, "HTTP/1.1 200") {
+			t.Errorf("response %d: unexpected status line %q", i+1, status)
+		}
+		// The writer sends no Content-Length yet, so read up to the known body
+		for {
+			line, err := reader.ReadString('\n')
+			if err != nil {
+				t.Fatalf("response %d: %v", i+1, err)
+			}
+			if line == "\r\n" {
+				break
+			}
+		}
+		body := make([]byte, len("127.0.0.1"))
+		if _, err := io.ReadFull(reader, body); err != nil || string(body) != "127.0.0.1" {
+			t.Errorf("response %d: unexpected body %q (%v)", i+1, body, err)
+		}
+	}
+}
+
+// TestDialServerClosesConnection tests that the server closes the connection when the client asks it to
+func TestDialServerClosesConnection(t *testing.T) {
+	app := ghast.New()
+	app.Get("/", ghast.HandlerFunc(func(w ghast.ResponseWriter, r *ghast.Request) {
+		w.Plain(200, "bye")
+	}))
+
+	conn := Dial(app)
+	defer conn.Close()
+	conn.Write([]byte("GET / HTTP/1.1\r\nHost: localhost\r\nConnection: close\r\n\r\n"))
+	conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+
+	response, err := io.ReadAll(conn)
+	if err != nil {
+		t.Fatalf("expected EOF after response, got %v", err)
+	}
+	if !strings.HasSuffix(string(response), "\r\n\r\nbye") {
+		t.Errorf("unexpected response: %q", response)
+	}
+}
+
+// TestConnReadDeadline tests that blocked reads fail once the read deadline passes
+func TestConnReadDeadline(t *testing.T) {
+	client, server := NewConnPair()
+	defer client.Close()
+	defer server.Close()
+
+	client.SetReadDeadline(time.Now().Add(20 * time.Millisecond))
+	_, err := client.Read(make([]byte, 1))
+	if !errors.Is(err, os.ErrDeadlineExceeded) {
+		t.Errorf("expected deadline exceeded, got %v", err)
+	}
+
+	client.SetReadDeadline(time.Time{})
+	server.Write([]byte("x"))
+	if n, err := client.Read(make([]byte, 1)); n != 1 || err != nil {
+		t.Errorf("expected read to succeed after clearing deadline, got %d %v", n, err)
+	}
+}
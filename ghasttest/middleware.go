@@ -0,0 +1,38 @@
+package ghasttest
+
+import "github.com/Leonard-Atorough/ghast"
+
+// MiddlewareResult describes what happened when a middleware ran.
+type MiddlewareResult struct {
+	*ResponseRecorder                // Everything the middleware (and next) wrote
+	CalledNext        bool           // Whether the middleware passed the request on to the next handler
+	Request           *ghast.Request // Request as received by the next handler, or the original if next was not called
+}
+
+// RunMiddleware runs mw around a next handler that writes nothing and records the request it receives.
+//
+// Example:
+//
+//	res := ghasttest.RunMiddleware(middleware.CorsMiddleware(opts), ghasttest.NewRequest("OPTIONS", "/").Build())
+//	if res.CalledNext {
+//	    t.Error("preflight requests should not reach the handler")
+//	}
+func RunMiddleware(mw ghast.Middleware, req *ghast.Request) *MiddlewareResult {
+	return RunMiddlewareWith(mw, req, ghast.HandlerFunc(func(w ghast.ResponseWriter, r *ghast.Request) {}))
+}
+
+// RunMiddlewareWith runs mw around the given next handler, for middleware whose behavior depends on what the
+// handler does (e.g. recovering from a panic or timing a slow response).
+func RunMiddlewareWith(mw ghast.Middleware, req *ghast.Request, next ghast.Handler) *MiddlewareResult {
+	result := &MiddlewareResult{
+		ResponseRecorder: NewRecorder(),
+		Request:          req,
+	}
+	handler := mw(ghast.HandlerFunc(func(w ghast.ResponseWriter, r *ghast.Request) {
+		result.CalledNext = true
+		result.Request = r
+		next.ServeHTTP(w, r)
+	}))
+	handler.ServeHTTP(result.ResponseRecorder, req)
+	return result
+}
@@ -0,0 +1,56 @@
+package ghasttest
+
+import (
+	"testing"
+
+	"github.com/Leonard-Atorough/ghast"
+	"github.com/Leonard-Atorough/ghast/middleware"
+)
+
+// TestRunMiddlewareStopsChain tests a middleware that answers without calling next
+func TestRunMiddlewareStopsChain(t *testing.T) {
+	cors := middleware.CorsMiddleware(middleware.CorsOptions{})
+	req := NewRequest("OPTIONS", "/users").Header("Access-Control-Request-Method", "POST").Build()
+
+	res := RunMiddleware(cors, req)
+
+	if res.CalledNext {
+		t.Error("expected preflight request not to reach next")
+	}
+	if res.Code != 200 {
+		t.Errorf("expected 200, got %d", res.Code)
+	}
+}
+
+// TestRunMiddlewareMutatesRequest tests that the request seen by next is reported
+func TestRunMiddlewareMutatesRequest(t *testing.T) {
+	withUser := func(next ghast.Handler) ghast.Handler {
+		return ghast.HandlerFunc(func(w ghast.ResponseWriter, r *ghast.Request) {
+			r2 := *r
+			r2.Headers = map[string]string{"X-User": "alice"}
+			next.ServeHTTP(w, &r2)
+		})
+	}
+	req := NewRequest("GET", "/").Build()
+
+	res := RunMiddleware(withUser, req)
+
+	if !res.CalledNext {
+		t.Fatal("expected next to be called")
+	}
+	if res.Request == req || res.Request.GetHeader("X-User") != "alice" {
+		t.Errorf("expected mutated request to be reported, got %+v", res.Request)
+	}
+}
+
+// TestRunMiddlewareWithPanickingHandler tests running middleware around a custom next handler
+func TestRunMiddlewareWithPanickingHandler(t *testing.T) {
+	recovery := middleware.RecoveryMiddleware(middleware.Options{})
+	panicking := ghast.HandlerFunc(func(w ghast.ResponseWriter, r *ghast.Request) { panic("boom") })
+
+	res := RunMiddlewareWith(recovery, NewRequest("GET", "/").Build(), panicking)
+
+	if !res.CalledNext || res.Code != 500 {
+		t.Errorf("expected recovered 500, got called=%v code=%d", res.CalledNext, res.Code)
+	}
+}
@@ -0,0 +1,157 @@
+// Package ghasttest provides utilities for testing ghast handlers, middleware and applications.
+package ghasttest
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"strings"
+
+	"github.com/Leonard-Atorough/ghast"
+)
+
+// ResponseRecorder is a ghast.ResponseWriter that records the response for later inspection, so tests can
+// assert on the status, headers and body directly instead of searching raw wire output.
+//
+// Example:
+//
+//	rec := ghasttest.NewRecorder()
+//	router.ServeHTTP(rec, &ghast.Request{Method: "GET", Path: "/users/1"})
+//	if rec.Code != 200 {
+//	    t.Errorf("expected 200, got %d", rec.Code)
+//	}
+type ResponseRecorder struct {
+	Code      int               // Status code set by the handler; defaults to 200
+	HeaderMap map[string]string // Headers set by the handler
+	Body      *bytes.Buffer     // Bytes written by the handler
+	Flushed   bool              // Whether the handler called Flush
+	Renderer  ghast.Renderer    // Renderer used by Render; Render fails if it is nil
+
+	wroteHeader bool // Set once the body has been written, after which the status and headers are frozen
+}
+
+// NewRecorder returns an initialized ResponseRecorder.
+func NewRecorder() *ResponseRecorder {
+	return &ResponseRecorder{
+		Code:      200,
+		HeaderMap: make(map[string]string),
+		Body:      new(bytes.Buffer),
+	}
+}
+
+// Header returns the recorded headers map.
+func (rec *ResponseRecorder) Header() map[string]string {
+	return rec.HeaderMap
+}
+
+// Status records the status code unless the body has already been written, matching the server's writer.
+func (rec *ResponseRecorder) Status(statusCode int) ghast.ResponseWriter {
+	if !rec.wroteHeader {
+		rec.Code = statusCode
+	}
+	return rec
+}
+
+// SetHeader records a response header.
+func (rec *ResponseRecorder) SetHeader(key, value string) ghast.ResponseWriter {
+	if !rec.wroteHeader {
+		rec.HeaderMap[key] = value
+	}
+	return rec
+}
+
+// Send records data as part of the body.
+func (rec *ResponseRecorder) Send(data []byte) (int, error) {
+	rec.wroteHeader = true
+	return rec.Body.Write(data)
+}
+
+// SendString records a string as part of the body.
+func (rec *ResponseRecorder) SendString(s string) (int, error) {
+	return rec.Send([]byte(s))
+}
+
+// JSON records data marshaled as JSON with the application/json content type.
+func (rec *ResponseRecorder) JSON(statusCode int, data interface{}) error {
+	jsonData, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	rec.Status(statusCode).SetHeader("Content-Type", "application/json")
+	_, err = rec.Send(jsonData)
+	return err
+}
+
+// JSONPretty records data marshaled as indented JSON with the application/json content type.
+func (rec *ResponseRecorder) JSONPretty(statusCode int, data interface{}) error {
+	jsonData, err := json.MarshalIndent(data, "", "  ")
+	if err != nil {
+		return err
+	}
+	rec.Status(statusCode).SetHeader("Content-Type", "application/json")
+	_, err = rec.Send(jsonData)
+	return err
+}
+
+// HTML records an HTML body with the text/html content type.
+func (rec *ResponseRecorder) HTML(statusCode int, html string) error {
+	rec.Status(statusCode).SetHeader("Content-Type", "text/html")
+	_, err := rec.SendString(html)
+	return err
+}
+
+// Plain records a plain text body with the text/plain content type.
+func (rec *ResponseRecorder) Plain(statusCode int, text string) error {
+	rec.Status(statusCode).SetHeader("Content-Type", "text/plain")
+	_, err := rec.SendString(text)
+	return err
+}
+
+// Render renders the named view with the recorder's Renderer and records it with the text/html content type.
+func (rec *ResponseRecorder) Render(statusCode int, name string, data any) error {
+	if rec.Renderer == nil {
+		return errors.New("ghasttest: ResponseRecorder.Renderer is not set")
+	}
+	var buf bytes.Buffer
+	if err := rec.Renderer.Render(&buf, name, data); err != nil {
+		return err
+	}
+	rec.Status(statusCode).SetHeader("Content-Type", "text/html; charset=utf-8")
+	_, err := rec.Send(buf.Bytes())
+	return err
+}
+
+// Flush implements ghast.Flusher, recording that the handler flushed the response.
+func (rec *ResponseRecorder) Flush() error {
+	rec.wroteHeader = true
+	rec.Flushed = true
+	return nil
+}
+
+// HeaderValue returns a recorded header value (case-insensitive), or an empty string if it was not set.
+func (rec *ResponseRecorder) HeaderValue(key string) string {
+	if val, ok := rec.HeaderMap[key]; ok {
+		return val
+	}
+	for k, v := range rec.HeaderMap {
+		if strings.EqualFold(k, key) {
+			return v
+		}
+	}
+	return ""
+}
+
+// Result returns the recorded response as a ghast.Response, the same type returned by Ghast.Test.
+func (rec *ResponseRecorder) Result() *ghast.Response {
+	headers := make(map[string]string, len(rec.HeaderMap))
+	for k, v := range rec.HeaderMap {
+		headers[k] = v
+	}
+	return &ghast.Response{
+		StatusCode: rec.Code,
+		Status:     strconv.Itoa(rec.Code) + " " + ghast.StatusText(rec.Code),
+		Headers:    headers,
+		Body:       rec.Body.String(),
+	}
+}
@@ -0,0 +1,45 @@
+package ghasttest
+
+import (
+	"testing"
+
+	"github.com/Leonard-Atorough/ghast"
+)
+
+// TestRecorderCapturesResponse tests that the recorder captures status, headers and body
+func TestRecorderCapturesResponse(t *testing.T) {
+	rec := NewRecorder()
+	handler := ghast.HandlerFunc(func(w ghast.ResponseWriter, r *ghast.Request) {
+		w.SetHeader("X-Custom", "value")
+		w.JSON(201, map[string]string{"message": "created"})
+	})
+
+	handler.ServeHTTP(rec, &ghast.Request{Method: "POST", Path: "/"})
+
+	if rec.Code != 201 {
+		t.Errorf("expected status 201, got %d", rec.Code)
+	}
+	if rec.HeaderValue("content-type") != "application/json" {
+		t.Errorf("expected JSON content type, got %q", rec.HeaderMap["Content-Type"])
+	}
+	if rec.HeaderMap["X-Custom"] != "value" {
+		t.Errorf("expected X-Custom header, got %q", rec.HeaderMap["X-Custom"])
+	}
+	if rec.Body.String() != `{"message":"created"}` {
+		t.Errorf("unexpected body: %q", rec.Body.String())
+	}
+	if res := rec.Result(); res.Status != "201 Created" || res.Body != rec.Body.String() {
+		t.Errorf("unexpected result: %+v", res)
+	}
+}
+
+// TestRecorderFreezesStatusAfterWrite tests that the status can't change once the body is written
+func TestRecorderFreezesStatusAfterWrite(t *testing.T) {
+	rec := NewRecorder()
+	rec.SendString("partial")
+	rec.Status(500)
+
+	if rec.Code != 200 {
+		t.Errorf("expected status to stay 200 after write, got %d", rec.Code)
+	}
+}
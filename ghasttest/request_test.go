@@ -0,0 +1,42 @@
+package ghasttest
+
+import "testing"
+
+// TestNewRequestBuild tests that built requests are populated like parsed ones
+func TestNewRequestBuild(t *testing.T) {
+	req := NewRequest("POST", "/users?x=1").
+		Query("tag", "new").
+		JSON(map[string]string{"name": "alice"}).
+		Header("x-api-key", "secret").
+		Build()
+
+	if req.Method != "POST" || req.Path != "/users" || req.Version != "HTTP/1.1" {
+		t.Errorf("unexpected request line: %s %s %s", req.Method, req.Path, req.Version)
+	}
+	if req.Query("x") != "1" || req.Query("tag") != "new" {
+		t.Errorf("unexpected queries: %v", req.Queries)
+	}
+	if req.Headers["X-Api-Key"] != "secret" {
+		t.Errorf("expected canonical X-Api-Key header, got %v", req.Headers)
+	}
+	if req.ContentType() != "application/json" || req.Headers["Content-Length"] != "16" {
+		t.Errorf("unexpected body headers: %v", req.Headers)
+	}
+	var body map[string]string
+	if err := req.JSON(&body); err != nil || body["name"] != "alice" {
+		t.Errorf("unexpected body %q: %v", req.Body, err)
+	}
+	if req.Params == nil {
+		t.Error("expected params map to be initialized")
+	}
+}
+
+// TestNewRequestInvalidPanics tests that requests the server would reject can't be built
+func TestNewRequestInvalidPanics(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("expected Build to panic for an unknown method")
+		}
+	}()
+	NewRequest("BREW", "/coffee").Build()
+}
@@ -0,0 +1,51 @@
+package ghasttest
+
+import (
+	"maps"
+	"testing"
+
+	"github.com/Leonard-Atorough/ghast"
+)
+
+// AssertMatches checks that a request for method and path is routed to the route described by want, written
+// as "METHOD /path/template" (e.g. "GET /users/:id"). No handler is invoked.
+//
+// Example:
+//
+//	ghasttest.AssertMatches(t, router, "GET", "/users/42", "GET /users/:id")
+func AssertMatches(t testing.TB, router ghast.Router, method, path, want string) {
+	t.Helper()
+	info, _, ok := router.Lookup(method, path)
+	if !ok {
+		t.Errorf("%s %s: expected to match %q, but no route matched", method, path, want)
+		return
+	}
+	if got := info.Method + " " + info.Path; got != want {
+		t.Errorf("%s %s: expected to match %q, got %q", method, path, want, got)
+	}
+}
+
+// AssertNoMatch checks that no route handles a request for method and path.
+func AssertNoMatch(t testing.TB, router ghast.Router, method, path string) {
+	t.Helper()
+	if info, _, ok := router.Lookup(method, path); ok {
+		t.Errorf("%s %s: expected no match, got %q", method, path, info.Method+" "+info.Path)
+	}
+}
+
+// AssertParams checks the route parameters extracted from path when routing a request for method.
+//
+// Example:
+//
+//	ghasttest.AssertParams(t, router, "GET", "/users/42/posts/7", map[string]string{"userId": "42", "postId": "7"})
+func AssertParams(t testing.TB, router ghast.Router, method, path string, want map[string]string) {
+	t.Helper()
+	_, params, ok := router.Lookup(method, path)
+	if !ok {
+		t.Errorf("%s %s: expected params %v, but no route matched", method, path, want)
+		return
+	}
+	if !maps.Equal(params, want) {
+		t.Errorf("%s %s: expected params %v, got %v", method, path, want, params)
+	}
+}
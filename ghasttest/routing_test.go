@@ -0,0 +1,44 @@
+package ghasttest
+
+import (
+	"testing"
+
+	"github.com/Leonard-Atorough/ghast"
+)
+
+// TestRouteAssertions tests route assertions against a router without invoking handlers
+func TestRouteAssertions(t *testing.T) {
+	called := false
+	handler := ghast.HandlerFunc(func(w ghast.ResponseWriter, r *ghast.Request) { called = true })
+	router := ghast.NewRouter()
+	router.Get("/users/me", handler)
+	router.Get("/users/:id", handler)
+	router.Get("/users/:userId/posts/:postId", handler)
+
+	AssertMatches(t, router, "GET", "/users/42", "GET /users/:id")
+	AssertMatches(t, router, "GET", "/users/me", "GET /users/me")
+	AssertParams(t, router, "GET", "/users/me", map[string]string{})
+	AssertParams(t, router, "GET", "/users/42/posts/7", map[string]string{"userId": "42", "postId": "7"})
+	AssertNoMatch(t, router, "POST", "/users/42")
+	AssertNoMatch(t, router, "GET", "/posts")
+
+	if called {
+		t.Error("assertions should not invoke handlers")
+	}
+}
+
+// TestRouteAssertionsReportMismatches tests that failed route assertions are reported
+func TestRouteAssertionsReportMismatches(t *testing.T) {
+	router := ghast.NewRouter()
+	router.Get("/users/:id", ghast.HandlerFunc(func(w ghast.ResponseWriter, r *ghast.Request) {}))
+
+	tb := &recordingTB{TB: t}
+	AssertMatches(tb, router, "GET", "/users/42", "GET /users/:userId")
+	AssertMatches(tb, router, "GET", "/posts", "GET /posts")
+	AssertParams(tb, router, "GET", "/users/42", map[string]string{"id": "43"})
+	AssertNoMatch(tb, router, "GET", "/users/42")
+
+	if len(tb.failures) != 4 {
+		t.Errorf("expected 4 failures, got %d: %v", len(tb.failures), tb.failures)
+	}
+}
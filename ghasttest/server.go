@@ -0,0 +1,61 @@
+package ghasttest
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/Leonard-Atorough/ghast"
+)
+
+// serverShutdownTimeout bounds how long the cleanup registered by Start waits for the app to shut down.
+const serverShutdownTimeout = 5 * time.Second
+
+// Server is an application listening on a random loopback port, started with Start.
+type Server struct {
+	URL    string       // Base URL of the server, e.g. "http://127.0.0.1:54321"
+	Addr   string       // Listen address, e.g. "127.0.0.1:54321"
+	Client *http.Client // Client configured for the server, closed along with it
+
+	app *ghast.Ghast
+}
+
+// Start serves app over real TCP on a random loopback port for end-to-end tests. Start hooks run as with Listen,
+// and the app is shut down (running its shutdown hooks) when the test finishes; shutdown errors fail the test.
+//
+// Example:
+//
+//	srv := ghasttest.Start(t, app)
+//	res, err := srv.Client.Get(srv.URL + "/health")
+func Start(t testing.TB, app *ghast.Ghast) *Server {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("ghasttest: listen: %v", err)
+	}
+
+	srv := &Server{
+		URL:    "http://" + ln.Addr().String(),
+		Addr:   ln.Addr().String(),
+		Client: &http.Client{Timeout: 10 * time.Second, Transport: &http.Transport{}},
+		app:    app,
+	}
+
+	served := make(chan error, 1)
+	go func() { served <- app.Serve(ln) }()
+
+	t.Cleanup(func() {
+		srv.Client.CloseIdleConnections()
+		ctx, cancel := context.WithTimeout(context.Background(), serverShutdownTimeout)
+		defer cancel()
+		if err := app.Shutdown(ctx); err != nil {
+			t.Errorf("ghasttest: shutdown: %v", err)
+		}
+		if err := <-served; err != nil {
+			t.Errorf("ghasttest: serve: %v", err)
+		}
+	})
+	return srv
+}
@@ -0,0 +1,39 @@
+package ghasttest
+
+import (
+	"context"
+	"io"
+	"testing"
+
+	"github.com/Leonard-Atorough/ghast"
+)
+
+// TestStartServesOverTCP tests an end-to-end request and that cleanup shuts the app down
+func TestStartServesOverTCP(t *testing.T) {
+	app := ghast.New(ghast.WithHideBanner())
+	app.Get("/hello", ghast.HandlerFunc(func(w ghast.ResponseWriter, r *ghast.Request) {
+		w.Plain(200, "hello "+r.ClientIP)
+	}))
+	started, stopped := false, false
+	app.OnStart(func(ctx context.Context) error { started = true; return nil })
+	app.OnShutdown(func(ctx context.Context) error { stopped = true; return nil })
+
+	t.Run("request", func(t *testing.T) {
+		srv := Start(t, app)
+
+		res, err := srv.Client.Get(srv.URL + "/hello")
+		if err != nil {
+			t.Fatalf("request failed: %v", err)
+		}
+		defer res.Body.Close()
+		body, _ := io.ReadAll(res.Body)
+
+		if res.StatusCode != 200 || string(body) != "hello 127.0.0.1" {
+			t.Errorf("unexpected response: %d %q", res.StatusCode, body)
+		}
+	})
+
+	if !started || !stopped {
+		t.Errorf("expected start and shutdown hooks to run, got started=%v stopped=%v", started, stopped)
+	}
+}
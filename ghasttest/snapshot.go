@@ -0,0 +1,147 @@
+package ghasttest
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/Leonard-Atorough/ghast"
+)
+
+// update rewrites golden files with the current responses instead of comparing against them.
+var update = flag.Bool("ghasttest.update", false, "update ghasttest golden files")
+
+// snapshotTimeout bounds how long Snapshot waits for the application to respond.
+const snapshotTimeout = 5 * time.Second
+
+// volatileHeaders are replaced with a placeholder before comparison because they change on every request.
+var volatileHeaders = []string{"Date", "X-Request-ID", "Request-ID"}
+
+// Snapshot sends req to app over an in-memory connection and compares the response, exactly as written on the
+// wire, with the golden file at path. Volatile headers (Date, X-Request-ID, Request-ID) are normalized and
+// headers are sorted, so only meaningful changes show up. Run the tests with -ghasttest.update to create or
+// refresh golden files.
+//
+// Example:
+//
+//	func TestGetUser(t *testing.T) {
+//	    ghasttest.Snapshot(t, app, &ghast.Request{Method: "GET", Path: "/users/1"}, "testdata/users_get.golden")
+//	}
+//
+//	// go test ./... -args -ghasttest.update
+func Snapshot(t testing.TB, app *ghast.Ghast, req *ghast.Request, path string) {
+	t.Helper()
+
+	raw, err := roundTrip(app, req)
+	if err != nil {
+		t.Fatalf("ghasttest: %s %s: %v", req.Method, req.Path, err)
+	}
+	got := normalizeResponse(raw)
+
+	if *update {
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatalf("ghasttest: create golden directory: %v", err)
+		}
+		if err := os.WriteFile(path, got, 0o644); err != nil {
+			t.Fatalf("ghasttest: write golden file: %v", err)
+		}
+		return
+	}
+
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("ghasttest: read golden file (run with -ghasttest.update to create it): %v", err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("response for %s %s does not match %s\n--- got ---\n%s\n--- want ---\n%s",
+			req.Method, req.Path, path, got, want)
+	}
+}
+
+// roundTrip writes req to app over an in-memory connection and returns the raw response bytes.
+func roundTrip(app *ghast.Ghast, req *ghast.Request) ([]byte, error) {
+	conn := Dial(app)
+	defer conn.Close()
+
+	if _, err := conn.Write(serializeRequest(req)); err != nil {
+		return nil, err
+	}
+	conn.SetReadDeadline(time.Now().Add(snapshotTimeout))
+	return io.ReadAll(conn)
+}
+
+// serializeRequest writes req in HTTP/1.1 wire format, asking the server to close the connection afterwards
+// so the response ends at EOF.
+func serializeRequest(req *ghast.Request) []byte {
+	method := req.Method
+	if method == "" {
+		method = ghast.GET
+	}
+	target := req.Path
+	if len(req.Queries) > 0 && !strings.Contains(target, "?") {
+		values := url.Values{}
+		for k, v := range req.Queries {
+			values.Set(k, v)
+		}
+		target += "?" + values.Encode()
+	}
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "%s %s %s\r\n", method, target, ghast.HTTPVersion)
+	if req.Headers["Host"] == "" {
+		buf.WriteString("Host: localhost\r\n") // Required by HTTP/1.1
+	}
+	for key, value := range req.Headers {
+		if strings.EqualFold(key, "Connection") || strings.EqualFold(key, "Content-Length") {
+			continue
+		}
+		fmt.Fprintf(&buf, "%s: %s\r\n", key, value)
+	}
+	if len(req.Body) > 0 {
+		fmt.Fprintf(&buf, "Content-Length: %s\r\n", strconv.Itoa(len(req.Body)))
+	}
+	buf.WriteString("Connection: close\r\n\r\n")
+	buf.Write(req.Body)
+	return buf.Bytes()
+}
+
+// normalizeResponse sorts the response headers and replaces volatile header values with placeholders.
+func normalizeResponse(raw []byte) []byte {
+	head, body, found := bytes.Cut(raw, []byte("\r\n\r\n"))
+	if !found {
+		return raw
+	}
+
+	lines := strings.Split(string(head), "\r\n")
+	headers := lines[1:]
+	for i, line := range headers {
+		key, _, found := strings.Cut(line, ":")
+		if !found {
+			continue
+		}
+		for _, volatile := range volatileHeaders {
+			if strings.EqualFold(key, volatile) {
+				headers[i] = key + ": <" + strings.ToLower(volatile) + ">"
+			}
+		}
+	}
+	sort.Strings(headers)
+
+	var buf bytes.Buffer
+	buf.WriteString(lines[0] + "\r\n")
+	for _, line := range headers {
+		buf.WriteString(line + "\r\n")
+	}
+	buf.WriteString("\r\n")
+	buf.Write(body)
+	return buf.Bytes()
+}
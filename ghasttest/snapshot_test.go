@@ -0,0 +1,33 @@
+package ghasttest
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Leonard-Atorough/ghast"
+	"github.com/Leonard-Atorough/ghast/middleware"
+)
+
+// TestSnapshotUsersGet tests a full wire response against its golden file
+func TestSnapshotUsersGet(t *testing.T) {
+	app := ghast.New()
+	app.Use(middleware.RequestIDMiddleware(middleware.RequestIDOptions{}))
+	app.Get("/users/:id", ghast.HandlerFunc(func(w ghast.ResponseWriter, r *ghast.Request) {
+		w.SetHeader("Date", time.Now().UTC().Format(time.RFC1123))
+		w.JSON(200, map[string]string{"id": r.Param("id"), "name": "alice"})
+	}))
+
+	Snapshot(t, app, &ghast.Request{Method: "GET", Path: "/users/1"}, "testdata/users_get.golden")
+}
+
+// TestNormalizeResponse tests that headers are sorted and volatile values replaced
+func TestNormalizeResponse(t *testing.T) {
+	raw := "HTTP/1.1 200 OK\r\nX-Request-ID: 1234\r\nContent-Type: text/plain\r\ndate: Mon, 01 Jan 2026 00:00:00 GMT\r\n\r\nhello"
+
+	got := string(normalizeResponse([]byte(raw)))
+
+	want := "HTTP/1.1 200 OK\r\nContent-Type: text/plain\r\nX-Request-ID: <x-request-id>\r\ndate: <date>\r\n\r\nhello"
+	if got != want {
+		t.Errorf("unexpected normalized response:\n%q\nwant:\n%q", got, want)
+	}
+}
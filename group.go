@@ -1,7 +1,377 @@
 package ghast
 
+import (
+	"io"
+	"io/fs"
+	"net/http"
+	"slices"
+	"strings"
+)
+
 type routeGroup struct {
 	prefix      string
 	middlewares []Middleware
-	router      Router
+	handler     Handler // Mounted Router (via Route) or sub-application (via Mount)
+}
+
+// Group is a set of routes sharing a path prefix and middleware. Groups register their routes directly
+// on the application's root router, so they can be nested freely without creating and mounting routers.
+//
+// Example:
+//
+//	api := app.Group("/api", authMiddleware)
+//	v1 := api.Group("/v1")
+//	v1.Get("/users", listUsers) // GET /api/v1/users, wrapped by authMiddleware
+type Group struct {
+	prefix      string       // Full path prefix, including the prefixes of any parent groups.
+	middlewares []Middleware // Middleware applied to every route in the group, parent group middleware first.
+	router      Router       // Router the group's routes are registered on.
+}
+
+// Group creates a route group under the given path prefix. The middleware is applied to every route
+// registered through the group and any of its nested groups.
+func (g *Ghast) Group(prefix string, middlewares ...Middleware) *Group {
+	return &Group{
+		prefix:      joinPaths("", prefix),
+		middlewares: middlewares,
+		router:      g.rootRouter,
+	}
+}
+
+// Group creates a nested group whose prefix is appended to this group's prefix. The nested group
+// inherits this group's middleware, followed by the middleware given here.
+func (gr *Group) Group(prefix string, middlewares ...Middleware) *Group {
+	combined := make([]Middleware, 0, len(gr.middlewares)+len(middlewares))
+	combined = append(combined, gr.middlewares...)
+	combined = append(combined, middlewares...)
+	return &Group{
+		prefix:      joinPaths(gr.prefix, prefix),
+		middlewares: combined,
+		router:      gr.router,
+	}
+}
+
+// Prefix returns the full path prefix of the group.
+func (gr *Group) Prefix() string {
+	return gr.prefix
+}
+
+// Handle registers a handler for a specific HTTP method and path relative to the group prefix.
+func (gr *Group) Handle(method string, path string, handler Handler, middlewares ...Middleware) {
+	combined := make([]Middleware, 0, len(gr.middlewares)+len(middlewares))
+	combined = append(combined, gr.middlewares...)
+	combined = append(combined, middlewares...)
+	gr.router.Handle(method, joinPaths(gr.prefix, path), handler, combined...)
+}
+
+// Get registers a GET handler relative to the group prefix. Returns the group for chaining.
+func (gr *Group) Get(path string, handler Handler, middlewares ...Middleware) *Group {
+	gr.Handle(GET, path, handler, middlewares...)
+	return gr
+}
+
+// Post registers a POST handler relative to the group prefix. Returns the group for chaining.
+func (gr *Group) Post(path string, handler Handler, middlewares ...Middleware) *Group {
+	gr.Handle(POST, path, handler, middlewares...)
+	return gr
+}
+
+// Put registers a PUT handler relative to the group prefix. Returns the group for chaining.
+func (gr *Group) Put(path string, handler Handler, middlewares ...Middleware) *Group {
+	gr.Handle(PUT, path, handler, middlewares...)
+	return gr
+}
+
+// Delete registers a DELETE handler relative to the group prefix. Returns the group for chaining.
+func (gr *Group) Delete(path string, handler Handler, middlewares ...Middleware) *Group {
+	gr.Handle(DELETE, path, handler, middlewares...)
+	return gr
+}
+
+// Patch registers a PATCH handler relative to the group prefix. Returns the group for chaining.
+func (gr *Group) Patch(path string, handler Handler, middlewares ...Middleware) *Group {
+	gr.Handle(PATCH, path, handler, middlewares...)
+	return gr
+}
+
+// Head registers a HEAD handler relative to the group prefix. Returns the group for chaining.
+func (gr *Group) Head(path string, handler Handler, middlewares ...Middleware) *Group {
+	gr.Handle(HEAD, path, handler, middlewares...)
+	return gr
+}
+
+// Options registers an OPTIONS handler relative to the group prefix. Returns the group for chaining.
+func (gr *Group) Options(path string, handler Handler, middlewares ...Middleware) *Group {
+	gr.Handle(OPTIONS, path, handler, middlewares...)
+	return gr
+}
+
+// Any registers the handler for every method relative to the group prefix. Returns the group for chaining.
+func (gr *Group) Any(path string, handler Handler, middlewares ...Middleware) *Group {
+	return gr.Match(anyMethods, path, handler, middlewares...)
+}
+
+// Match registers the handler for each of the given methods relative to the group prefix. Returns the group for
+// chaining.
+func (gr *Group) Match(methods []string, path string, handler Handler, middlewares ...Middleware) *Group {
+	for _, method := range methods {
+		gr.Handle(method, path, handler, middlewares...)
+	}
+	return gr
+}
+
+// Resource registers the routes for the actions controller implements under path, relative to the group prefix
+// (see Router.Resource). Returns the group for chaining.
+func (gr *Group) Resource(path string, controller any, middlewares ...Middleware) *Group {
+	registerResource(gr.Handle, path, controller, middlewares)
+	return gr
+}
+
+// StaticFS registers routes serving the files in fsys under prefix, relative to the group prefix (see
+// Ghast.StaticFS). Returns the group for chaining.
+func (gr *Group) StaticFS(prefix string, fsys fs.FS, middlewares ...Middleware) *Group {
+	registerStatic(gr.Handle, prefix, fsys, middlewares)
+	return gr
+}
+
+// Redirect registers a route redirecting requests for from to to with the given status code (see
+// Router.Redirect). Paths starting with "/", for both, are relative to the group prefix. Returns the group for
+// chaining.
+func (gr *Group) Redirect(from, to string, code int) *Group {
+	if strings.HasPrefix(to, "/") {
+		to = joinPaths(gr.prefix, to)
+	}
+	return gr.Any(from, newRedirectHandler(to, code))
+}
+
+// joinPaths joins a group prefix and a route path into a single clean path.
+// Example: joinPaths("/api/", "users") returns "/api/users"; joinPaths("/api", "/") returns "/api".
+func joinPaths(prefix, path string) string {
+	prefix = strings.TrimRight(prefix, "/")
+	if prefix != "" && !strings.HasPrefix(prefix, "/") {
+		prefix = "/" + prefix
+	}
+	path = strings.TrimLeft(path, "/")
+	if path == "" {
+		if prefix == "" {
+			return "/"
+		}
+		return prefix
+	}
+	return prefix + "/" + path
+}
+
+// routerGroup is a child router returned by Router.Group. It has no routing table of its own: routes are
+// registered on the root router with the full prefix prepended, along with the chain of groups they came
+// through, so the root dispatches them like any other route and can rebuild them when group middleware is added.
+type routerGroup struct {
+	root        *router      // Router the group's routes are registered on
+	parent      *routerGroup // Enclosing group, nil for a group created directly on the root
+	prefix      string       // Full path prefix, including those of enclosing groups
+	middlewares []Middleware // Middleware applied to every route registered through the group
+}
+
+// Handle registers a handler on the root router for the path relative to the group prefix.
+func (rg *routerGroup) Handle(method string, path string, handler Handler, middlewares ...Middleware) {
+	rg.root.register([]string{method}, joinPaths(rg.prefix, path), handler, rg.chain(), middlewares)
+}
+
+// chain returns the groups from the outermost one down to rg.
+func (rg *routerGroup) chain() []*routerGroup {
+	var groups []*routerGroup
+	for group := rg; group != nil; group = group.parent {
+		groups = append(groups, group)
+	}
+	slices.Reverse(groups)
+	return groups
+}
+
+// Get registers a GET handler relative to the group prefix. Returns the group for chaining.
+func (rg *routerGroup) Get(path string, handler Handler, middlewares ...Middleware) Router {
+	rg.Handle(GET, path, handler, middlewares...)
+	return rg
+}
+
+// Post registers a POST handler relative to the group prefix. Returns the group for chaining.
+func (rg *routerGroup) Post(path string, handler Handler, middlewares ...Middleware) Router {
+	rg.Handle(POST, path, handler, middlewares...)
+	return rg
+}
+
+// Put registers a PUT handler relative to the group prefix. Returns the group for chaining.
+func (rg *routerGroup) Put(path string, handler Handler, middlewares ...Middleware) Router {
+	rg.Handle(PUT, path, handler, middlewares...)
+	return rg
+}
+
+// Delete registers a DELETE handler relative to the group prefix. Returns the group for chaining.
+func (rg *routerGroup) Delete(path string, handler Handler, middlewares ...Middleware) Router {
+	rg.Handle(DELETE, path, handler, middlewares...)
+	return rg
+}
+
+// Patch registers a PATCH handler relative to the group prefix. Returns the group for chaining.
+func (rg *routerGroup) Patch(path string, handler Handler, middlewares ...Middleware) Router {
+	rg.Handle(PATCH, path, handler, middlewares...)
+	return rg
+}
+
+// Head registers a HEAD handler relative to the group prefix. Returns the group for chaining.
+func (rg *routerGroup) Head(path string, handler Handler, middlewares ...Middleware) Router {
+	rg.Handle(HEAD, path, handler, middlewares...)
+	return rg
+}
+
+// Options registers an OPTIONS handler relative to the group prefix. Returns the group for chaining.
+func (rg *routerGroup) Options(path string, handler Handler, middlewares ...Middleware) Router {
+	rg.Handle(OPTIONS, path, handler, middlewares...)
+	return rg
+}
+
+// Any registers the handler for every method relative to the group prefix. Returns the group for chaining.
+func (rg *routerGroup) Any(path string, handler Handler, middlewares ...Middleware) Router {
+	return rg.Match(anyMethods, path, handler, middlewares...)
+}
+
+// Match registers the handler for each of the given methods relative to the group prefix. Returns the group for
+// chaining.
+func (rg *routerGroup) Match(methods []string, path string, handler Handler, middlewares ...Middleware) Router {
+	rg.root.register(methods, joinPaths(rg.prefix, path), handler, rg.chain(), middlewares)
+	return rg
+}
+
+// Meta attaches a metadata entry to the routes registered by the previous registration call. Returns the group
+// for chaining.
+func (rg *routerGroup) Meta(key, value string) Router {
+	rg.root.Meta(key, value)
+	return rg
+}
+
+// Tag adds tags to the routes registered by the previous registration call. Returns the group for chaining.
+func (rg *routerGroup) Tag(tags ...string) Router {
+	rg.root.Tag(tags...)
+	return rg
+}
+
+// Consumes restricts the routes registered by the previous registration call to requests with one of the given
+// content types. Returns the group for chaining.
+func (rg *routerGroup) Consumes(mediaTypes ...string) Router {
+	rg.root.Consumes(mediaTypes...)
+	return rg
+}
+
+// Produces restricts the routes registered by the previous registration call to requests accepting one of the
+// given media types. Returns the group for chaining.
+func (rg *routerGroup) Produces(mediaTypes ...string) Router {
+	rg.root.Produces(mediaTypes...)
+	return rg
+}
+
+// Resource registers the routes for the actions controller implements under path, relative to the group prefix.
+// Returns the group for chaining.
+func (rg *routerGroup) Resource(path string, controller any, middlewares ...Middleware) Router {
+	registerResource(rg.Handle, path, controller, middlewares)
+	return rg
+}
+
+// Redirect registers a route redirecting requests for from to to with the given status code. Paths starting
+// with "/", for both, are relative to the group prefix. Returns the group for chaining.
+func (rg *routerGroup) Redirect(from, to string, code int) Router {
+	if strings.HasPrefix(to, "/") {
+		to = joinPaths(rg.prefix, to)
+	}
+	rg.Any(from, newRedirectHandler(to, code))
+	return rg
+}
+
+// DumpRoutes writes the routes of the router the group registers on to w as JSON.
+func (rg *routerGroup) DumpRoutes(w io.Writer) error {
+	return rg.root.DumpRoutes(w)
+}
+
+// StaticFS registers routes serving the files in fsys under prefix, relative to the group prefix. Returns the
+// group for chaining.
+func (rg *routerGroup) StaticFS(prefix string, fsys fs.FS, middlewares ...Middleware) Router {
+	registerStatic(rg.Handle, prefix, fsys, middlewares)
+	return rg
+}
+
+// Mount routes requests for prefix, relative to the group prefix, and the paths below it to a net/http handler.
+// Returns the group for chaining.
+func (rg *routerGroup) Mount(prefix string, handler http.Handler, middlewares ...Middleware) Router {
+	mountHTTP(rg, prefix, handler, middlewares)
+	return rg
+}
+
+// Host registers a host router on the root router. Host routing happens before path matching, so it applies
+// to every path of the root, not only those under the group prefix. Returns the group for chaining.
+func (rg *routerGroup) Host(pattern string, router Router) Router {
+	rg.root.Host(pattern, router)
+	return rg
+}
+
+// Version serves router as an API version under the "/<version>" prefix, relative to the group prefix. Returns
+// the group for chaining.
+func (rg *routerGroup) Version(version string, router Router, opts ...VersionOptions) Router {
+	registerVersion(rg, rg.root, rg.prefix, version, router, opts)
+	return rg
+}
+
+// ServeHTTP dispatches the request through the root router, which holds the group's routes.
+func (rg *routerGroup) ServeHTTP(w ResponseWriter, req *Request) {
+	rg.root.ServeHTTP(w, req)
+}
+
+// Use adds middleware to the group. Like Router.Use, it also applies to the routes registered before the call.
+func (rg *routerGroup) Use(middleware Middleware) Router {
+	rg.root.mu.Lock()
+	defer rg.root.mu.Unlock()
+	rg.middlewares = append(rg.middlewares, middleware)
+	rg.root.recompose()
+	return rg
+}
+
+// Remove unregisters the route for method and path, relative to the group prefix, and reports whether there
+// was one.
+func (rg *routerGroup) Remove(method, path string) bool {
+	return rg.root.Remove(method, joinPaths(rg.prefix, path))
+}
+
+// Replace swaps the handler and route middleware of the route for method and path, relative to the group
+// prefix, and reports whether there was one. The group middleware still applies.
+func (rg *routerGroup) Replace(method, path string, handler Handler, middlewares ...Middleware) bool {
+	return rg.root.Replace(method, joinPaths(rg.prefix, path), handler, middlewares...)
+}
+
+// UsePath adds middleware to the root router for the routes matching pattern, relative to the group prefix.
+// Returns the group for chaining.
+func (rg *routerGroup) UsePath(pattern string, middleware Middleware) Router {
+	rg.root.UsePath(joinPaths(rg.prefix, pattern), middleware)
+	return rg
+}
+
+// Group returns a nested group whose prefix and middleware follow this group's.
+func (rg *routerGroup) Group(prefix string, middlewares ...Middleware) Router {
+	return &routerGroup{
+		root:        rg.root,
+		parent:      rg,
+		prefix:      joinPaths(rg.prefix, prefix),
+		middlewares: middlewares,
+	}
+}
+
+// Routes returns the routes of the root router under the group prefix, with their full paths.
+func (rg *routerGroup) Routes() []RouteInfo {
+	var routes []RouteInfo
+	for _, route := range rg.root.Routes() {
+		if rg.prefix == "/" || route.Path == rg.prefix || strings.HasPrefix(route.Path, rg.prefix+"/") {
+			routes = append(routes, route)
+		}
+	}
+	return routes
+}
+
+// Lookup reports which route the root router would use for the method and full path.
+func (rg *routerGroup) Lookup(method, path string) (RouteInfo, map[string]string, bool) {
+	return rg.root.Lookup(method, path)
 }
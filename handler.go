@@ -23,3 +23,51 @@ type HandlerFunc func(ResponseWriter, *Request)
 func (f HandlerFunc) ServeHTTP(rw ResponseWriter, req *Request) {
 	f(rw, req)
 }
+
+// HandlerE is an error-returning handler. Returning an error hands it to the error handler configured
+// with Ghast.SetErrorHandler (or DefaultErrorHandler), which removes the need to write an error response
+// in every handler. HandlerE implements Handler, so it is accepted by all route registration methods.
+//
+// Example:
+//
+//	app.Get("/users/:id", ghast.HandlerE(func(w ghast.ResponseWriter, r *ghast.Request) error {
+//	    user, err := store.Find(r.Param("id"))
+//	    if err != nil {
+//	        return ghast.NewHTTPError(404, "user not found")
+//	    }
+//	    return w.JSON(200, user)
+//	}))
+type HandlerE func(ResponseWriter, *Request) error
+
+// ServeHTTP implements the Handler interface for HandlerE. An error returned after the handler started its
+// response can't be turned into an error response, so it is logged instead.
+func (f HandlerE) ServeHTTP(rw ResponseWriter, req *Request) {
+	if err := f(rw, req); err != nil {
+		if responseStarted(rw) {
+			req.Logger().Error("handler failed after writing its response", "method", req.Method, "path", req.Path, "err", err)
+			return
+		}
+		handleError(err, rw, req)
+	}
+}
+
+// responseStarted reports whether the response on w has been started, after which its status and headers are
+// sent. Writers that don't track it, such as those of middleware outside the package, report false.
+func responseStarted(w ResponseWriter) bool {
+	s, ok := w.(interface{ responseStarted() bool })
+	return ok && s.responseStarted()
+}
+
+// HandlerFuncE is an alias of HandlerE, named to match HandlerFunc.
+type HandlerFuncE = HandlerE
+
+// E adapts an error-returning handler to a Handler, so it can be wrapped by existing Handler-based
+// middleware and passed anywhere a Handler is expected. Errors are routed to the application's error handler.
+//
+// Example:
+//
+//	router.Get("/users/:id", ghast.E(getUser), authMiddleware)
+//	wrapped := loggingMiddleware(ghast.E(getUser))
+func E(h HandlerFuncE) Handler {
+	return h
+}
@@ -0,0 +1,283 @@
+package ghast
+
+import (
+	"bufio"
+	"context"
+	"encoding/base64"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// http2Server serves the connections that negotiate HTTP/2 through ALPN, or open with the HTTP/2 preface or
+// upgrade to HTTP/2 when h2c is enabled, by handing them to the HTTP/2 implementation of net/http. Each stream
+// is translated into a ghast Request (see ToHTTP), so the same routers and middleware serve HTTP/1.1 and HTTP/2
+// clients.
+type http2Server struct {
+	srv *http.Server
+	ln  *connListener // Listener handing connections to srv
+
+	mu   sync.Mutex
+	done map[net.Conn]chan struct{} // Closed once srv is finished with each connection
+}
+
+// newHTTP2Server starts serving HTTP/2 connections with handler, refusing request bodies larger than the
+// configured MaxRequestBodySize with 413 and applying the configured read and idle timeouts.
+func newHTTP2Server(handler Handler, config *serverConfig) *http2Server {
+	h := &http2Server{
+		ln:   &connListener{conns: make(chan net.Conn), closed: make(chan struct{})},
+		done: make(map[net.Conn]chan struct{}),
+	}
+	httpHandler := ToHTTP(handler)
+	if config.MaxRequestBodySize > 0 {
+		httpHandler = http.MaxBytesHandler(httpHandler, int64(config.MaxRequestBodySize))
+	}
+	h.srv = &http.Server{
+		Handler:           httpHandler,
+		ConnState:         h.connState,
+		ReadHeaderTimeout: config.ReadHeaderTimeout,
+		ReadTimeout:       config.ReadTimeout,
+		IdleTimeout:       config.IdleTimeout,
+	}
+	h.srv.Protocols = new(http.Protocols)
+	h.srv.Protocols.SetHTTP1(true)
+	h.srv.Protocols.SetHTTP2(true)
+	h.srv.Protocols.SetUnencryptedHTTP2(true)
+	go h.srv.Serve(h.ln)
+	return h
+}
+
+// serve serves an HTTP/2 connection, whose TLS handshake is complete if it has one, until it is closed.
+func (h *http2Server) serve(conn net.Conn) {
+	done := make(chan struct{})
+	h.mu.Lock()
+	h.done[conn] = done
+	h.mu.Unlock()
+
+	select {
+	case h.ln.conns <- conn:
+		<-done
+	case <-h.ln.closed: // Shut down
+		h.connState(conn, http.StateClosed)
+	}
+}
+
+// connState notices when net/http is finished with a connection.
+func (h *http2Server) connState(conn net.Conn, state http.ConnState) {
+	if state != http.StateClosed && state != http.StateHijacked {
+		return
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if done, ok := h.done[conn]; ok {
+		close(done)
+		delete(h.done, conn)
+	}
+}
+
+// shutdown asks the clients of open HTTP/2 connections to stop sending requests, and closes each connection
+// once its streams are finished.
+func (h *http2Server) shutdown(ctx context.Context) {
+	h.srv.Shutdown(ctx)
+}
+
+// connListener is a net.Listener returning the connections sent on a channel.
+type connListener struct {
+	conns     chan net.Conn
+	closed    chan struct{}
+	closeOnce sync.Once
+}
+
+func (l *connListener) Accept() (net.Conn, error) {
+	select {
+	case conn := <-l.conns:
+		return conn, nil
+	case <-l.closed:
+		return nil, net.ErrClosed
+	}
+}
+
+func (l *connListener) Close() error {
+	l.closeOnce.Do(func() { close(l.closed) })
+	return nil
+}
+
+func (l *connListener) Addr() net.Addr {
+	return &net.TCPAddr{}
+}
+
+// h2cSwitchingProtocols accepts an HTTP/1.1 request's upgrade to cleartext HTTP/2.
+const h2cSwitchingProtocols = "HTTP/1.1 101 Switching Protocols\r\nConnection: Upgrade\r\nUpgrade: h2c\r\n\r\n"
+
+// HTTP/2 frame types and flags used to hand an upgraded request over (RFC 9113 section 6).
+const (
+	http2FrameHeaders      = 0x1
+	http2FrameSettings     = 0x4
+	http2FrameContinuation = 0x9
+	http2FlagEndStream     = 0x1
+	http2FlagEndHeaders    = 0x4
+	http2MaxFrameSize      = 16384 // The initial SETTINGS_MAX_FRAME_SIZE
+)
+
+// http2ConnectionHeaders are the HTTP/1.1 headers that are specific to a connection and not allowed in HTTP/2
+// (RFC 9113 section 8.2.2), or are carried by a pseudo-header instead.
+var http2ConnectionHeaders = []string{
+	"Connection", "Upgrade", "Http2-Settings", "Keep-Alive", "Proxy-Connection", "Transfer-Encoding", "Host", "Te",
+}
+
+// isH2CUpgrade reports whether req asks to upgrade its connection to cleartext HTTP/2 (RFC 7540 section 3.2)
+// in a way the server can honor: an origin-form HTTP/1.1 request without a body, with a valid HTTP2-Settings
+// header and both Upgrade and HTTP2-Settings as connection options. Other upgrade requests are answered over
+// HTTP/1.1, as servers are free to ignore Upgrade.
+func isH2CUpgrade(req *Request, requestLine string) bool {
+	if req.Version != "HTTP/1.1" || !hasToken(req.Headers["Upgrade"], "h2c") ||
+		!hasToken(req.Headers["Connection"], "Upgrade") || !hasToken(req.Headers["Connection"], "HTTP2-Settings") {
+		return false
+	}
+	if _, ok := h2cSettings(req); !ok {
+		return false
+	}
+	if length, found := req.Headers["Content-Length"]; found && length != "0" {
+		return false
+	}
+	_, expect := req.Headers["Expect"]
+	return !expect && strings.HasPrefix(requestTarget(requestLine), "/")
+}
+
+// h2cSettings returns the SETTINGS frame payload carried by an upgrade request's HTTP2-Settings header, and
+// whether the header holds a valid one.
+func h2cSettings(req *Request) ([]byte, bool) {
+	settings, found := req.Headers["Http2-Settings"]
+	if !found {
+		return nil, false
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(settings, "="))
+	if err != nil || len(payload)%6 != 0 || len(payload) > http2MaxFrameSize {
+		return nil, false
+	}
+	return payload, true
+}
+
+// requestTarget returns the request target of an HTTP/1.1 request line, as sent by the client.
+func requestTarget(requestLine string) string {
+	_, target, _ := strings.Cut(requestLine, " ")
+	target, _, _ = strings.Cut(target, " ")
+	return target
+}
+
+// h2cUpgradeConn is a connection upgraded from HTTP/1.1 to HTTP/2. The HTTP/2 server reads it as though the
+// client had opened it with the preface and sent the upgraded request as stream 1: the client's preface and
+// first SETTINGS frame, which it sends after the 101 response, are read first and followed by the request's
+// HEADERS frames. The settings of the request's HTTP2-Settings header are the client's initial settings (RFC
+// 7540 section 3.2.1), so they are put at the front of that first SETTINGS frame, where the client's own
+// settings still override them and the single acknowledgement the client expects is all the server sends.
+type h2cUpgradeConn struct {
+	net.Conn
+	reader   *bufio.Reader
+	settings []byte // Payload of the request's HTTP2-Settings header
+	request  []byte // HEADERS and CONTINUATION frames of the upgraded request
+
+	once sync.Once
+	head []byte // Client preface, SETTINGS frame and request frames not yet read
+	err  error  // Error reading the client preface, returned by every Read
+}
+
+func (c *h2cUpgradeConn) Read(p []byte) (int, error) {
+	c.once.Do(c.readPreface)
+	if c.err != nil {
+		return 0, c.err
+	}
+	if len(c.head) > 0 {
+		n := copy(p, c.head)
+		c.head = c.head[n:]
+		return n, nil
+	}
+	return c.reader.Read(p)
+}
+
+// readPreface reads the client preface and the SETTINGS frame that must follow it, and queues them, with the
+// upgrade's settings added to the frame, ahead of the upgraded request.
+func (c *h2cUpgradeConn) readPreface() {
+	head := make([]byte, len(h2cPreface)+9)
+	if _, err := io.ReadFull(c.reader, head); err != nil {
+		c.err = err
+		return
+	}
+	length := int(head[len(h2cPreface)])<<16 | int(head[len(h2cPreface)+1])<<8 | int(head[len(h2cPreface)+2])
+	if string(head[:len(h2cPreface)]) != h2cPreface || head[len(h2cPreface)+3] != http2FrameSettings ||
+		length+len(c.settings) > http2MaxFrameSize {
+		c.err = errors.New("ghast: invalid HTTP/2 preface after h2c upgrade")
+		return
+	}
+	settings := make([]byte, length)
+	if _, err := io.ReadFull(c.reader, settings); err != nil {
+		c.err = err
+		return
+	}
+	merged := length + len(c.settings)
+	head[len(h2cPreface)], head[len(h2cPreface)+1], head[len(h2cPreface)+2] = byte(merged>>16), byte(merged>>8), byte(merged)
+	c.head = append(append(append(head, c.settings...), settings...), c.request...)
+}
+
+// h2cUpgradeRequest encodes a body-less HTTP/1.1 request as the HEADERS frame, and CONTINUATION frames if the
+// headers don't fit in one, that open stream 1 and end it.
+func h2cUpgradeRequest(req *Request, requestLine string) []byte {
+	block := appendHPACKField(nil, ":method", req.Method)
+	block = appendHPACKField(block, ":scheme", "http")
+	block = appendHPACKField(block, ":authority", req.Headers["Host"])
+	block = appendHPACKField(block, ":path", requestTarget(requestLine))
+
+	names := make([]string, 0, len(req.Headers))
+	for name := range req.Headers {
+		if !slices.Contains(http2ConnectionHeaders, name) && !hasToken(req.Headers["Connection"], name) {
+			names = append(names, name)
+		}
+	}
+	slices.Sort(names)
+	for _, name := range names {
+		block = appendHPACKField(block, strings.ToLower(name), req.Headers[name])
+	}
+
+	var frames []byte
+	frameType, flags := byte(http2FrameHeaders), byte(http2FlagEndStream)
+	for {
+		chunk := block[:min(len(block), http2MaxFrameSize)]
+		block = block[len(chunk):]
+		if len(block) == 0 {
+			flags |= http2FlagEndHeaders
+		}
+		frames = append(frames, byte(len(chunk)>>16), byte(len(chunk)>>8), byte(len(chunk)), frameType, flags, 0, 0, 0, 1)
+		frames = append(frames, chunk...)
+		if len(block) == 0 {
+			return frames
+		}
+		frameType, flags = http2FrameContinuation, 0
+	}
+}
+
+// appendHPACKField appends a header field to an HPACK block as a literal without indexing, with a literal name
+// and no Huffman coding (RFC 7541 section 6.2.2), which needs no state shared with the decoder.
+func appendHPACKField(block []byte, name, value string) []byte {
+	block = append(block, 0)
+	block = appendHPACKString(block, name)
+	return appendHPACKString(block, value)
+}
+
+// appendHPACKString appends a string literal: its length as an integer with a 7-bit prefix, then its bytes.
+func appendHPACKString(block []byte, s string) []byte {
+	n := len(s)
+	if n < 127 {
+		block = append(block, byte(n))
+	} else {
+		block = append(block, 127)
+		for n -= 127; n >= 128; n >>= 7 {
+			block = append(block, byte(n%128+128))
+		}
+		block = append(block, byte(n))
+	}
+	return append(block, s...)
+}
@@ -4,6 +4,11 @@ import (
 	"fmt"
 )
 
+// StatusText returns the standard HTTP status text for a status code, such as "Not Found" for 404.
+func StatusText(statusCode int) string {
+	return httpStatusText(statusCode)
+}
+
 // httpStatusText returns the standard HTTP status text for a given status code.
 func httpStatusText(statusCode int) string {
 	switch statusCode {
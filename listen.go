@@ -0,0 +1,113 @@
+package ghast
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"net"
+	"os"
+	"strings"
+	"syscall"
+)
+
+// unixPrefix marks listen addresses naming a Unix domain socket, as in "unix:/var/run/ghast.sock".
+const unixPrefix = "unix:"
+
+// listen opens a listener on addr with the configured ListenConfig: a Unix domain socket for addresses starting
+// with "unix:", a TCP address otherwise. A listener inherited from the process that started this one through
+// Restart is used instead when it listens on addr. The TCP socket options of the configuration are applied, and
+// with ProxyProtocol set, connections are read past their PROXY protocol header.
+func (s *server) listen(addr string) (net.Listener, error) {
+	ln, err := inheritedListener(addr)
+	if err != nil {
+		return nil, err
+	}
+	if ln == nil {
+		lc := s.config.ListenConfig
+		if path, ok := strings.CutPrefix(addr, unixPrefix); ok {
+			ln, err = listenUnix(lc, path, s.config.UnixSocketMode)
+		} else {
+			if s.config.ReusePort {
+				lc.Control = withReusePort(lc.Control)
+			}
+			ln, err = lc.Listen(context.Background(), "tcp", addr)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	s.mu.Lock()
+	s.socket = ln
+	s.mu.Unlock()
+	if _, ok := ln.(*net.TCPListener); ok && s.config.DisableNoDelay {
+		ln = &noDelayListener{Listener: ln}
+	}
+	if s.config.ProxyProtocol {
+		ln = &proxyListener{ln}
+	}
+	return ln, nil
+}
+
+// withReusePort returns a socket control function setting SO_REUSEPORT after running control, if any.
+func withReusePort(control func(network, address string, c syscall.RawConn) error) func(string, string, syscall.RawConn) error {
+	return func(network, address string, c syscall.RawConn) error {
+		if control != nil {
+			if err := control(network, address, c); err != nil {
+				return err
+			}
+		}
+		return setReusePort(c)
+	}
+}
+
+// noDelayListener is a TCP listener turning off TCP_NODELAY, which Go enables by default, on the connections it
+// accepts, so small writes are coalesced by Nagle's algorithm.
+type noDelayListener struct {
+	net.Listener
+}
+
+func (l *noDelayListener) Accept() (net.Conn, error) {
+	conn, err := l.Listener.Accept()
+	if tcpConn, ok := conn.(*net.TCPConn); ok {
+		tcpConn.SetNoDelay(false)
+	}
+	return conn, err
+}
+
+// listenUnix listens on the Unix domain socket at path, replacing a stale socket file left behind by a process
+// that didn't shut down cleanly, and sets the socket file's permissions to mode if it is non-zero. The socket
+// file is removed when the listener is closed.
+func listenUnix(lc net.ListenConfig, path string, mode fs.FileMode) (net.Listener, error) {
+	if err := removeStaleSocket(path); err != nil {
+		return nil, err
+	}
+	ln, err := lc.Listen(context.Background(), "unix", path)
+	if err != nil {
+		return nil, err
+	}
+	if mode != 0 {
+		if err := os.Chmod(path, mode); err != nil {
+			ln.Close()
+			return nil, err
+		}
+	}
+	return ln, nil
+}
+
+// removeStaleSocket removes the socket file at path if no server is accepting connections on it. Files that
+// aren't sockets are left alone, so listening fails rather than deleting them.
+func removeStaleSocket(path string) error {
+	info, err := os.Lstat(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil || info.Mode().Type() != fs.ModeSocket {
+		return err
+	}
+	if conn, err := net.Dial("unix", path); err == nil {
+		conn.Close()
+		return nil // In use: let listening report it
+	}
+	return os.Remove(path)
+}
@@ -0,0 +1,149 @@
+package ghast
+
+import (
+	"context"
+	"io"
+	"io/fs"
+	"net"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// startUnixTestApp starts app on a Unix domain socket at path and waits until it is listening.
+func startUnixTestApp(t *testing.T, app *Ghast, path string) {
+	t.Helper()
+	listening := make(chan struct{})
+	app.server.onListen = func(net.Addr) { close(listening) }
+	go app.Listen("unix:" + path)
+
+	select {
+	case <-listening:
+	case <-time.After(2 * time.Second):
+		t.Fatal("server did not start listening")
+	}
+}
+
+// TestListenUnix tests serving on a Unix domain socket with the configured permissions, removed on shutdown
+func TestListenUnix(t *testing.T) {
+	dir, err := os.MkdirTemp("", "ghast")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "app.sock")
+
+	app := New(WithUnixSocketMode(0o600))
+	app.Get("/", HandlerFunc(func(w ResponseWriter, r *Request) { w.Plain(200, "over unix") }))
+	startUnixTestApp(t, app, path)
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatalf("expected the socket file to exist: %v", err)
+	}
+	if info.Mode().Type() != fs.ModeSocket || info.Mode().Perm() != 0o600 {
+		t.Errorf("expected a socket with mode 0600, got %v", info.Mode())
+	}
+
+	conn, err := net.Dial("unix", path)
+	if err != nil {
+		t.Fatalf("dial failed: %v", err)
+	}
+	conn.Write([]byte("GET / HTTP/1.1\r\nHost: localhost\r\nConnection: close\r\n\r\n"))
+	conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	response, _ := io.ReadAll(conn)
+	conn.Close()
+	if !strings.HasPrefix(string(response), "HTTP/1.1 200") || !strings.HasSuffix(string(response), "over unix") {
+		t.Errorf("expected the request to be served, got %q", response)
+	}
+
+	if err := app.Shutdown(context.Background()); err != nil {
+		t.Fatalf("shutdown failed: %v", err)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("expected the socket file to be removed on shutdown, got %v", err)
+	}
+}
+
+// TestListenUnixStaleSocket tests that a socket file left behind by a previous process is replaced, while
+// other files are not
+func TestListenUnixStaleSocket(t *testing.T) {
+	dir, err := os.MkdirTemp("", "ghast")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "app.sock")
+
+	stale, err := net.ListenUnix("unix", &net.UnixAddr{Name: path, Net: "unix"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	stale.SetUnlinkOnClose(false)
+	stale.Close()
+
+	app := New()
+	startUnixTestApp(t, app, path)
+	app.Shutdown(context.Background())
+
+	regular := filepath.Join(dir, "regular")
+	os.WriteFile(regular, []byte("keep"), 0o644)
+	if err := New().Listen("unix:" + regular); err == nil {
+		t.Error("expected listening over a regular file to fail")
+	}
+	if data, _ := os.ReadFile(regular); string(data) != "keep" {
+		t.Error("expected the regular file to be left alone")
+	}
+}
+
+// TestListenConfig tests that the listen configuration's Control hook runs for the listening socket
+func TestListenConfig(t *testing.T) {
+	controlled := make(chan string, 1)
+	app := New(WithListenConfig(net.ListenConfig{
+		Control: func(network, address string, c syscall.RawConn) error {
+			controlled <- network
+			return nil
+		},
+	}))
+	startTestApp(t, app)
+	defer app.Shutdown(context.Background())
+
+	select {
+	case network := <-controlled:
+		if network != "tcp4" {
+			t.Errorf("expected the control hook to run for a tcp4 socket, got %q", network)
+		}
+	default:
+		t.Error("expected the control hook to run")
+	}
+}
+
+// TestReusePort tests that two servers with SO_REUSEPORT can listen on the same address
+func TestReusePort(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("SO_REUSEPORT is not supported on Windows")
+	}
+	first := New(WithReusePort())
+	first.Get("/", HandlerFunc(func(w ResponseWriter, r *Request) { w.Plain(200, "ok") }))
+	addr := startTestApp(t, first)
+	defer first.Shutdown(context.Background())
+
+	second := New(WithReusePort())
+	listening := make(chan struct{})
+	second.server.onListen = func(net.Addr) { close(listening) }
+	failed := make(chan error, 1)
+	go func() { failed <- second.Listen(addr) }()
+	defer second.Shutdown(context.Background())
+
+	select {
+	case <-listening:
+	case err := <-failed:
+		t.Fatalf("expected the second server to listen on %s too, got %v", addr, err)
+	case <-time.After(2 * time.Second):
+		t.Fatal("second server did not start listening")
+	}
+}
@@ -0,0 +1,43 @@
+package ghast
+
+import "log/slog"
+
+// SetLogger sets the logger used for the framework's own messages: the startup report, accept errors, the
+// development mode request log and the defaults of the bundled middleware. Handlers can log through it with
+// Request.Logger. Mounted sub-applications without their own logger use their parent's, and applications
+// without one use slog.Default. Returns the app for chaining.
+//
+// Example:
+//
+//	app.SetLogger(slog.New(slog.NewJSONHandler(os.Stderr, nil)))
+func (g *Ghast) SetLogger(logger *slog.Logger) *Ghast {
+	g.config.Logger = logger
+	return g
+}
+
+// logger returns the logger configured on the application or its parents, or slog.Default.
+func (g *Ghast) logger() *slog.Logger {
+	for app := g; app != nil; app = app.parent {
+		if app.config.Logger != nil {
+			return app.config.Logger
+		}
+	}
+	return slog.Default()
+}
+
+// Logger returns the logger of the application handling the request, as set with SetLogger, or slog.Default
+// for requests not being handled by an application.
+func (r *Request) Logger() *slog.Logger {
+	if r.app == nil {
+		return slog.Default()
+	}
+	return r.app.logger()
+}
+
+// logger returns the logger configured for the server, or slog.Default.
+func (c *serverConfig) logger() *slog.Logger {
+	if c.Logger != nil {
+		return c.Logger
+	}
+	return slog.Default()
+}
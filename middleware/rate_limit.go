@@ -1,51 +1,66 @@
 package middleware
 
 import (
+	"context"
 	"time"
 
 	"github.com/Leonard-Atorough/ghast"
 )
 
+const defaultRateLimitMaxClients = 10000
+const rateLimitWindow = time.Minute
+
 type RateLimitOptions struct {
 	RequestsPerMinute int
+	MaxClients        int            // Optional: Maximum number of client IPs tracked at once by the in-memory store; the oldest are evicted beyond it (default: 10000)
+	Store             RateLimitStore // Optional: Where request counts are kept, e.g. a Redis store shared by every instance (default: in memory)
 }
 
-type rateLimitEntry struct {
-	Count     int
-	Timestamp int64
+// RateLimitStore counts requests per key in fixed windows. Implementations backed by a shared database let
+// several instances of an application enforce a single limit.
+type RateLimitStore interface {
+	// Increment adds one to the count for key and returns the new count. The count starts at zero and resets
+	// window after the first request in the window.
+	Increment(ctx context.Context, key string, window time.Duration) (int, error)
 }
 
-var rateLimitCollection = make(map[string]rateLimitEntry) // Map of client IP to slice of request timestamps
-
 // RateLimitMiddleware returns a middleware function that implements simple per-IP rate limiting.
-// When a new IP is receive, we create a new entry with a timestamp and a coutn. If we receive another request from the same ip, we check if the request timestamp - duration is less than 1 minute. If it is, we check if this plus the count is greater than rpm. If it is, we return a 429 Too Many Requests. if it is not we increment the count. If the request timestamp - duration is greater than 1 minute, we reset the count and timestamp for that IP.
+// Each client IP gets a one-minute window that starts with its first request. Requests beyond RequestsPerMinute within the window get a 429 Too Many Requests; once the window expires, the client's count starts over.
+// By default clients are tracked in a sharded in-memory store bounded by MaxClients, so a flood of distinct IPs can't grow memory without limit.
+// If the store fails, the request is allowed and the error is logged, so an outage of a shared store doesn't take the application down with it.
 func RateLimitMiddleware(options RateLimitOptions) ghast.Middleware {
+	store := options.Store
+	if store == nil {
+		maxClients := defaultRateLimitMaxClients
+		if options.MaxClients > 0 {
+			maxClients = options.MaxClients
+		}
+		store = &memoryRateLimitStore{counts: newBoundedStore[int](maxClients, rateLimitWindow)}
+	}
+
 	return func(next ghast.Handler) ghast.Handler {
 		return ghast.HandlerFunc(func(rw ghast.ResponseWriter, r *ghast.Request) {
-			clientIP := r.ClientIP
-			entry, exists := rateLimitCollection[clientIP]
-			currentTime := time.Now().Unix()
-
-			if exists {
-				if currentTime-entry.Timestamp < 60 {
-					if entry.Count >= options.RequestsPerMinute {
-						rw.Status(429)
-						rw.Send([]byte("Too Many Requests"))
-						return
-					}
-					entry.Count++
-				} else {
-					entry.Count = 1
-					entry.Timestamp = currentTime
-				}
-				rateLimitCollection[clientIP] = entry
-			} else {
-				rateLimitCollection[clientIP] = rateLimitEntry{
-					Count:     1,
-					Timestamp: currentTime,
-				}
+			count, err := store.Increment(r.Context(), r.ClientIP, rateLimitWindow)
+			if err != nil {
+				r.Logger().Error("rate limit store failed", "err", err)
+			} else if count > options.RequestsPerMinute {
+				rw.Status(429)
+				rw.Send([]byte("Too Many Requests"))
+				return
 			}
 			next.ServeHTTP(rw, r)
 		})
 	}
 }
+
+// memoryRateLimitStore is the default RateLimitStore, counting requests in a boundedStore whose TTL is the
+// window.
+type memoryRateLimitStore struct {
+	counts *boundedStore[int]
+}
+
+func (s *memoryRateLimitStore) Increment(ctx context.Context, key string, window time.Duration) (int, error) {
+	return s.counts.update(key, time.Now(), func(count int, found bool) int {
+		return count + 1
+	}), nil
+}
@@ -9,7 +9,7 @@ import (
 // RecoveryMiddleware is a middleware that recovers from panics in handlers and returns a 500 error.
 type Options struct {
 	Log    bool        // Whether to log the panic error (default: true)
-	Logger *log.Logger // Optional custom logger (default: standard logger)
+	Logger *log.Logger // Optional custom logger (default: the application's logger, see ghast.Ghast.SetLogger)
 }
 
 // RecoveryMiddleware creates a RecoveryMiddleware with the given options.
@@ -22,7 +22,7 @@ func RecoveryMiddleware(opts Options) ghast.Middleware {
 						if opts.Logger != nil {
 							opts.Logger.Printf("Panic recovered: %v", err)
 						} else {
-							log.Printf("Panic recovered: %v", err)
+							r.Logger().Error("Panic recovered", "err", err)
 						}
 					}
 					w.JSON(500, map[string]string{"error": "Internal Server Error"})
@@ -0,0 +1,204 @@
+package middleware
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/Leonard-Atorough/ghast"
+)
+
+const defaultSessionCookieName = "ghast_session"
+const defaultSessionTTL = 24 * time.Hour
+const defaultSessionMaxSessions = 10000
+
+type SessionOptions struct {
+	Store       SessionStore  // Optional: Where session data is kept, e.g. a Redis store shared by every instance (default: in memory)
+	CookieName  string        // Optional: The name of the cookie holding the session ID (default: "ghast_session")
+	TTL         time.Duration // Optional: How long a session lives after it was last saved (default: 24 hours)
+	Secure      bool          // Optional: Only send the session cookie over HTTPS
+	MaxSessions int           // Optional: Maximum number of sessions kept by the in-memory store; the oldest are evicted beyond it (default: 10000)
+}
+
+// SessionStore keeps session data by session ID. Implementations backed by a shared database let every
+// instance of an application see the same sessions.
+type SessionStore interface {
+	// Get returns the value stored under key and whether it exists.
+	Get(ctx context.Context, key string) ([]byte, bool, error)
+
+	// Set stores value under key, expiring it after ttl.
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration) error
+
+	// Delete removes key. Deleting a missing key is not an error.
+	Delete(ctx context.Context, key string) error
+}
+
+// Session is the data of one client's session, loaded by SessionMiddleware. Changes are kept only once Save is
+// called.
+type Session struct {
+	id      string // Empty until the session is first saved
+	values  map[string]string
+	store   SessionStore
+	options SessionOptions
+	w       ghast.ResponseWriter
+}
+
+type sessionKey struct{}
+
+// SessionMiddleware returns a middleware function that loads the client's session, identified by a random ID
+// in a cookie, from the store, for handlers to read and change through GetSession.
+// Session IDs the store doesn't know are ignored, so a client can't choose its own ID; saving such a session gives it a new one.
+// If the store fails, the request continues with an empty session and the error is logged, like RateLimitMiddleware.
+func SessionMiddleware(options SessionOptions) ghast.Middleware {
+	if options.CookieName == "" {
+		options.CookieName = defaultSessionCookieName
+	}
+	if options.TTL <= 0 {
+		options.TTL = defaultSessionTTL
+	}
+	store := options.Store
+	if store == nil {
+		maxSessions := defaultSessionMaxSessions
+		if options.MaxSessions > 0 {
+			maxSessions = options.MaxSessions
+		}
+		store = &memorySessionStore{sessions: newBoundedStore[[]byte](maxSessions, options.TTL)}
+	}
+
+	return func(next ghast.Handler) ghast.Handler {
+		return ghast.HandlerFunc(func(w ghast.ResponseWriter, r *ghast.Request) {
+			session := &Session{values: map[string]string{}, store: store, options: options, w: w}
+			if id := sessionCookie(r, options.CookieName); id != "" {
+				data, found, err := store.Get(r.Context(), id)
+				if err != nil {
+					r.Logger().Error("session store failed", "err", err)
+				} else if found {
+					if err := json.Unmarshal(data, &session.values); err != nil {
+						r.Logger().Error("invalid session data", "err", err)
+					} else {
+						session.id = id
+					}
+				}
+			}
+			next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), sessionKey{}, session)))
+		})
+	}
+}
+
+// GetSession returns the session loaded by SessionMiddleware, or nil if the middleware isn't in use.
+func GetSession(r *ghast.Request) *Session {
+	session, _ := r.Context().Value(sessionKey{}).(*Session)
+	return session
+}
+
+// ID returns the session ID, or "" if the session hasn't been saved.
+func (s *Session) ID() string {
+	return s.id
+}
+
+// Get returns the value stored under key, or "" if there is none.
+func (s *Session) Get(key string) string {
+	return s.values[key]
+}
+
+// Set stores value under key.
+func (s *Session) Set(key, value string) {
+	s.values[key] = value
+}
+
+// Delete removes key from the session.
+func (s *Session) Delete(key string) {
+	delete(s.values, key)
+}
+
+// Save writes the session to the store, giving it an ID first if it has none, and sets the session cookie,
+// which restarts the session's TTL. It sets a response header, so it must be called before the response is
+// written.
+func (s *Session) Save(ctx context.Context) error {
+	data, err := json.Marshal(s.values)
+	if err != nil {
+		return err
+	}
+	id := s.id
+	if id == "" {
+		id = generateSessionID()
+	}
+	if err := s.store.Set(ctx, id, data, s.options.TTL); err != nil {
+		return err
+	}
+	s.id = id
+	s.setCookie(id, int(s.options.TTL/time.Second))
+	return nil
+}
+
+// Destroy removes the session from the store, clears its values and expires the session cookie, so the client
+// starts a new session. Like Save, it must be called before the response is written.
+func (s *Session) Destroy(ctx context.Context) error {
+	if s.id != "" {
+		if err := s.store.Delete(ctx, s.id); err != nil {
+			return err
+		}
+	}
+	s.id = ""
+	clear(s.values)
+	s.setCookie("", -1)
+	return nil
+}
+
+func (s *Session) setCookie(value string, maxAge int) {
+	cookie := &http.Cookie{
+		Name:     s.options.CookieName,
+		Value:    value,
+		Path:     "/",
+		MaxAge:   maxAge,
+		Secure:   s.options.Secure,
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	}
+	s.w.SetHeader("Set-Cookie", cookie.String())
+}
+
+// sessionCookie returns the value of the named cookie sent with the request.
+func sessionCookie(r *ghast.Request, name string) string {
+	cookies, err := http.ParseCookie(r.GetHeader("Cookie"))
+	if err != nil {
+		return ""
+	}
+	for _, cookie := range cookies {
+		if cookie.Name == name {
+			return cookie.Value
+		}
+	}
+	return ""
+}
+
+// generateSessionID returns a random 256-bit session ID.
+func generateSessionID() string {
+	b := make([]byte, 32)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// memorySessionStore is the default SessionStore, keeping sessions in a boundedStore whose TTL is the session
+// TTL.
+type memorySessionStore struct {
+	sessions *boundedStore[[]byte]
+}
+
+func (s *memorySessionStore) Get(ctx context.Context, key string) ([]byte, bool, error) {
+	data, found := s.sessions.get(key, time.Now())
+	return data, found, nil
+}
+
+func (s *memorySessionStore) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	s.sessions.put(key, value, time.Now())
+	return nil
+}
+
+func (s *memorySessionStore) Delete(ctx context.Context, key string) error {
+	s.sessions.delete(key)
+	return nil
+}
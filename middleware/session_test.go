@@ -0,0 +1,73 @@
+package middleware
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/Leonard-Atorough/ghast"
+)
+
+// TestSessionSaveLoadDestroy tests that saved values come back with the session cookie and are gone once the
+// session is destroyed
+func TestSessionSaveLoadDestroy(t *testing.T) {
+	app := ghast.New()
+	app.Use(SessionMiddleware(SessionOptions{}))
+	app.Post("/login", ghast.HandlerFunc(func(w ghast.ResponseWriter, r *ghast.Request) {
+		session := GetSession(r)
+		session.Set("user", "alice")
+		if err := session.Save(r.Context()); err != nil {
+			t.Fatal(err)
+		}
+		w.SendString("ok")
+	}))
+	app.Get("/me", ghast.HandlerFunc(func(w ghast.ResponseWriter, r *ghast.Request) {
+		w.SendString(GetSession(r).Get("user"))
+	}))
+	app.Post("/logout", ghast.HandlerFunc(func(w ghast.ResponseWriter, r *ghast.Request) {
+		if err := GetSession(r).Destroy(r.Context()); err != nil {
+			t.Fatal(err)
+		}
+		w.SendString("bye")
+	}))
+
+	res := app.Test(&ghast.Request{Method: ghast.POST, Path: "/login"})
+	cookie, err := http.ParseSetCookie(res.Header("Set-Cookie"))
+	if err != nil || cookie.Name != defaultSessionCookieName || cookie.Value == "" || !cookie.HttpOnly {
+		t.Fatalf("unexpected session cookie %q: %v", res.Header("Set-Cookie"), err)
+	}
+	withCookie := map[string]string{"Cookie": cookie.Name + "=" + cookie.Value}
+
+	res = app.Test(&ghast.Request{Method: ghast.GET, Path: "/me", Headers: withCookie})
+	if res.Body != "alice" {
+		t.Errorf("expected the saved user, got %q", res.Body)
+	}
+
+	res = app.Test(&ghast.Request{Method: ghast.POST, Path: "/logout", Headers: withCookie})
+	if !strings.Contains(res.Header("Set-Cookie"), "Max-Age=0") {
+		t.Errorf("expected the cookie to be expired, got %q", res.Header("Set-Cookie"))
+	}
+	res = app.Test(&ghast.Request{Method: ghast.GET, Path: "/me", Headers: withCookie})
+	if res.Body != "" {
+		t.Errorf("expected no user after logout, got %q", res.Body)
+	}
+}
+
+// TestSessionIgnoresUnknownID tests that a session ID the store doesn't know is replaced when the session is saved
+func TestSessionIgnoresUnknownID(t *testing.T) {
+	app := ghast.New()
+	app.Use(SessionMiddleware(SessionOptions{CookieName: "sid"}))
+	app.Post("/", ghast.HandlerFunc(func(w ghast.ResponseWriter, r *ghast.Request) {
+		session := GetSession(r)
+		if session.ID() != "" {
+			t.Errorf("expected a new session, got ID %q", session.ID())
+		}
+		session.Save(r.Context())
+		w.SendString(session.ID())
+	}))
+
+	res := app.Test(&ghast.Request{Method: ghast.POST, Path: "/", Headers: map[string]string{"Cookie": "sid=chosen-by-client"}})
+	if res.Body == "" || res.Body == "chosen-by-client" {
+		t.Errorf("expected a generated session ID, got %q", res.Body)
+	}
+}
@@ -0,0 +1,133 @@
+package middleware
+
+import (
+	"hash/maphash"
+	"sync"
+	"time"
+)
+
+// storeShards is the number of independently locked shards in a boundedStore.
+const storeShards = 16
+
+// boundedStore is a sharded map of per-key state (rate limit counters, sessions, cached values) with a maximum
+// size and a time-to-live per entry, so high-cardinality keys such as client IPs can't grow it without bound on
+// a long-running server.
+type boundedStore[V any] struct {
+	shards      [storeShards]storeShard[V]
+	seed        maphash.Seed
+	ttl         time.Duration // How long an entry lives after it is created
+	maxPerShard int           // Entries allowed in each shard before the oldest is evicted
+}
+
+type storeShard[V any] struct {
+	mu      sync.Mutex
+	entries map[string]storeEntry[V]
+}
+
+type storeEntry[V any] struct {
+	value   V
+	expires time.Time
+}
+
+// newBoundedStore creates a store holding at most maxEntries entries (spread across shards), each expiring ttl
+// after it was created.
+func newBoundedStore[V any](maxEntries int, ttl time.Duration) *boundedStore[V] {
+	s := &boundedStore[V]{
+		seed:        maphash.MakeSeed(),
+		ttl:         ttl,
+		maxPerShard: max(1, maxEntries/storeShards),
+	}
+	for i := range s.shards {
+		s.shards[i].entries = make(map[string]storeEntry[V])
+	}
+	return s
+}
+
+// update atomically replaces the value for key with fn's result. fn receives the current value and whether a
+// live entry exists. A new entry expires ttl after now; updating an existing entry keeps its expiry.
+func (s *boundedStore[V]) update(key string, now time.Time, fn func(value V, found bool) V) V {
+	shard := &s.shards[maphash.String(s.seed, key)%storeShards]
+	shard.mu.Lock()
+	defer shard.mu.Unlock()
+
+	entry, found := shard.entries[key]
+	if found && !now.Before(entry.expires) {
+		delete(shard.entries, key)
+		entry, found = storeEntry[V]{}, false
+	}
+	if !found {
+		shard.makeRoom(now, s.maxPerShard)
+		entry.expires = now.Add(s.ttl)
+	}
+	entry.value = fn(entry.value, found)
+	shard.entries[key] = entry
+	return entry.value
+}
+
+// get returns the value for key and whether a live entry exists at now.
+func (s *boundedStore[V]) get(key string, now time.Time) (V, bool) {
+	shard := &s.shards[maphash.String(s.seed, key)%storeShards]
+	shard.mu.Lock()
+	defer shard.mu.Unlock()
+
+	entry, found := shard.entries[key]
+	if !found || !now.Before(entry.expires) {
+		var zero V
+		return zero, false
+	}
+	return entry.value, true
+}
+
+// put stores value under key, expiring ttl after now whether or not the key already existed.
+func (s *boundedStore[V]) put(key string, value V, now time.Time) {
+	shard := &s.shards[maphash.String(s.seed, key)%storeShards]
+	shard.mu.Lock()
+	defer shard.mu.Unlock()
+
+	if _, found := shard.entries[key]; !found {
+		shard.makeRoom(now, s.maxPerShard)
+	}
+	shard.entries[key] = storeEntry[V]{value: value, expires: now.Add(s.ttl)}
+}
+
+// delete removes key from the store.
+func (s *boundedStore[V]) delete(key string) {
+	shard := &s.shards[maphash.String(s.seed, key)%storeShards]
+	shard.mu.Lock()
+	defer shard.mu.Unlock()
+	delete(shard.entries, key)
+}
+
+// len returns the number of entries in the store, including expired entries not yet evicted.
+func (s *boundedStore[V]) len() int {
+	n := 0
+	for i := range s.shards {
+		s.shards[i].mu.Lock()
+		n += len(s.shards[i].entries)
+		s.shards[i].mu.Unlock()
+	}
+	return n
+}
+
+// makeRoom ensures the shard has space for one more entry, first by dropping expired entries and then, if it is
+// still full, by evicting the entry closest to expiring. It must be called with the shard locked.
+func (sh *storeShard[V]) makeRoom(now time.Time, limit int) {
+	if len(sh.entries) < limit {
+		return
+	}
+	var oldestKey string
+	var oldest time.Time
+	haveOldest := false
+	for key, entry := range sh.entries {
+		if !now.Before(entry.expires) {
+			delete(sh.entries, key)
+			continue
+		}
+		if !haveOldest || entry.expires.Before(oldest) {
+			oldestKey, oldest, haveOldest = key, entry.expires, true
+		}
+	}
+	if len(sh.entries) >= limit {
+		delete(sh.entries, oldestKey)
+	}
+}
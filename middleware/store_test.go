@@ -0,0 +1,34 @@
+package middleware
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+// TestBoundedStoreExpiresEntries tests that an entry starts over once its TTL has passed
+func TestBoundedStoreExpiresEntries(t *testing.T) {
+	store := newBoundedStore[int](100, time.Minute)
+	now := time.Now()
+	inc := func(v int, found bool) int { return v + 1 }
+
+	store.update("a", now, inc)
+	if got := store.update("a", now.Add(30*time.Second), inc); got != 2 {
+		t.Errorf("expected count 2 within the TTL, got %d", got)
+	}
+	if got := store.update("a", now.Add(time.Minute), inc); got != 1 {
+		t.Errorf("expected count to restart after the TTL, got %d", got)
+	}
+}
+
+// TestBoundedStoreEvictsOldest tests that the store stays within its size limit under many distinct keys
+func TestBoundedStoreEvictsOldest(t *testing.T) {
+	store := newBoundedStore[int](storeShards*4, time.Minute)
+	now := time.Now()
+	for i := range 10000 {
+		store.update(fmt.Sprintf("10.0.%d.%d", i/256, i%256), now.Add(time.Duration(i)), func(v int, found bool) int { return v + 1 })
+	}
+	if n := store.len(); n > storeShards*4 {
+		t.Errorf("expected at most %d entries, got %d", storeShards*4, n)
+	}
+}
@@ -0,0 +1,363 @@
+package ghast
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// WrapHTTP adapts a net/http handler to a ghast Handler, so handlers from the net/http ecosystem (file servers,
+// pprof, metrics exporters, reverse proxies) can be registered on a ghast router. Route parameters are available
+// to the wrapped handler through http.Request.PathValue.
+//
+// net/http headers can hold several values per key while ghast headers hold one, so multiple values written by
+// the wrapped handler are joined with ", ".
+//
+// Example:
+//
+//	app.Get("/metrics", ghast.WrapHTTP(promhttp.Handler()))
+//	app.Get("/files/:name", ghast.WrapHTTP(http.StripPrefix("/files", http.FileServer(http.Dir("public")))))
+func WrapHTTP(h http.Handler) Handler {
+	if adapted, ok := h.(httpHandler); ok {
+		return adapted.handler // Unwrap instead of converting twice
+	}
+	return wrappedHTTPHandler{handler: h}
+}
+
+// ToHTTP adapts a ghast Handler, including a whole *Ghast application, to a net/http handler, so ghast code can
+// be hosted by net/http servers and wrapped by net/http tooling such as httptest.
+//
+// Example:
+//
+//	app := ghast.New()
+//	app.Get("/users/:id", getUser)
+//	http.ListenAndServe(":8080", ghast.ToHTTP(app))
+func ToHTTP(h Handler) http.Handler {
+	if wrapped, ok := h.(wrappedHTTPHandler); ok {
+		return wrapped.handler
+	}
+	return httpHandler{handler: h}
+}
+
+// WrapHTTPMiddleware adapts net/http middleware (gorilla/handlers, nosurf, httprate and the like) to ghast
+// Middleware, so it can be passed to Use or a route without being rewritten. The ghast request's route
+// parameters and application carry through to the next handler, while changes the middleware makes to the
+// method, path, headers, query, body or context are seen by it.
+//
+// Example:
+//
+//	app.Use(ghast.WrapHTTPMiddleware(handlers.CompressHandler))
+//	app.Use(ghast.WrapHTTPMiddleware(httprate.LimitByIP(100, time.Minute)))
+func WrapHTTPMiddleware(mw func(http.Handler) http.Handler) Middleware {
+	return func(next Handler) Handler {
+		return WrapHTTP(mw(ToHTTP(next)))
+	}
+}
+
+// ghastRequestKey is the context key under which newHTTPRequest stores the ghast request it was built from.
+type ghastRequestKey struct{}
+
+// wrappedHTTPHandler is a net/http handler exposed as a ghast Handler.
+type wrappedHTTPHandler struct {
+	handler http.Handler
+}
+
+// ServeHTTP implements Handler, translating the ghast request into a net/http request.
+func (h wrappedHTTPHandler) ServeHTTP(w ResponseWriter, r *Request) {
+	hw := &httpWriter{w: w, header: make(http.Header), statusCode: 200}
+	h.handler.ServeHTTP(hw, newHTTPRequest(r))
+	hw.finish()
+}
+
+// httpHandler is a ghast Handler exposed as a net/http handler.
+type httpHandler struct {
+	handler Handler
+}
+
+// ServeHTTP implements http.Handler, translating the net/http request into a ghast Request.
+func (h httpHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	req, err := newRequestFromHTTP(r)
+	if err != nil {
+		if maxBytesErr := (*http.MaxBytesError)(nil); errors.As(err, &maxBytesErr) {
+			// The body was limited by http.MaxBytesHandler, as the ghast server does for HTTP/2 and FastCGI
+			http.Error(w, "request body too large", http.StatusRequestEntityTooLarge)
+			return
+		}
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err = req.cleanPath(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if original, ok := r.Context().Value(ghastRequestKey{}).(*Request); ok {
+		// The request came from ghast through net/http middleware: keep what net/http can't carry.
+		req.Params = original.Params
+		req.app = original.app
+	}
+	gw := &ghastWriter{w: w, headers: make(map[string]string), statusCode: 200, req: req}
+	h.handler.ServeHTTP(gw, req)
+	gw.finish()
+}
+
+// newHTTPRequest builds the net/http equivalent of a ghast Request.
+func newHTTPRequest(r *Request) *http.Request {
+	u := &url.URL{Path: r.Path}
+	if path, err := url.PathUnescape(r.Path); err == nil {
+		// ghast paths are kept as sent, percent-encoded; net/http keeps the decoded form alongside.
+		u.Path, u.RawPath = path, r.Path
+	}
+	// Query values are kept as sent, so the query string is passed on as is rather than encoded again
+	u.RawQuery = strings.TrimPrefix(queryString(r), "?")
+
+	req := &http.Request{
+		Method:        r.Method,
+		URL:           u,
+		RequestURI:    u.RequestURI(),
+		Proto:         HTTPVersion,
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        make(http.Header, len(r.Headers)),
+		Body:          http.NoBody,
+		ContentLength: int64(len(r.Body)),
+		Host:          r.Headers["Host"],
+	}
+	if major, minor, ok := http.ParseHTTPVersion(r.Version); ok {
+		req.Proto, req.ProtoMajor, req.ProtoMinor = r.Version, major, minor
+	}
+	for key, value := range r.Headers {
+		if key != "Host" {
+			req.Header[key] = []string{value}
+		}
+	}
+	if len(r.Body) > 0 {
+		req.Body = io.NopCloser(bytes.NewReader(r.Body))
+	}
+	if r.ClientIP != "" {
+		req.RemoteAddr = net.JoinHostPort(r.ClientIP, "0")
+	}
+	for key, value := range r.Params {
+		req.SetPathValue(key, value)
+	}
+	return req.WithContext(context.WithValue(r.Context(), ghastRequestKey{}, r))
+}
+
+// newRequestFromHTTP builds a ghast Request from a net/http request, reading its body. Its query parameters are
+// parsed from the raw query string, keeping their values as sent like the ghast server does.
+func newRequestFromHTTP(r *http.Request) (*Request, error) {
+	queries := make(map[string]string)
+	if r.URL.RawQuery != "" {
+		if err := parseParamsInto(r.URL.RawQuery, queries); err != nil {
+			return nil, err
+		}
+	}
+	var body []byte
+	if r.Body != nil {
+		var err error
+		if body, err = io.ReadAll(r.Body); err != nil {
+			return nil, fmt.Errorf("failed to read request body: %w", err)
+		}
+	}
+
+	req := &Request{
+		Method:   r.Method,
+		Path:     r.URL.EscapedPath(),
+		Headers:  make(map[string]string, len(r.Header)+1),
+		Body:     body,
+		Version:  r.Proto,
+		Params:   make(map[string]string),
+		Queries:  queries,
+		ClientIP: r.RemoteAddr,
+		ctx:      r.Context(),
+		rawQuery: r.URL.RawQuery,
+	}
+	for key, values := range r.Header {
+		req.Headers[key] = strings.Join(values, ", ")
+	}
+	if r.Host != "" {
+		req.Headers["Host"] = r.Host
+	}
+	if host, _, err := net.SplitHostPort(r.RemoteAddr); err == nil {
+		req.ClientIP = host
+	}
+	return req, nil
+}
+
+// httpWriter implements http.ResponseWriter on top of a ghast ResponseWriter. As with net/http, headers can be
+// changed until the first body write, when they are copied to the ghast writer.
+type httpWriter struct {
+	w           ResponseWriter
+	header      http.Header
+	statusCode  int
+	wroteHeader bool // WriteHeader has been called
+	committed   bool // Status and headers have been copied to w
+}
+
+func (hw *httpWriter) Header() http.Header {
+	return hw.header
+}
+
+// WriteHeader records the status code. Only the first call has an effect.
+func (hw *httpWriter) WriteHeader(statusCode int) {
+	if hw.wroteHeader {
+		return
+	}
+	hw.wroteHeader = true
+	hw.statusCode = statusCode
+}
+
+// Write sends body data, detecting the content type from the first chunk as net/http does.
+func (hw *httpWriter) Write(p []byte) (int, error) {
+	if !hw.committed {
+		if hw.header.Get("Content-Type") == "" && len(p) > 0 {
+			hw.header.Set("Content-Type", http.DetectContentType(p))
+		}
+		hw.commit()
+	}
+	return hw.w.Send(p)
+}
+
+// Flush implements http.Flusher when the underlying writer can flush.
+func (hw *httpWriter) Flush() {
+	hw.commit()
+	if f, ok := hw.w.(Flusher); ok {
+		f.Flush()
+	}
+}
+
+// commit copies the status and headers to the ghast writer, joining multiple header values.
+func (hw *httpWriter) commit() {
+	if hw.committed {
+		return
+	}
+	hw.committed = true
+	hw.wroteHeader = true
+	for key, values := range hw.header {
+		hw.w.SetHeader(key, strings.Join(values, ", "))
+	}
+	hw.w.Status(hw.statusCode)
+}
+
+// finish applies the status and headers if the wrapped handler never wrote a body.
+func (hw *httpWriter) finish() {
+	hw.commit()
+}
+
+// ghastWriter implements ResponseWriter on top of a net/http ResponseWriter.
+type ghastWriter struct {
+	w          http.ResponseWriter
+	headers    map[string]string
+	statusCode int
+	written    bool
+	req        *Request
+}
+
+func (gw *ghastWriter) responseStarted() bool {
+	return gw.written
+}
+
+func (gw *ghastWriter) Header() map[string]string {
+	return gw.headers
+}
+
+func (gw *ghastWriter) Status(statusCode int) ResponseWriter {
+	if !gw.written {
+		gw.statusCode = statusCode
+	}
+	return gw
+}
+
+func (gw *ghastWriter) SetHeader(key, value string) ResponseWriter {
+	gw.headers[key] = value
+	return gw
+}
+
+func (gw *ghastWriter) Send(data []byte) (int, error) {
+	gw.writeHeader()
+	return gw.w.Write(data)
+}
+
+func (gw *ghastWriter) SendString(s string) (int, error) {
+	return gw.Send([]byte(s))
+}
+
+func (gw *ghastWriter) JSON(statusCode int, data interface{}) error {
+	return gw.encodeJSON(statusCode, data, "")
+}
+
+func (gw *ghastWriter) JSONPretty(statusCode int, data interface{}) error {
+	return gw.encodeJSON(statusCode, data, "  ")
+}
+
+func (gw *ghastWriter) encodeJSON(statusCode int, data interface{}, indent string) error {
+	var body []byte
+	var err error
+	if indent == "" {
+		body, err = json.Marshal(data)
+	} else {
+		body, err = json.MarshalIndent(data, "", indent)
+	}
+	if err != nil {
+		return err
+	}
+	gw.Status(statusCode)
+	gw.SetHeader("Content-Type", "application/json")
+	_, err = gw.Send(body)
+	return err
+}
+
+func (gw *ghastWriter) HTML(statusCode int, html string) error {
+	gw.Status(statusCode)
+	gw.SetHeader("Content-Type", "text/html")
+	_, err := gw.SendString(html)
+	return err
+}
+
+func (gw *ghastWriter) Plain(statusCode int, text string) error {
+	gw.Status(statusCode)
+	gw.SetHeader("Content-Type", "text/plain")
+	_, err := gw.SendString(text)
+	return err
+}
+
+func (gw *ghastWriter) Render(statusCode int, name string, data any) error {
+	body, err := renderView(rendererFor(gw.req), name, data)
+	if err != nil {
+		return err
+	}
+	gw.Status(statusCode)
+	gw.SetHeader("Content-Type", "text/html; charset=utf-8")
+	_, err = gw.Send(body)
+	return err
+}
+
+// Flush sends the headers and any data buffered by the net/http server.
+func (gw *ghastWriter) Flush() error {
+	gw.writeHeader()
+	return http.NewResponseController(gw.w).Flush()
+}
+
+// writeHeader copies the headers and status to the net/http writer before the first body write.
+func (gw *ghastWriter) writeHeader() {
+	if gw.written {
+		return
+	}
+	gw.written = true
+	header := gw.w.Header()
+	for key, value := range gw.headers {
+		header.Set(key, value)
+	}
+	gw.w.WriteHeader(gw.statusCode)
+}
+
+// finish writes the status and headers if the handler never wrote a body.
+func (gw *ghastWriter) finish() {
+	gw.writeHeader()
+}
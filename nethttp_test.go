@@ -0,0 +1,182 @@
+package ghast
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// TestWrapHTTP tests that a net/http handler sees the ghast request and its response reaches the client
+func TestWrapHTTP(t *testing.T) {
+	app := New()
+	app.Post("/users/:id", WrapHTTP(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		if r.PathValue("id") != "42" || r.URL.Query().Get("notify") != "1" || string(body) != "hello" {
+			t.Errorf("unexpected request: id=%q query=%q body=%q", r.PathValue("id"), r.URL.RawQuery, body)
+		}
+		if r.Header.Get("X-Token") != "secret" {
+			t.Errorf("expected X-Token header, got %q", r.Header.Get("X-Token"))
+		}
+		w.Header().Add("Vary", "Accept")
+		w.Header().Add("Vary", "Origin")
+		w.WriteHeader(http.StatusCreated)
+		io.WriteString(w, "<p>created</p>")
+	})))
+
+	res := app.Test(&Request{
+		Method:  POST,
+		Path:    "/users/42?notify=1",
+		Headers: map[string]string{"x-token": "secret"},
+		Body:    []byte("hello"),
+	})
+
+	if res.StatusCode != 201 || res.Body != "<p>created</p>" {
+		t.Errorf("unexpected response: %d %q", res.StatusCode, res.Body)
+	}
+	if res.Header("Vary") != "Accept, Origin" {
+		t.Errorf("expected joined Vary header, got %q", res.Header("Vary"))
+	}
+	if !strings.HasPrefix(res.Header("Content-Type"), "text/html") {
+		t.Errorf("expected sniffed text/html content type, got %q", res.Header("Content-Type"))
+	}
+}
+
+// TestToHTTP tests that a ghast application can be served by net/http
+func TestToHTTP(t *testing.T) {
+	app := New()
+	app.Put("/items/:id", HandlerFunc(func(w ResponseWriter, r *Request) {
+		w.SetHeader("X-Client", r.ClientIP)
+		w.JSON(200, map[string]string{"id": r.Param("id"), "q": r.Query("q"), "body": string(r.Body), "host": r.GetHeader("Host")})
+	}))
+
+	req := httptest.NewRequest("PUT", "http://example.com/items/7?q=go", strings.NewReader("payload"))
+	rec := httptest.NewRecorder()
+	ToHTTP(app).ServeHTTP(rec, req)
+
+	if rec.Code != 200 || rec.Header().Get("Content-Type") != "application/json" {
+		t.Errorf("unexpected response: %d %q", rec.Code, rec.Header().Get("Content-Type"))
+	}
+	expected := `{"body":"payload","host":"example.com","id":"7","q":"go"}`
+	if rec.Body.String() != expected {
+		t.Errorf("expected body %s, got %s", expected, rec.Body.String())
+	}
+	if rec.Header().Get("X-Client") != "192.0.2.1" {
+		t.Errorf("expected client IP from RemoteAddr, got %q", rec.Header().Get("X-Client"))
+	}
+
+	rec = httptest.NewRecorder()
+	ToHTTP(app).ServeHTTP(rec, httptest.NewRequest("GET", "/missing", nil))
+	if rec.Code != 404 {
+		t.Errorf("expected 404, got %d", rec.Code)
+	}
+}
+
+// TestHTTPAdaptersQueries tests that query values reach handlers the same way through both adapters as through
+// the ghast server: kept as sent for ghast handlers, decoded by net/http for net/http handlers
+func TestHTTPAdaptersQueries(t *testing.T) {
+	app := New()
+	app.Get("/ghast", HandlerFunc(func(w ResponseWriter, r *Request) { w.Plain(200, r.Query("q")) }))
+	app.Get("/nethttp", WrapHTTP(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, r.URL.RawQuery+" "+r.URL.Query().Get("q"))
+	})))
+
+	rec := httptest.NewRecorder()
+	ToHTTP(app).ServeHTTP(rec, httptest.NewRequest("GET", "/ghast?q=a%20b", nil))
+	if rec.Body.String() != "a%20b" {
+		t.Errorf("expected ToHTTP to keep the query value as sent, got %q", rec.Body.String())
+	}
+	native := app.Test(&Request{Method: GET, Path: "/ghast?q=a%20b"})
+	if native.Body != rec.Body.String() {
+		t.Errorf("expected the same query value as the ghast server, got %q and %q", native.Body, rec.Body.String())
+	}
+
+	res := app.Test(&Request{Method: GET, Path: "/nethttp?q=a%20b"})
+	if res.Body != "q=a%20b a b" {
+		t.Errorf("expected WrapHTTP to pass the query string on as sent, got %q", res.Body)
+	}
+}
+
+// TestToHTTPBodyLimit tests that bodies cut off by http.MaxBytesHandler get 413
+func TestToHTTPBodyLimit(t *testing.T) {
+	app := New()
+	app.Post("/", HandlerFunc(func(w ResponseWriter, r *Request) { w.Plain(200, string(r.Body)) }))
+	handler := http.MaxBytesHandler(ToHTTP(app), 4)
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest("POST", "/", strings.NewReader("too long")))
+	if rec.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("expected 413, got %d", rec.Code)
+	}
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest("POST", "/", strings.NewReader("ok")))
+	if rec.Code != 200 || rec.Body.String() != "ok" {
+		t.Errorf("expected 200 ok, got %d %q", rec.Code, rec.Body.String())
+	}
+}
+
+// TestHTTPAdaptersUnwrap tests that converting a handler there and back returns the original
+func TestHTTPAdaptersUnwrap(t *testing.T) {
+	app := New()
+	if WrapHTTP(ToHTTP(app)) != Handler(app) {
+		t.Error("expected WrapHTTP(ToHTTP(h)) to return h")
+	}
+}
+
+// TestWrapHTTPMiddleware tests that net/http middleware can modify the request and response or stop the chain,
+// and that route parameters survive the round trip
+func TestWrapHTTPMiddleware(t *testing.T) {
+	requireKey := func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Header.Get("X-Api-Key") == "" {
+				http.Error(w, "missing key", http.StatusUnauthorized)
+				return
+			}
+			w.Header().Set("X-Checked", "yes")
+			r.Header.Set("X-User", "alice")
+			next.ServeHTTP(w, r)
+		})
+	}
+
+	app := New()
+	app.Get("/users/:id", HandlerFunc(func(w ResponseWriter, r *Request) {
+		w.Plain(200, r.Param("id")+" "+r.GetHeader("X-User"))
+	}), WrapHTTPMiddleware(requireKey))
+
+	res := app.Test(&Request{Method: GET, Path: "/users/7"})
+	if res.StatusCode != 401 || !strings.Contains(res.Body, "missing key") {
+		t.Errorf("expected 401 from middleware, got %d %q", res.StatusCode, res.Body)
+	}
+
+	res = app.Test(&Request{Method: GET, Path: "/users/7", Headers: map[string]string{"X-Api-Key": "k"}})
+	if res.StatusCode != 200 || res.Body != "7 alice" {
+		t.Errorf("unexpected response: %d %q", res.StatusCode, res.Body)
+	}
+	if res.Header("X-Checked") != "yes" {
+		t.Errorf("expected header set by middleware, got %q", res.Header("X-Checked"))
+	}
+}
+
+// TestRouterMountHTTP tests that a mounted net/http handler serves the prefix and every path below it
+func TestRouterMountHTTP(t *testing.T) {
+	app := New()
+	app.Router().Mount("/debug", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		io.WriteString(w, r.Method+" "+r.URL.Path)
+	}))
+
+	for _, tc := range []struct{ method, path string }{
+		{"GET", "/debug"},
+		{"GET", "/debug/pprof/heap"},
+		{"POST", "/debug/vars"},
+	} {
+		res := app.Test(&Request{Method: tc.method, Path: tc.path})
+		if want := tc.method + " " + tc.path; res.StatusCode != 200 || res.Body != want {
+			t.Errorf("%s %s: expected 200 %q, got %d %q", tc.method, tc.path, want, res.StatusCode, res.Body)
+		}
+	}
+
+	if res := app.Test(&Request{Method: "GET", Path: "/debugger"}); res.StatusCode != 404 {
+		t.Errorf("expected 404 outside the prefix, got %d", res.StatusCode)
+	}
+}
@@ -0,0 +1,376 @@
+package ghast
+
+import (
+	"fmt"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// OpenAPIVersion is the OpenAPI specification version of generated documents.
+const OpenAPIVersion = "3.0.3"
+
+// OpenAPIInfo is the metadata section of a generated OpenAPI document.
+type OpenAPIInfo struct {
+	Title       string `json:"title"`
+	Version     string `json:"version"`
+	Description string `json:"description,omitempty"`
+}
+
+// Operation annotates a route with documentation used for OpenAPI generation.
+// Request and Responses take example values (or nil pointers) of the Go types exchanged with clients;
+// their schemas are derived from the types via reflection, honoring json struct tags.
+type Operation struct {
+	Summary     string      // Short summary of what the operation does
+	Description string      // Longer explanation of the operation
+	Tags        []string    // Tags used to group operations in documentation tools
+	Deprecated  bool        // Marks the operation as deprecated
+	Request     any         // Value of the request body type, e.g. CreateUser{}
+	Responses   map[int]any // Value of the response body type per status code; nil means no body
+}
+
+// OpenAPIDocument is a generated OpenAPI 3 document.
+type OpenAPIDocument struct {
+	OpenAPI    string                                  `json:"openapi"`
+	Info       OpenAPIInfo                             `json:"info"`
+	Paths      map[string]map[string]*OpenAPIOperation `json:"paths"`
+	Components *OpenAPIComponents                      `json:"components,omitempty"`
+}
+
+// OpenAPIOperation is a single operation (method + path) in a generated document.
+type OpenAPIOperation struct {
+	Summary     string                      `json:"summary,omitempty"`
+	Description string                      `json:"description,omitempty"`
+	OperationID string                      `json:"operationId,omitempty"`
+	Tags        []string                    `json:"tags,omitempty"`
+	Deprecated  bool                        `json:"deprecated,omitempty"`
+	Parameters  []OpenAPIParameter          `json:"parameters,omitempty"`
+	RequestBody *OpenAPIRequestBody         `json:"requestBody,omitempty"`
+	Responses   map[string]*OpenAPIResponse `json:"responses"`
+}
+
+// OpenAPIParameter describes a path, query or header parameter.
+type OpenAPIParameter struct {
+	Name     string  `json:"name"`
+	In       string  `json:"in"`
+	Required bool    `json:"required"`
+	Schema   *Schema `json:"schema"`
+}
+
+// OpenAPIRequestBody describes the body accepted by an operation.
+type OpenAPIRequestBody struct {
+	Required bool                         `json:"required"`
+	Content  map[string]*OpenAPIMediaType `json:"content"`
+}
+
+// OpenAPIResponse describes a response returned by an operation.
+type OpenAPIResponse struct {
+	Description string                       `json:"description"`
+	Content     map[string]*OpenAPIMediaType `json:"content,omitempty"`
+}
+
+// OpenAPIMediaType holds the schema for a given content type.
+type OpenAPIMediaType struct {
+	Schema *Schema `json:"schema"`
+}
+
+// OpenAPIComponents holds reusable schemas referenced from operations.
+type OpenAPIComponents struct {
+	Schemas map[string]*Schema `json:"schemas,omitempty"`
+}
+
+// Schema is the subset of JSON Schema used by OpenAPI documents.
+type Schema struct {
+	Ref                  string             `json:"$ref,omitempty"`
+	Type                 string             `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Pattern              string             `json:"pattern,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	AdditionalProperties *Schema            `json:"additionalProperties,omitempty"`
+}
+
+// SetOpenAPIInfo sets the title, version and description of the generated OpenAPI document.
+// Returns the app for chaining.
+func (g *Ghast) SetOpenAPIInfo(info OpenAPIInfo) *Ghast {
+	g.openAPIInfo = info
+	return g
+}
+
+// Document attaches OpenAPI documentation to the route registered for method and path. The path is the full
+// path as reported by Routes, e.g. "/api/users/:id" for a route in an "/api" group. Returns the app for chaining.
+func (g *Ghast) Document(method, path string, op Operation) *Ghast {
+	if g.operations == nil {
+		g.operations = make(map[string]Operation)
+	}
+	g.operations[method+" "+path] = op
+	return g
+}
+
+// ServeOpenAPI registers a GET route at path serving the OpenAPI document generated from the application's
+// routes. The document is generated on each request, so routes registered later are included.
+// Returns the app for chaining.
+//
+// Example:
+//
+//	app.SetOpenAPIInfo(ghast.OpenAPIInfo{Title: "Users API", Version: "1.2.0"})
+//	app.Document("GET", "/users/:id", ghast.Operation{Summary: "Get a user", Responses: map[int]any{200: User{}}})
+//	app.ServeOpenAPI("/openapi.json")
+func (g *Ghast) ServeOpenAPI(path string) *Ghast {
+	g.undocumented = append(g.undocumented, path)
+	return g.Get(path, HandlerFunc(func(w ResponseWriter, r *Request) {
+		w.JSON(200, g.OpenAPI())
+	}))
+}
+
+// ServeSwaggerUI registers a GET route at path serving a Swagger UI page for the document served at specPath.
+// The UI assets are loaded from the unpkg CDN. Returns the app for chaining.
+func (g *Ghast) ServeSwaggerUI(path, specPath string) *Ghast {
+	g.undocumented = append(g.undocumented, path)
+	page := fmt.Sprintf(swaggerUIPage, strconv.Quote(specPath))
+	return g.Get(path, HandlerFunc(func(w ResponseWriter, r *Request) {
+		w.HTML(200, page)
+	}))
+}
+
+const swaggerUIPage = `<!DOCTYPE html>
+<html>
+<head>
+<title>API Documentation</title>
+<link rel="stylesheet" href="https://unpkg.com/swagger-ui-dist@5/swagger-ui.css">
+</head>
+<body>
+<div id="swagger-ui"></div>
+<script src="https://unpkg.com/swagger-ui-dist@5/swagger-ui-bundle.js"></script>
+<script>window.ui = SwaggerUIBundle({url: %s, dom_id: "#swagger-ui"});</script>
+</body>
+</html>`
+
+// OpenAPI generates an OpenAPI 3 document describing every route served by the application.
+func (g *Ghast) OpenAPI() *OpenAPIDocument {
+	info := g.openAPIInfo
+	if info.Title == "" {
+		info.Title = "Ghast API"
+	}
+	if info.Version == "" {
+		info.Version = "1.0.0"
+	}
+
+	doc := &OpenAPIDocument{
+		OpenAPI: OpenAPIVersion,
+		Info:    info,
+		Paths:   make(map[string]map[string]*OpenAPIOperation),
+	}
+	schemas := make(map[string]*Schema)
+
+	for _, route := range g.Routes() {
+		if g.isUndocumented(route.Path) {
+			continue
+		}
+		path := openAPIPath(route.Path)
+		if doc.Paths[path] == nil {
+			doc.Paths[path] = make(map[string]*OpenAPIOperation)
+		}
+		doc.Paths[path][strings.ToLower(route.Method)] = g.buildOperation(route, schemas)
+	}
+
+	if len(schemas) > 0 {
+		doc.Components = &OpenAPIComponents{Schemas: schemas}
+	}
+	return doc
+}
+
+// isUndocumented reports whether path belongs to a documentation route that should not be listed itself.
+func (g *Ghast) isUndocumented(path string) bool {
+	for _, p := range g.undocumented {
+		if p == path {
+			return true
+		}
+	}
+	return false
+}
+
+// buildOperation converts a route and its optional annotation into an OpenAPI operation.
+func (g *Ghast) buildOperation(route RouteInfo, schemas map[string]*Schema) *OpenAPIOperation {
+	annotation := g.operations[route.Method+" "+route.Path]
+	op := &OpenAPIOperation{
+		Summary:     annotation.Summary,
+		Description: annotation.Description,
+		OperationID: operationID(route),
+		Tags:        annotation.Tags,
+		Deprecated:  annotation.Deprecated,
+		Responses:   make(map[string]*OpenAPIResponse),
+	}
+
+	// Tags attached to the route with Router.Tag group it alongside those of its annotation.
+	for _, tag := range route.Tags {
+		if !slices.Contains(op.Tags, tag) {
+			op.Tags = append(slices.Clip(op.Tags), tag)
+		}
+	}
+
+	for _, param := range route.Params {
+		op.Parameters = append(op.Parameters, OpenAPIParameter{
+			Name:     param,
+			In:       "path",
+			Required: true,
+			Schema:   &Schema{Type: "string"},
+		})
+	}
+
+	if annotation.Request != nil {
+		op.RequestBody = &OpenAPIRequestBody{
+			Required: true,
+			Content:  jsonContent(schemaFor(reflect.TypeOf(annotation.Request), schemas)),
+		}
+	}
+
+	for status, body := range annotation.Responses {
+		response := &OpenAPIResponse{Description: httpStatusText(status)}
+		if body != nil {
+			response.Content = jsonContent(schemaFor(reflect.TypeOf(body), schemas))
+		}
+		op.Responses[strconv.Itoa(status)] = response
+	}
+	if len(op.Responses) == 0 {
+		op.Responses["200"] = &OpenAPIResponse{Description: httpStatusText(200)}
+	}
+	return op
+}
+
+// jsonContent wraps a schema in an application/json content map.
+func jsonContent(schema *Schema) map[string]*OpenAPIMediaType {
+	return map[string]*OpenAPIMediaType{"application/json": {Schema: schema}}
+}
+
+// openAPIPath converts a ghast path template to OpenAPI syntax. Constraints are dropped and wildcards become
+// ordinary parameters.
+// Example: "/users/:id(\d+)/files/*path" returns "/users/{id}/files/{path}".
+func openAPIPath(path string) string {
+	parts := strings.Split(path, "/")
+	for i, part := range parts {
+		if name, ok := templateParamName(part); ok {
+			parts[i] = "{" + name + "}"
+		}
+	}
+	return strings.Join(parts, "/")
+}
+
+// templateParamName returns the parameter name of a ":name", ":name(pattern)" or "*name" template segment.
+func templateParamName(part string) (string, bool) {
+	if !strings.HasPrefix(part, ":") && !strings.HasPrefix(part, "*") {
+		return "", false
+	}
+	name, _, _ := strings.Cut(strings.TrimSuffix(part[1:], "?"), "(")
+	return name, true
+}
+
+// operationID derives a stable operation identifier from the method and path.
+// Example: GET "/users/:id" returns "getUsersById".
+func operationID(route RouteInfo) string {
+	var b strings.Builder
+	b.WriteString(strings.ToLower(route.Method))
+	for _, part := range strings.Split(route.Path, "/") {
+		if part == "" {
+			continue
+		}
+		if name, ok := templateParamName(part); ok {
+			b.WriteString("By")
+			part = name
+		}
+		for _, word := range strings.FieldsFunc(part, func(r rune) bool { return r == '-' || r == '_' || r == '.' }) {
+			b.WriteString(strings.ToUpper(word[:1]) + word[1:])
+		}
+	}
+	return b.String()
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// schemaFor derives a schema from a Go type. Named struct types are added to schemas and referenced,
+// which keeps documents small and supports recursive types.
+func schemaFor(t reflect.Type, schemas map[string]*Schema) *Schema {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	switch {
+	case t == timeType:
+		return &Schema{Type: "string", Format: "date-time"}
+	case t.Kind() == reflect.Struct && t.Name() != "":
+		name := t.Name()
+		if _, ok := schemas[name]; !ok {
+			schemas[name] = &Schema{} // Placeholder so recursive references terminate.
+			schemas[name] = structSchema(t, schemas)
+		}
+		return &Schema{Ref: "#/components/schemas/" + name}
+	}
+
+	switch t.Kind() {
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return &Schema{Type: "integer", Format: "int32"}
+	case reflect.Int64, reflect.Uint64:
+		return &Schema{Type: "integer", Format: "int64"}
+	case reflect.Float32:
+		return &Schema{Type: "number", Format: "float"}
+	case reflect.Float64:
+		return &Schema{Type: "number", Format: "double"}
+	case reflect.String:
+		return &Schema{Type: "string"}
+	case reflect.Slice, reflect.Array:
+		if t.Elem().Kind() == reflect.Uint8 {
+			return &Schema{Type: "string", Format: "byte"}
+		}
+		return &Schema{Type: "array", Items: schemaFor(t.Elem(), schemas)}
+	case reflect.Map:
+		return &Schema{Type: "object", AdditionalProperties: schemaFor(t.Elem(), schemas)}
+	case reflect.Struct:
+		return structSchema(t, schemas)
+	default:
+		return &Schema{}
+	}
+}
+
+// structSchema builds an object schema from exported struct fields, honoring json tags. Fields without
+// omitempty are listed as required, and untagged embedded structs are flattened as encoding/json does.
+func structSchema(t reflect.Type, schemas map[string]*Schema) *Schema {
+	schema := &Schema{Type: "object", Properties: make(map[string]*Schema)}
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if !field.IsExported() {
+			continue
+		}
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name, options, _ := strings.Cut(tag, ",")
+
+		fieldType := field.Type
+		for fieldType.Kind() == reflect.Pointer {
+			fieldType = fieldType.Elem()
+		}
+		if field.Anonymous && name == "" && fieldType.Kind() == reflect.Struct {
+			embedded := structSchema(fieldType, schemas)
+			for propName, prop := range embedded.Properties {
+				schema.Properties[propName] = prop
+			}
+			schema.Required = append(schema.Required, embedded.Required...)
+			continue
+		}
+
+		if name == "" {
+			name = field.Name
+		}
+		schema.Properties[name] = schemaFor(field.Type, schemas)
+		if !strings.Contains(options, "omitempty") && field.Type.Kind() != reflect.Pointer {
+			schema.Required = append(schema.Required, name)
+		}
+	}
+	return schema
+}
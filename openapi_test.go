@@ -0,0 +1,116 @@
+package ghast
+
+import (
+	"encoding/json"
+	"slices"
+	"testing"
+	"time"
+)
+
+type openAPITestUser struct {
+	ID        int                `json:"id"`
+	Name      string             `json:"name"`
+	Email     string             `json:"email,omitempty"`
+	CreatedAt time.Time          `json:"created_at"`
+	Friends   []*openAPITestUser `json:"friends,omitempty"`
+	internal  string
+}
+
+// TestOpenAPIDocument tests generation of paths, parameters and component schemas from routes
+func TestOpenAPIDocument(t *testing.T) {
+	app := New()
+	app.SetOpenAPIInfo(OpenAPIInfo{Title: "Users API", Version: "2.0.0"})
+	app.Group("/api").Get("/users/:id", HandlerFunc(func(w ResponseWriter, r *Request) {}))
+	app.Post("/users", HandlerFunc(func(w ResponseWriter, r *Request) {}))
+	app.Document("GET", "/api/users/:id", Operation{
+		Summary:   "Get a user",
+		Tags:      []string{"users"},
+		Responses: map[int]any{200: openAPITestUser{}, 404: nil},
+	})
+	app.Document("POST", "/users", Operation{Request: &openAPITestUser{}})
+	app.ServeOpenAPI("/openapi.json")
+
+	doc := app.OpenAPI()
+
+	if doc.Info.Title != "Users API" || doc.OpenAPI != OpenAPIVersion {
+		t.Errorf("unexpected document header: %+v", doc.Info)
+	}
+	if _, ok := doc.Paths["/openapi.json"]; ok {
+		t.Error("the OpenAPI route itself should not be documented")
+	}
+
+	get := doc.Paths["/api/users/{id}"]["get"]
+	if get == nil {
+		t.Fatalf("GET /api/users/{id} missing from paths: %v", doc.Paths)
+	}
+	if get.Summary != "Get a user" || get.OperationID != "getApiUsersById" {
+		t.Errorf("unexpected operation: %+v", get)
+	}
+	if len(get.Parameters) != 1 || get.Parameters[0].Name != "id" || get.Parameters[0].In != "path" {
+		t.Errorf("unexpected parameters: %+v", get.Parameters)
+	}
+	if get.Responses["200"].Content["application/json"].Schema.Ref != "#/components/schemas/openAPITestUser" {
+		t.Errorf("200 response should reference the user schema: %+v", get.Responses["200"])
+	}
+	if get.Responses["404"].Content != nil {
+		t.Error("404 response should have no content")
+	}
+
+	user := doc.Components.Schemas["openAPITestUser"]
+	if user == nil {
+		t.Fatal("user schema missing from components")
+	}
+	if user.Properties["created_at"].Format != "date-time" {
+		t.Errorf("time fields should be date-time strings: %+v", user.Properties["created_at"])
+	}
+	if user.Properties["friends"].Items.Ref != "#/components/schemas/openAPITestUser" {
+		t.Errorf("recursive field should reference the user schema: %+v", user.Properties["friends"])
+	}
+	if _, ok := user.Properties["internal"]; ok {
+		t.Error("unexported fields should not be documented")
+	}
+	required, _ := json.Marshal(user.Required)
+	if string(required) != `["id","name","created_at"]` {
+		t.Errorf("unexpected required fields: %s", required)
+	}
+
+	post := doc.Paths["/users"]["post"]
+	if post.RequestBody == nil || post.Responses["200"] == nil {
+		t.Errorf("unexpected POST operation: %+v", post)
+	}
+}
+
+// TestOpenAPIRouteTags tests that tags attached to routes group their operations along with annotated tags
+func TestOpenAPIRouteTags(t *testing.T) {
+	app := New()
+	app.Router().Get("/users", HandlerFunc(func(w ResponseWriter, r *Request) {})).Tag("users", "v1")
+	app.Document("GET", "/users", Operation{Tags: []string{"users", "public"}})
+
+	get := app.OpenAPI().Paths["/users"]["get"]
+	if get == nil || !slices.Equal(get.Tags, []string{"users", "public", "v1"}) {
+		t.Errorf("expected tags [users public v1], got %+v", get)
+	}
+}
+
+// TestServeOpenAPI tests that the OpenAPI document is served as JSON
+func TestServeOpenAPI(t *testing.T) {
+	app := New()
+	app.Get("/health", HandlerFunc(func(w ResponseWriter, r *Request) {}))
+	app.ServeOpenAPI("/openapi.json")
+	app.ServeSwaggerUI("/docs", "/openapi.json")
+
+	res := app.Test(&Request{Method: "GET", Path: "/openapi.json"})
+
+	var doc OpenAPIDocument
+	if err := json.Unmarshal([]byte(res.Body), &doc); err != nil {
+		t.Fatalf("invalid OpenAPI JSON: %v", err)
+	}
+	if len(doc.Paths) != 1 || doc.Paths["/health"] == nil {
+		t.Errorf("unexpected paths: %v", doc.Paths)
+	}
+
+	res = app.Test(&Request{Method: "GET", Path: "/docs"})
+	if res.StatusCode != 200 || res.Header("Content-Type") != "text/html" {
+		t.Errorf("unexpected Swagger UI response: %d %s", res.StatusCode, res.Header("Content-Type"))
+	}
+}
@@ -0,0 +1,232 @@
+package ghast
+
+import (
+	"io/fs"
+	"net"
+	"time"
+)
+
+// Option configures the application's server. Options are passed to New:
+//
+//	app := ghast.New(ghast.WithHideBanner(), ghast.WithPrintRoutes())
+type Option func(*serverConfig)
+
+// WithHidePort hides the port when the listen address is logged at startup.
+func WithHidePort() Option {
+	return func(c *serverConfig) {
+		c.HidePort = true
+	}
+}
+
+// WithHideBanner suppresses the startup banner. The listen address is still logged.
+func WithHideBanner() Option {
+	return func(c *serverConfig) {
+		c.HideBanner = true
+	}
+}
+
+// WithAllowedHosts restricts the hosts the server answers for. Requests whose Host header (ignoring the port)
+// matches none of the hosts get 421 Misdirected Request. An entry such as "*.example.com" matches any
+// subdomain of example.com.
+//
+//	app := ghast.New(ghast.WithAllowedHosts("example.com", "*.example.com"))
+func WithAllowedHosts(hosts ...string) Option {
+	return func(c *serverConfig) {
+		c.AllowedHosts = append(c.AllowedHosts, hosts...)
+	}
+}
+
+// WithMaxRequestLineSize sets the maximum length of the request line ("GET /path?query HTTP/1.1") in bytes.
+// Clients that send a longer one get 414 URI Too Long and the connection is closed. The default is 8 KB.
+func WithMaxRequestLineSize(size int) Option {
+	return func(c *serverConfig) {
+		c.MaxRequestLineSize = size
+	}
+}
+
+// WithMaxHeaderBytes sets the maximum total size of a request's header lines in bytes, not counting the request
+// line. Clients that send more get 431 Request Header Fields Too Large and the connection is closed. The
+// default is 1 MB; zero or less removes the limit.
+func WithMaxHeaderBytes(size int) Option {
+	return func(c *serverConfig) {
+		c.MaxHeaderBytes = size
+	}
+}
+
+// WithMaxRequestBodySize sets the maximum size of a request body in bytes. Requests declaring a larger
+// Content-Length get 413 Content Too Large before their body is read, and the connection is closed. The default
+// is 10 MB; zero or less removes the limit.
+func WithMaxRequestBodySize(size int) Option {
+	return func(c *serverConfig) {
+		c.MaxRequestBodySize = size
+	}
+}
+
+// WithReadHeaderTimeout sets how long the server waits for the request line and headers of a request, and for a
+// new connection's first request, before answering 408 Request Timeout and closing the connection (default: 10
+// seconds). This stops slowloris clients, which trickle their headers in, from holding connections; zero
+// disables it.
+func WithReadHeaderTimeout(timeout time.Duration) Option {
+	return func(c *serverConfig) {
+		c.ReadHeaderTimeout = timeout
+	}
+}
+
+// WithReadTimeout sets how long the server waits for the body of a request once its headers are read, before
+// answering 408 Request Timeout and closing the connection (default: no limit).
+func WithReadTimeout(timeout time.Duration) Option {
+	return func(c *serverConfig) {
+		c.ReadTimeout = timeout
+	}
+}
+
+// WithRequestTimeout gives every request's handler timeout to start its response: the request context passes
+// its deadline then, and a handler that hasn't written anything by that point gets ErrHandlerTimeout from its
+// writes, while the client gets 503 Service Unavailable once the handler returns. Responses are not buffered, so
+// a handler should watch Request.Context to return in time; a response already under way can still be written
+// after the deadline. Event streams registered with SSE open before their handler runs, so the deadline doesn't
+// cancel their context. Routes that need a hard limit on the whole response can use WithTimeout.
+func WithRequestTimeout(timeout time.Duration) Option {
+	return func(c *serverConfig) {
+		c.RequestTimeout = timeout
+	}
+}
+
+// WithIdleTimeout sets how long a keep-alive connection may wait for its next request before the server closes
+// it (default: 2 minutes), freeing its MaxConnections slot or pool worker; zero lets idle connections stay open
+// until the client closes them.
+func WithIdleTimeout(timeout time.Duration) Option {
+	return func(c *serverConfig) {
+		c.IdleTimeout = timeout
+	}
+}
+
+// WithRouterOptions configures the application's root router, which serves the routes registered directly on
+// the app. Routers mounted with Route keep the options they were created with.
+//
+//	app := ghast.New(ghast.WithRouterOptions(ghast.RouterOptions{DisableMethodNotAllowed: true}))
+func WithRouterOptions(opts RouterOptions) Option {
+	return func(c *serverConfig) {
+		c.RouterOptions = opts
+	}
+}
+
+// WithDisableHTTP2 stops ListenTLS and ListenTLSConfig from offering HTTP/2, so every client is served over
+// HTTP/1.1.
+func WithDisableHTTP2() Option {
+	return func(c *serverConfig) {
+		c.DisableHTTP2 = true
+	}
+}
+
+// WithH2C enables HTTP/2 over cleartext TCP for clients with prior knowledge of HTTP/2, for internal traffic
+// between services and load balancers that speak HTTP/2 to their backends. Connections opening with the HTTP/2
+// preface are served over HTTP/2; other connections are served over HTTP/1.1 as usual. Clients without prior
+// knowledge can upgrade an HTTP/1.1 connection with "Upgrade: h2c" and an HTTP2-Settings header (RFC 7540
+// section 3.2): the request gets 101 Switching Protocols and its response is sent as the first HTTP/2 stream.
+// Only requests without a body are upgraded; others are answered over HTTP/1.1.
+func WithH2C() Option {
+	return func(c *serverConfig) {
+		c.H2C = true
+	}
+}
+
+// WithMaxConnections limits the server to max connections at once, keep-alive ones included. With
+// ConnectionLimitQueue the server stops accepting while at the limit, leaving new clients waiting until a
+// connection closes; with ConnectionLimitReject they are answered with 503 Service Unavailable straight away.
+// Keep-alive connections give up their slot once idle for the idle timeout (see WithIdleTimeout), which then
+// defaults to 2 minutes even if it was disabled.
+func WithMaxConnections(max int, policy ConnectionLimitPolicy) Option {
+	return func(c *serverConfig) {
+		c.MaxConnections = max
+		c.ConnectionLimitPolicy = policy
+	}
+}
+
+// WithWorkerPool serves connections with a fixed pool of size goroutines instead of a goroutine per connection,
+// keeping memory use predictable under connection floods. A worker serves one connection at a time, for as long
+// as it is kept alive, until it is idle for the idle timeout (see WithIdleTimeout, which then defaults to 2
+// minutes even if it was disabled); while all of them are busy the server stops accepting, and new clients wait
+// in the listen backlog.
+func WithWorkerPool(size int) Option {
+	return func(c *serverConfig) {
+		c.Workers = size
+	}
+}
+
+// WithBadRequestBody sets the body of the 400 Bad Request responses the server sends, before closing the
+// connection, to requests it can't parse or that are malformed, such as a JSON error document matching the rest
+// of an API:
+//
+//	ghast.WithBadRequestBody("application/json", `{"error":"bad request"}`)
+func WithBadRequestBody(contentType, body string) Option {
+	return func(c *serverConfig) {
+		c.BadRequestContentType = contentType
+		c.BadRequestBody = body
+	}
+}
+
+// WithUnixSocketMode sets the permissions of the socket file when listening on a Unix domain socket, such as
+// 0o660 to let a reverse proxy in the same group connect. By default the file gets the process's umask.
+func WithUnixSocketMode(mode fs.FileMode) Option {
+	return func(c *serverConfig) {
+		c.UnixSocketMode = mode
+	}
+}
+
+// WithProxyProtocol reads the PROXY protocol header (v1 or v2) that HAProxy and TCP load balancers such as AWS
+// NLB send ahead of each connection, so Request.ClientIP is the real client's address rather than the load
+// balancer's. Every connection must then open with a header, and those that don't are closed, so only enable
+// it when all traffic comes through such a load balancer. It applies to the listeners opened by Listen,
+// ListenTLS and ListenFastCGI.
+func WithProxyProtocol() Option {
+	return func(c *serverConfig) {
+		c.ProxyProtocol = true
+	}
+}
+
+// WithListenConfig sets the configuration used to open the listeners of Listen, ListenTLS and ListenFastCGI,
+// for tuning TCP keep-alive probes (KeepAlive and KeepAliveConfig) or setting other socket options through its
+// Control hook:
+//
+//	app := ghast.New(ghast.WithListenConfig(net.ListenConfig{
+//	    KeepAliveConfig: net.KeepAliveConfig{Enable: true, Idle: 30 * time.Second, Interval: 10 * time.Second, Count: 3},
+//	}))
+func WithListenConfig(lc net.ListenConfig) Option {
+	return func(c *serverConfig) {
+		c.ListenConfig = lc
+	}
+}
+
+// WithReusePort sets SO_REUSEPORT on TCP listeners, so several processes can listen on the same address and the
+// kernel spreads connections between them, as in multi-process deployments or while a new version starts up
+// next to the old one. Listening fails on platforms without SO_REUSEPORT, such as Windows.
+func WithReusePort() Option {
+	return func(c *serverConfig) {
+		c.ReusePort = true
+	}
+}
+
+// WithDisableNoDelay turns off TCP_NODELAY, which Go enables by default, on accepted TCP connections, letting
+// Nagle's algorithm coalesce small writes at the cost of latency.
+func WithDisableNoDelay() Option {
+	return func(c *serverConfig) {
+		c.DisableNoDelay = true
+	}
+}
+
+// WithGracefulShutdownTimeout sets how long Shutdown waits for the requests in flight to finish before
+// force-closing their connections, rounded up to whole seconds (default: 30 seconds). Zero waits for as long as
+// the context passed to Shutdown allows.
+func WithGracefulShutdownTimeout(timeout time.Duration) Option {
+	return func(c *serverConfig) {
+		c.GracefulShutdownTimeout = int((timeout + time.Second - 1) / time.Second)
+	}
+}
+
+// WithPrintRoutes prints a table of all registered routes (including grouped and mounted ones) at startup.
+func WithPrintRoutes() Option {
+	return func(c *serverConfig) {
+		c.PrintRoutes = true
+	}
+}
@@ -0,0 +1,59 @@
+package ghast
+
+import (
+	"io"
+	"sync"
+)
+
+// requestPool recycles Request structs, along with their Headers, Queries and Params maps, across the requests
+// served by the server, so keep-alive connections under load don't allocate a fresh set per request.
+var requestPool = sync.Pool{
+	New: func() any {
+		return &Request{
+			Headers: make(map[string]string),
+			Queries: make(map[string]string),
+			Params:  make(map[string]string),
+		}
+	},
+}
+
+// responseWriterPool recycles responseWriters and their header maps.
+var responseWriterPool = sync.Pool{
+	New: func() any {
+		return &responseWriter{headers: make(map[string]string)}
+	},
+}
+
+// acquireRequest returns an empty Request from the pool.
+func acquireRequest() *Request {
+	return requestPool.Get().(*Request)
+}
+
+// releaseRequest resets req and returns it to the pool. The request must not be used afterwards; handlers
+// that need a request beyond their own lifetime must use Request.Clone.
+func releaseRequest(req *Request) {
+	headers, queries, params := req.Headers, req.Queries, req.Params
+	clear(headers)
+	clear(queries)
+	clear(params)
+	*req = Request{Headers: headers, Queries: queries, Params: params}
+	requestPool.Put(req)
+}
+
+// acquireResponseWriter returns a responseWriter from the pool, reset to write to conn.
+func acquireResponseWriter(conn io.Writer) *responseWriter {
+	rw := responseWriterPool.Get().(*responseWriter)
+	rw.conn = conn
+	rw.statusCode = 200
+	rw.statusText = "OK"
+	rw.written = false
+	return rw
+}
+
+// releaseResponseWriter returns rw to the pool once its response has been finished.
+func releaseResponseWriter(rw *responseWriter) {
+	clear(rw.headers)
+	rw.conn = nil
+	rw.req = nil
+	responseWriterPool.Put(rw)
+}
@@ -0,0 +1,151 @@
+package ghast
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// proxyV1MaxLength is the maximum length of a PROXY protocol v1 header line, CRLF included.
+const proxyV1MaxLength = 107
+
+// proxyV2Signature opens every PROXY protocol v2 header.
+var proxyV2Signature = []byte("\r\n\r\n\x00\r\nQUIT\n")
+
+// errInvalidProxyHeader is returned when reading from a connection that didn't open with a valid PROXY protocol
+// header.
+var errInvalidProxyHeader = errors.New("ghast: invalid PROXY protocol header")
+
+// proxyListener is a net.Listener whose connections start with a PROXY protocol header, as sent by HAProxy and
+// TCP load balancers such as AWS NLB, giving the address of the client the load balancer accepted.
+type proxyListener struct {
+	net.Listener
+}
+
+func (l *proxyListener) Accept() (net.Conn, error) {
+	conn, err := l.Listener.Accept()
+	if err != nil {
+		return nil, err
+	}
+	return &proxyConn{Conn: conn}, nil
+}
+
+// proxyConn is a connection accepted by a proxyListener. Its header is read on the first Read or RemoteAddr
+// call rather than in Accept, so a slow client can't hold up the accept loop.
+type proxyConn struct {
+	net.Conn
+	once   sync.Once
+	reader *bufio.Reader // Reads the connection past the header
+	remote net.Addr      // Client address from the header, or nil to use the connection's own
+	err    error         // Error reading the header, returned by every Read
+}
+
+// readHeader reads the PROXY protocol header once.
+func (c *proxyConn) readHeader() {
+	c.once.Do(func() {
+		c.reader = bufio.NewReader(c.Conn)
+		c.remote, c.err = readProxyHeader(c.reader)
+	})
+}
+
+func (c *proxyConn) Read(p []byte) (int, error) {
+	c.readHeader()
+	if c.err != nil {
+		return 0, c.err
+	}
+	return c.reader.Read(p)
+}
+
+// RemoteAddr returns the client address given by the PROXY protocol header, or the address of the load balancer
+// for headers that carry none (v1 UNKNOWN and v2 LOCAL).
+func (c *proxyConn) RemoteAddr() net.Addr {
+	c.readHeader()
+	if c.remote != nil {
+		return c.remote
+	}
+	return c.Conn.RemoteAddr()
+}
+
+// readProxyHeader reads a PROXY protocol v1 or v2 header from r and returns the source address it carries, or
+// nil if it carries none.
+func readProxyHeader(r *bufio.Reader) (net.Addr, error) {
+	signature, err := r.Peek(len(proxyV2Signature))
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case bytes.Equal(signature, proxyV2Signature):
+		return readProxyV2Header(r)
+	case bytes.HasPrefix(signature, []byte("PROXY ")):
+		return readProxyV1Header(r)
+	}
+	return nil, errInvalidProxyHeader
+}
+
+// readProxyV1Header reads a human-readable header, such as "PROXY TCP4 203.0.113.7 10.0.0.1 56324 443\r\n".
+func readProxyV1Header(r *bufio.Reader) (net.Addr, error) {
+	line, err := readLine(r, proxyV1MaxLength)
+	if errors.Is(err, errLineTooLong) {
+		return nil, errInvalidProxyHeader
+	}
+	if err != nil {
+		return nil, err
+	}
+	line, ok := strings.CutSuffix(line, "\r\n")
+	if !ok {
+		return nil, errInvalidProxyHeader
+	}
+	fields := strings.Split(line, " ")
+	if len(fields) >= 2 && fields[1] == "UNKNOWN" {
+		return nil, nil
+	}
+	if len(fields) != 6 || fields[1] != "TCP4" && fields[1] != "TCP6" {
+		return nil, errInvalidProxyHeader
+	}
+	ip := net.ParseIP(fields[2])
+	port, err := strconv.ParseUint(fields[4], 10, 16)
+	if ip == nil || err != nil || (ip.To4() != nil) != (fields[1] == "TCP4") {
+		return nil, errInvalidProxyHeader
+	}
+	return &net.TCPAddr{IP: ip, Port: int(port)}, nil
+}
+
+// readProxyV2Header reads a binary header: the signature, a version and command byte, an address family and
+// transport byte, the length of the rest, then the addresses and optional TLVs, which are skipped.
+func readProxyV2Header(r *bufio.Reader) (net.Addr, error) {
+	var header [16]byte
+	if _, err := io.ReadFull(r, header[:]); err != nil {
+		return nil, err
+	}
+	version, command, family := header[12]>>4, header[12]&0x0f, header[13]>>4
+	if version != 2 || command > 1 {
+		return nil, errInvalidProxyHeader
+	}
+	payload := make([]byte, binary.BigEndian.Uint16(header[14:]))
+	if _, err := io.ReadFull(r, payload); err != nil {
+		return nil, err
+	}
+	if command == 0 {
+		return nil, nil // LOCAL: a health check from the load balancer itself
+	}
+
+	switch family {
+	case 1: // IPv4: source and destination addresses, then ports
+		if len(payload) < 12 {
+			return nil, errInvalidProxyHeader
+		}
+		return &net.TCPAddr{IP: net.IP(payload[0:4]), Port: int(binary.BigEndian.Uint16(payload[8:]))}, nil
+	case 2: // IPv6
+		if len(payload) < 36 {
+			return nil, errInvalidProxyHeader
+		}
+		return &net.TCPAddr{IP: net.IP(payload[0:16]), Port: int(binary.BigEndian.Uint16(payload[32:]))}, nil
+	}
+	return nil, nil // Unspecified or Unix addresses
+}
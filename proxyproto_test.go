@@ -0,0 +1,84 @@
+package ghast
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestReadProxyHeader tests parsing PROXY protocol v1 and v2 headers
+func TestReadProxyHeader(t *testing.T) {
+	v2 := func(command, family byte, payload string) string {
+		return string(proxyV2Signature) + string([]byte{0x20 | command, family<<4 | 1, 0, byte(len(payload))}) + payload
+	}
+	tests := []struct {
+		name, header, addr string
+		wantErr            bool
+	}{
+		{"v1 tcp4", "PROXY TCP4 203.0.113.7 10.0.0.1 56324 443\r\n", "203.0.113.7:56324", false},
+		{"v1 tcp6", "PROXY TCP6 2001:db8::1 2001:db8::2 56324 443\r\n", "[2001:db8::1]:56324", false},
+		{"v1 unknown", "PROXY UNKNOWN\r\n", "", false},
+		{"v1 family mismatch", "PROXY TCP4 2001:db8::1 10.0.0.1 56324 443\r\n", "", true},
+		{"v1 bad port", "PROXY TCP4 203.0.113.7 10.0.0.1 99999 443\r\n", "", true},
+		{"v1 missing fields", "PROXY TCP4 203.0.113.7\r\n", "", true},
+		{"v2 ipv4", v2(1, 1, "\xcb\x00\x71\x07\x0a\x00\x00\x01\xdc\x04\x01\xbb"), "203.0.113.7:56324", false},
+		{"v2 ipv4 with tlvs", v2(1, 1, "\xcb\x00\x71\x07\x0a\x00\x00\x01\xdc\x04\x01\xbb\x04\x00\x01\x00"), "203.0.113.7:56324", false},
+		{"v2 local", v2(0, 0, ""), "", false},
+		{"v2 short payload", v2(1, 1, "\xcb\x00"), "", true},
+		{"no header", "GET / HTTP/1.1\r\n\r\n", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reader := bufio.NewReader(strings.NewReader(tt.header + "GET /"))
+			addr, err := readProxyHeader(reader)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("expected an error, got address %v", addr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			got := ""
+			if addr != nil {
+				got = addr.String()
+			}
+			if got != tt.addr {
+				t.Errorf("expected address %q, got %q", tt.addr, got)
+			}
+			if rest, _ := io.ReadAll(reader); string(rest) != "GET /" {
+				t.Errorf("expected the header to be consumed exactly, left %q", rest)
+			}
+		})
+	}
+}
+
+// TestProxyProtocol tests that ClientIP comes from the PROXY protocol header, and that connections without one
+// are closed
+func TestProxyProtocol(t *testing.T) {
+	app := New(WithProxyProtocol())
+	app.Get("/", HandlerFunc(func(w ResponseWriter, r *Request) { w.Plain(200, r.ClientIP) }))
+	addr := startTestApp(t, app)
+	defer app.Shutdown(context.Background())
+
+	response := rawRoundTrip(t, addr, "PROXY TCP4 203.0.113.7 10.0.0.1 56324 443\r\nGET / HTTP/1.1\r\nHost: localhost\r\nConnection: close\r\n\r\n")
+	if !strings.HasPrefix(response, "HTTP/1.1 200") || !strings.HasSuffix(response, "203.0.113.7") {
+		t.Errorf("expected the client address from the header, got %q", response)
+	}
+
+	conn, err := net.Dial("tcp", addr)
+	if err != nil {
+		t.Fatalf("dial failed: %v", err)
+	}
+	defer conn.Close()
+	conn.Write([]byte("GET / HTTP/1.1\r\nHost: localhost\r\n\r\n"))
+	conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	if data, err := io.ReadAll(conn); len(data) != 0 || err != nil {
+		t.Errorf("expected a connection without a header to be closed unanswered, got %q, %v", data, err)
+	}
+}
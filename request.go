@@ -1,8 +1,12 @@
 package ghast
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"maps"
+	"net/textproto"
 	"slices"
 	"strings"
 )
@@ -23,14 +27,65 @@ const (
 
 // Request represents an HTTP request with parsed components.
 type Request struct {
-	Method  string            // HTTP method (GET, POST, etc.)
-	Path    string            // URL path (without query string)
-	Headers map[string]string // HTTP headers
-	Body    string            // Request body as string
-	Version string            // HTTP version (e.g., "HTTP/1.1")
-	Params  map[string]string // Route parameters (e.g., from path variables)
-	Queries map[string]string // Query parameters
+	Method   string            // HTTP method (GET, POST, etc.)
+	Path     string            // URL path (without query string)
+	Headers  map[string]string // HTTP headers
+	Body     []byte            // Request body as read from the connection
+	Version  string            // HTTP version (e.g., "HTTP/1.1")
+	Params   map[string]string // Route parameters (e.g., from path variables)
+	Queries  map[string]string // Query parameters
 	ClientIP string            // Client IP address (to be populated by server)
+
+	app       *Ghast          // Application handling the request, used to resolve shared state
+	ctx       context.Context // Request context, canceled when the connection finishes serving the request
+	mountPath string          // Prefixes stripped from Path by the mounts the request passed through
+	route     *RouteInfo      // Route matched by the router, set before its middleware and handler run
+	unclean   bool            // Whether Path was normalized from a non-normalized path sent by the client
+	rawQuery  string          // Query string as sent by the client, without its "?"
+}
+
+// Context returns the request's context. It is canceled once the server has finished serving the request,
+// and never nil: requests created outside the server default to context.Background.
+func (r *Request) Context() context.Context {
+	if r.ctx == nil {
+		return context.Background()
+	}
+	return r.ctx
+}
+
+// Route returns the description of the route matched for the request, including the metadata attached with
+// Router.Meta and Router.Tag. It reports false before routing, so middleware added with Ghast.Use sees no route;
+// middleware added to the router or the route does.
+func (r *Request) Route() (RouteInfo, bool) {
+	if r.route == nil {
+		return RouteInfo{}, false
+	}
+	return *r.route, true
+}
+
+// WithContext returns a shallow copy of the request with its context replaced by ctx.
+func (r *Request) WithContext(ctx context.Context) *Request {
+	if ctx == nil {
+		panic("ghast: nil context")
+	}
+	r2 := *r
+	r2.ctx = ctx
+	return &r2
+}
+
+// Clone returns a deep copy of the request. Requests are recycled once their handler returns, so a handler that
+// hands the request to a goroutine or keeps it for later must pass a clone instead.
+//
+// Example:
+//
+//	r2 := r.Clone()
+//	go audit(r2)
+func (r *Request) Clone() *Request {
+	r2 := *r
+	r2.Headers = maps.Clone(r.Headers)
+	r2.Queries = maps.Clone(r.Queries)
+	r2.Params = maps.Clone(r.Params)
+	return &r2
 }
 
 // Query retrieves a query parameter by key. Returns empty string if not found.
@@ -51,23 +106,29 @@ func (r *Request) Param(key string) string {
 
 // JSON unmarshals the request body as JSON into the provided value. To be replaced by a common body command and content type handling in the future.
 func (r *Request) JSON(v any) error {
-	return json.Unmarshal([]byte(r.Body), v)
+	return json.Unmarshal(r.Body, v)
 }
 
 // GetHeader retrieves a header value (case-insensitive).
 // Returns empty string if header not found.
+//
+// Header names are canonicalized when a request is parsed (e.g. "content-type" is stored as "Content-Type"),
+// so this is a single map lookup. Keys of hand-built requests are also matched exactly as given.
 func (r *Request) GetHeader(key string) string {
-	// Try exact match first
-	if val, ok := r.Headers[key]; ok {
+	if val, ok := r.Headers[textproto.CanonicalMIMEHeaderKey(key)]; ok {
 		return val
 	}
-	// Try case-insensitive match
-	for k, v := range r.Headers {
-		if strings.EqualFold(k, key) {
-			return v
+	return r.Headers[key]
+}
+
+// canonicalizeHeaders rewrites the keys of headers in canonical form.
+func canonicalizeHeaders(headers map[string]string) {
+	for key, value := range headers {
+		if canonical := textproto.CanonicalMIMEHeaderKey(key); canonical != key {
+			delete(headers, key)
+			headers[canonical] = value
 		}
 	}
-	return ""
 }
 
 // ContentType returns the Content-Type header value.
@@ -76,7 +137,8 @@ func (r *Request) ContentType() string {
 }
 
 // ParseRequest parses a raw HTTP request string into a Request struct.
-// It extracts the method, path, version, headers, and query parameters.
+// It extracts the method, path, version, headers, and query parameters. The input is the request line and
+// header lines joined by CRLF, without the terminating blank line.
 //
 // Note: Body parsing is handled separately by the server due to TCP/stream considerations.
 //
@@ -85,45 +147,131 @@ func (r *Request) ContentType() string {
 //   - Add support for duplicate headers and query parameters.
 //   - Add validation for header names and values.
 //   - Add support for URL decoding of query parameters.
-func parseRequest(rawRequest string) (*Request, error) {
+func ParseRequest(rawRequest string) (*Request, error) {
+	req := &Request{}
+	if err := parseRequestInto(req, rawRequest); err != nil {
+		return nil, err
+	}
+	return req, nil
+}
+
+// parseRequestInto parses a raw HTTP request into req, reusing its Headers and Queries maps when present.
+// Params are left for the router to populate when matching dynamic routes, and the body is read by the server.
+func parseRequestInto(req *Request, rawRequest string) error {
 	lines := strings.Split(rawRequest, CRLF)
 
 	if len(lines) < 1 {
-		return nil, fmt.Errorf("invalid request: no lines found")
+		return fmt.Errorf("invalid request: no lines found")
 	}
 
 	method, path, version, err := parseRequestLine(lines)
 	if err != nil {
-		return nil, err
+		return err
 	}
 
-	headers, err := parseHeaders(lines[1:])
-	if err != nil {
-		return nil, err
+	if req.Headers == nil {
+		req.Headers = make(map[string]string)
+	}
+	if err := parseHeadersInto(lines[1:], req.Headers); err != nil {
+		return err
+	}
+	if isAbsoluteTarget(path) {
+		// The authority of an absolute-form target replaces the Host header (RFC 9112 section 3.2.2)
+		var authority string
+		if authority, path, err = splitAbsoluteTarget(path); err != nil {
+			return err
+		}
+		req.Headers["Host"] = authority
 	}
 
-	var queries map[string]string
-	if strings.Contains(path, "?") {
-		var err error
-		queries, err = parseParams(strings.Split(path, "?")[1])
-		if err != nil {
-			return nil, err
+	if path, rawQuery, found := strings.Cut(path, "?"); found {
+		if req.Queries == nil {
+			req.Queries = make(map[string]string)
+		}
+		rawQuery, _, _ = strings.Cut(rawQuery, "?")
+		if err := parseParamsInto(rawQuery, req.Queries); err != nil {
+			return err
 		}
-		path = strings.Split(path, "?")[0] // Strip query string from path for routing
+		req.rawQuery = rawQuery
+		req.Path = path // Strip query string from path for routing
+	} else {
+		req.Path = path
+	}
+	if err = req.cleanPath(); err != nil {
+		return err
+	}
+
+	req.Method = method
+	req.Version = version
+	return nil
+}
+
+// isAbsoluteTarget reports whether a request target is in absolute form (e.g. "http://example.com/path"), as
+// sent to forward proxies.
+func isAbsoluteTarget(target string) bool {
+	scheme, _, found := strings.Cut(target, "://")
+	return found && (strings.EqualFold(scheme, "http") || strings.EqualFold(scheme, "https"))
+}
+
+// splitAbsoluteTarget splits an absolute-form target into its authority and origin-form path, which keeps the
+// query string and defaults to "/".
+func splitAbsoluteTarget(target string) (authority, path string, err error) {
+	_, rest, _ := strings.Cut(target, "://")
+	end := strings.IndexAny(rest, "/?")
+	if end < 0 {
+		end = len(rest)
+	}
+	authority, path = rest[:end], rest[end:]
+	if authority == "" || strings.Contains(authority, "@") {
+		return "", "", fmt.Errorf("invalid request line: invalid authority in request target %q", target)
+	}
+	if !strings.HasPrefix(path, "/") {
+		path = "/" + path
 	}
+	return authority, path, nil
+}
 
-	var params map[string]string // Params will be populated later by the router when matching dynamic routes
+// errPathTraversal is returned for request paths whose ".." segments climb above the root.
+var errPathTraversal = errors.New("invalid request path: traverses above the root")
 
-	req := &Request{
-		Method:  method,
-		Path:    path,
-		Version: version,
-		Headers: headers,
-		Queries: queries,
-		Params:  params,
-		Body:    "", // Body will be populated later if Content-Length is present
+// cleanPath normalizes the request's path with cleanRequestPath, remembering whether it had to be changed.
+func (r *Request) cleanPath() error {
+	cleaned, err := cleanRequestPath(r.Path)
+	if err != nil {
+		return err
 	}
-	return req, nil
+	r.unclean = r.unclean || cleaned != r.Path
+	r.Path = cleaned
+	return nil
+}
+
+// cleanRequestPath resolves "." and ".." segments and collapses duplicate slashes, so every middleware and
+// router sees the same path for a resource (e.g. "/a//b/../c" becomes "/a/c"). A trailing slash is kept. Paths
+// that climb above the root are rejected with errPathTraversal rather than clamped to it.
+func cleanRequestPath(p string) (string, error) {
+	if p == "*" || (!strings.Contains(p, "//") && !strings.Contains(p, "/.")) {
+		return p, nil // Fast path: already clean
+	}
+
+	segments := make([]string, 0, strings.Count(p, "/"))
+	for segment := range strings.SplitSeq(p, "/") {
+		switch segment {
+		case "", ".":
+		case "..":
+			if len(segments) == 0 {
+				return "", errPathTraversal
+			}
+			segments = segments[:len(segments)-1]
+		default:
+			segments = append(segments, segment)
+		}
+	}
+
+	cleaned := "/" + strings.Join(segments, "/")
+	if len(segments) > 0 && (strings.HasSuffix(p, "/") || strings.HasSuffix(p, "/.") || strings.HasSuffix(p, "/..")) {
+		cleaned += "/"
+	}
+	return cleaned, nil
 }
 
 // parseRequestLine parses an HTTP request line (e.g., "GET /index.html HTTP/1.1") into method, path, and version.
@@ -148,11 +296,20 @@ func parseRequestLine(lines []string) (method, path, version string, err error)
 	if !isValidMethod {
 		return "", "", "", fmt.Errorf("invalid request line: unknown method %s", method)
 	}
+	if path == "*" && method != OPTIONS {
+		return "", "", "", fmt.Errorf("invalid request line: asterisk target is only valid for OPTIONS")
+	}
+	if !strings.HasPrefix(path, "/") && path != "*" && !isAbsoluteTarget(path) {
+		return "", "", "", fmt.Errorf("invalid request line: invalid request target %q", path)
+	}
+	if !strings.HasPrefix(version, "HTTP/") {
+		return "", "", "", fmt.Errorf("invalid request line: invalid version %q", version)
+	}
 
 	return method, path, version, nil
 }
 
-// parseHeaders parses HTTP request header lines into a map of header names to values.
+// parseHeaders parses HTTP request header lines into a map of canonical header names (e.g. "Content-Type") to values.
 //
 // TODO:
 //   - Add support for handling duplicate headers.
@@ -160,21 +317,39 @@ func parseRequestLine(lines []string) (method, path, version string, err error)
 //   - Add support for handling different line endings.
 func parseHeaders(lines []string) (map[string]string, error) {
 	headers := make(map[string]string)
+	if err := parseHeadersInto(lines, headers); err != nil {
+		return nil, err
+	}
+	return headers, nil
+}
+
+// parseHeadersInto parses HTTP request header lines into the given map.
+func parseHeadersInto(lines []string, headers map[string]string) error {
 	for _, line := range lines {
 		if line == "" {
 			break // End of headers
 		}
+		if line[0] == ' ' || line[0] == '\t' {
+			// Obsolete line folding (RFC 9112 section 5.2): rejected rather than unfolded, since peers that
+			// unfold differently could disagree on header values
+			return fmt.Errorf("invalid header line: obsolete line folding: %q", line)
+		}
 		parts := strings.SplitN(line, ": ", 2)
 		if len(parts) != 2 {
-			return nil, fmt.Errorf("invalid header line: %s", line)
+			return fmt.Errorf("invalid header line: %s", line)
 		}
 
 		if !isValidHeaderName(parts[0]) || !isValidHeaderValue(parts[1]) {
-			return nil, fmt.Errorf("invalid header line: %s", line)
+			return fmt.Errorf("invalid header line: %s", line)
 		}
-		headers[parts[0]] = parts[1]
+		key := textproto.CanonicalMIMEHeaderKey(parts[0])
+		if previous, found := headers[key]; found && key == "Content-Length" && previous != parts[1] {
+			// Peers that pick different lengths disagree on where the next request starts
+			return fmt.Errorf("invalid headers: conflicting Content-Length values %q and %q", previous, parts[1])
+		}
+		headers[key] = parts[1]
 	}
-	return headers, nil
+	return nil
 }
 
 // parseParams parses a query parameter string (e.g., "key1=value1&key2=value2") into a map of key-value pairs.
@@ -185,15 +360,23 @@ func parseHeaders(lines []string) (map[string]string, error) {
 //   - Add validation for query parameter keys and values.
 func parseParams(paramString string) (map[string]string, error) {
 	params := make(map[string]string)
+	if err := parseParamsInto(paramString, params); err != nil {
+		return nil, err
+	}
+	return params, nil
+}
+
+// parseParamsInto parses a query parameter string into the given map.
+func parseParamsInto(paramString string, params map[string]string) error {
 	pairs := strings.SplitSeq(paramString, "&")
 	for pair := range pairs {
 		kv := strings.SplitN(pair, "=", 2)
 		if len(kv) != 2 {
-			return nil, fmt.Errorf("invalid query parameter: %s", pair)
+			return fmt.Errorf("invalid query parameter: %s", pair)
 		}
 		params[kv[0]] = kv[1]
 	}
-	return params, nil
+	return nil
 }
 
 func isValidHeaderName(name string) bool {
@@ -0,0 +1,63 @@
+package ghast
+
+import "fmt"
+
+// The interfaces below are the actions a controller passed to Router.Resource can implement. A controller
+// implements whichever actions the resource supports; routes are only registered for those.
+type (
+	// ResourceIndexer lists a resource: GET /path.
+	ResourceIndexer interface {
+		Index(w ResponseWriter, r *Request)
+	}
+
+	// ResourceShower shows one item of a resource: GET /path/:id.
+	ResourceShower interface {
+		Show(w ResponseWriter, r *Request)
+	}
+
+	// ResourceCreator creates an item of a resource: POST /path.
+	ResourceCreator interface {
+		Create(w ResponseWriter, r *Request)
+	}
+
+	// ResourceUpdater updates one item of a resource: PUT /path/:id and PATCH /path/:id.
+	ResourceUpdater interface {
+		Update(w ResponseWriter, r *Request)
+	}
+
+	// ResourceDestroyer deletes one item of a resource: DELETE /path/:id.
+	ResourceDestroyer interface {
+		Destroy(w ResponseWriter, r *Request)
+	}
+)
+
+// registerResource registers the routes for the actions controller implements under path, through handle.
+// It panics if controller implements none of them.
+func registerResource(handle func(method, path string, handler Handler, middlewares ...Middleware), path string, controller any, middlewares []Middleware) {
+	item := joinPaths(path, ":id")
+	registered := false
+	if c, ok := controller.(ResourceIndexer); ok {
+		handle(GET, path, HandlerFunc(c.Index), middlewares...)
+		registered = true
+	}
+	if c, ok := controller.(ResourceCreator); ok {
+		handle(POST, path, HandlerFunc(c.Create), middlewares...)
+		registered = true
+	}
+	if c, ok := controller.(ResourceShower); ok {
+		handle(GET, item, HandlerFunc(c.Show), middlewares...)
+		registered = true
+	}
+	if c, ok := controller.(ResourceUpdater); ok {
+		handle(PUT, item, HandlerFunc(c.Update), middlewares...)
+		handle(PATCH, item, HandlerFunc(c.Update), middlewares...)
+		registered = true
+	}
+	if c, ok := controller.(ResourceDestroyer); ok {
+		handle(DELETE, item, HandlerFunc(c.Destroy), middlewares...)
+		registered = true
+	}
+	if !registered {
+		panic(fmt.Sprintf("ghast: resource %q: %T has none of the Index, Show, Create, Update and Destroy methods", path, controller))
+	}
+}
@@ -1,10 +1,13 @@
 package ghast
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
-	"net"
+	"io"
+	"strconv"
 	"strings"
+	"sync"
 )
 
 // ResponseWriter interface for constructing and sending HTTP responses.
@@ -26,19 +29,50 @@ type ResponseWriter interface {
 	HTML(statusCode int, html string) error // HTML sends an HTML response with the given status code.
 
 	Plain(statusCode int, text string) error // Plain sends a plain text response with the given status code.
+
+	Render(statusCode int, name string, data any) error // Render renders a view with the application's Renderer and sends it as HTML.
+}
+
+// Flusher is implemented by ResponseWriters that can send buffered data to the client immediately, which
+// streaming handlers (such as Server-Sent Events) use to deliver each chunk as it is written.
+type Flusher interface {
+	Flush() error // Flush writes the status line and headers if needed and sends any buffered data.
+}
+
+// Response is a parsed HTTP response, as returned by Ghast.Test.
+type Response struct {
+	StatusCode int               // HTTP status code (e.g., 200)
+	Status     string            // Status line text after the version (e.g., "200 OK")
+	Headers    map[string]string // Response headers as written on the wire
+	Body       string            // Response body as string
+}
+
+// Header retrieves a response header value (case-insensitive).
+// Returns empty string if header not found.
+func (r *Response) Header(key string) string {
+	if val, ok := r.Headers[key]; ok {
+		return val
+	}
+	for k, v := range r.Headers {
+		if strings.EqualFold(k, key) {
+			return v
+		}
+	}
+	return ""
 }
 
 // responseWriter implements ResponseWriter interface.
 type responseWriter struct {
-	conn       net.Conn
+	conn       io.Writer
 	headers    map[string]string
 	statusCode int
 	statusText string
-	written    bool // Tracks whether status/headers have been written
+	written    bool     // Tracks whether status/headers have been written
+	req        *Request // Request being answered, used to find the application's Renderer
 }
 
 // NewResponseWriter creates a new ResponseWriter for the given connection.
-func newResponseWriter(conn net.Conn) ResponseWriter {
+func newResponseWriter(conn io.Writer) ResponseWriter {
 	return &responseWriter{
 		conn:       conn,
 		headers:    make(map[string]string),
@@ -62,12 +96,29 @@ func (rw *responseWriter) Status(statusCode int) ResponseWriter {
 	return rw
 }
 
-// SetHeader sets a response header and returns self for chaining.
+// SetHeader sets a response header and returns self for chaining. Line breaks in the key or value are replaced
+// with spaces, so values taken from the request can't inject headers of their own.
 func (rw *responseWriter) SetHeader(key, value string) ResponseWriter {
-	rw.headers[key] = value
+	rw.headers[headerSafe(key)] = headerSafe(value)
 	return rw
 }
 
+// headerNewlineToSpace replaces the line breaks of header keys and values with spaces.
+var headerNewlineToSpace = strings.NewReplacer("\r", " ", "\n", " ")
+
+// headerSafe returns s with any CR and LF replaced by spaces, as net/http does when writing headers.
+func headerSafe(s string) string {
+	if !strings.ContainsAny(s, "\r\n") {
+		return s
+	}
+	return headerNewlineToSpace.Replace(s)
+}
+
+// responseStarted reports whether the status line and headers have been written.
+func (rw *responseWriter) responseStarted() bool {
+	return rw.written
+}
+
 // WriteHeader sets the HTTP status code (non-chainable, called automatically by Write).
 // @internal This is not meant to be called directly by handlers. Use Status() for chaining instead.
 func (rw *responseWriter) writeHeader(statusCode int) {
@@ -80,10 +131,19 @@ func (rw *responseWriter) writeHeader(statusCode int) {
 // Write writes data to the response body.
 // @internal - This is called by Send() and SendString() to write the response body. It automatically writes the status line and headers if they haven't been written yet.
 func (rw *responseWriter) write(data []byte) (int, error) {
+	head := rw.req != nil && rw.req.Method == HEAD
 	if !rw.written {
+		if _, found := rw.lookupHeader("Content-Length"); !found && bodyAllowed(rw.statusCode) && !head {
+			// Without a length, the end of the body is marked by closing the connection
+			rw.SetHeader("Connection", "close")
+		}
 		rw.writeStatusAndHeaders()
 		rw.written = true
 	}
+	if head {
+		// Responses to HEAD carry the headers of the GET response but never a body
+		return len(data), nil
+	}
 	return rw.conn.Write(data)
 }
 
@@ -99,36 +159,100 @@ func (rw *responseWriter) SendString(s string) (int, error) {
 
 // JSON marshals data as JSON and sends it with application/json content-type.
 func (rw *responseWriter) JSON(statusCode int, data interface{}) error {
-	rw.Status(statusCode)
-	rw.SetHeader("Content-Type", "application/json")
+	return rw.encodeJSON(statusCode, data, "")
+}
 
-	jsonData, err := json.Marshal(data)
-	if err != nil {
-		return err
+// JSONPretty marshals data as pretty-printed JSON.
+func (rw *responseWriter) JSONPretty(statusCode int, data interface{}) error {
+	return rw.encodeJSON(statusCode, data, "  ")
+}
+
+// encodeJSON encodes data with a pooled json.Encoder that writes through a jsonWriter, so payloads up to
+// jsonLengthThreshold get a Content-Length and larger ones go straight to the connection instead of being
+// copied into a buffer first.
+func (rw *responseWriter) encodeJSON(statusCode int, data interface{}, indent string) error {
+	jw := jsonWriterPool.Get().(*jsonWriter)
+	defer jw.release()
+	jw.rw, jw.statusCode = rw, statusCode
+
+	jw.enc.SetIndent("", indent)
+	if err := jw.enc.Encode(data); err != nil {
+		return err // Encoding fails before anything is written, so the handler can still send an error response
 	}
+	return jw.finish()
+}
 
-	_, err = rw.write(jsonData)
-	return err
+// jsonLengthThreshold is the largest JSON payload buffered to compute a Content-Length. Larger payloads are
+// streamed without one, so the connection is closed to end the response, as for any body of unknown length.
+const jsonLengthThreshold = 64 << 10
+
+// jsonWriterPool recycles jsonWriters and their encoders and buffers across JSON responses.
+var jsonWriterPool = sync.Pool{
+	New: func() any {
+		jw := &jsonWriter{}
+		jw.enc = json.NewEncoder(jw)
+		return jw
+	},
 }
 
-// JSONPretty marshals data as pretty-printed JSON.
-func (rw *responseWriter) JSONPretty(statusCode int, data interface{}) error {
-	rw.Status(statusCode)
-	rw.SetHeader("Content-Type", "application/json")
+// jsonWriter receives the output of a json.Encoder for a responseWriter. It buffers up to jsonLengthThreshold
+// bytes and writes straight through to the connection once the payload grows past it.
+type jsonWriter struct {
+	enc        *json.Encoder
+	buf        bytes.Buffer
+	rw         *responseWriter
+	statusCode int
+	streaming  bool
+}
 
-	jsonData, err := json.MarshalIndent(data, "", "  ")
-	if err != nil {
-		return err
+// Write buffers encoder output, or streams it once the payload exceeds jsonLengthThreshold.
+func (jw *jsonWriter) Write(p []byte) (int, error) {
+	if !jw.streaming {
+		if jw.buf.Len()+len(p) <= jsonLengthThreshold {
+			return jw.buf.Write(p)
+		}
+		jw.streaming = true
+		jw.writeHeader()
+		if _, err := jw.rw.write(jw.buf.Bytes()); err != nil {
+			return 0, err
+		}
+		jw.buf.Reset()
 	}
+	return jw.rw.write(p)
+}
 
-	_, err = rw.write(jsonData)
+// finish writes a buffered payload with its Content-Length. The encoder's trailing newline is dropped so the
+// body matches json.Marshal output; streamed payloads keep it.
+func (jw *jsonWriter) finish() error {
+	if jw.streaming {
+		return nil
+	}
+	body := bytes.TrimSuffix(jw.buf.Bytes(), []byte("\n"))
+	jw.writeHeader()
+	jw.rw.SetHeader("Content-Length", strconv.Itoa(len(body)))
+	_, err := jw.rw.write(body)
 	return err
 }
 
+func (jw *jsonWriter) writeHeader() {
+	jw.rw.Status(jw.statusCode)
+	jw.rw.SetHeader("Content-Type", "application/json")
+}
+
+// release returns the writer to the pool. Its buffer never grows past jsonLengthThreshold, so one large
+// response doesn't pin its memory for the life of the process.
+func (jw *jsonWriter) release() {
+	jw.buf.Reset()
+	jw.rw = nil
+	jw.streaming = false
+	jsonWriterPool.Put(jw)
+}
+
 // HTML sends an HTML response with the given status code.
 func (rw *responseWriter) HTML(statusCode int, html string) error {
 	rw.Status(statusCode)
 	rw.SetHeader("Content-Type", "text/html")
+	rw.SetHeader("Content-Length", strconv.Itoa(len(html)))
 	_, err := rw.write([]byte(html))
 	return err
 }
@@ -136,10 +260,73 @@ func (rw *responseWriter) HTML(statusCode int, html string) error {
 func (rw *responseWriter) Plain(statusCode int, text string) error {
 	rw.Status(statusCode)
 	rw.SetHeader("Content-Type", "text/plain")
+	rw.SetHeader("Content-Length", strconv.Itoa(len(text)))
 	_, err := rw.write([]byte(text))
 	return err
 }
 
+// Render renders the named view with the Renderer of the application handling the request and sends it as HTML.
+// Nothing is written if rendering fails, so the error can still be turned into an error response.
+func (rw *responseWriter) Render(statusCode int, name string, data any) error {
+	body, err := renderView(rendererFor(rw.req), name, data)
+	if err != nil {
+		return err
+	}
+	rw.Status(statusCode)
+	rw.SetHeader("Content-Type", "text/html; charset=utf-8")
+	rw.SetHeader("Content-Length", strconv.Itoa(len(body)))
+	_, err = rw.write(body)
+	return err
+}
+
+// Flush writes the status line and headers if they have not been sent yet. Body writes are not buffered,
+// so there is nothing else to flush.
+func (rw *responseWriter) Flush() error {
+	if !rw.written {
+		rw.writeStatusAndHeaders()
+		rw.written = true
+	}
+	return nil
+}
+
+// finish writes the status line and headers if the handler never wrote a body, so responses such as
+// a bare w.Status(204) still reach the client.
+// @internal - This is called by the server once the request has been fully handled.
+func (rw *responseWriter) finish() {
+	if !rw.written {
+		if _, found := rw.lookupHeader("Content-Length"); !found && bodyAllowed(rw.statusCode) && !responseCloses(rw.headers) {
+			rw.SetHeader("Content-Length", "0") // Lets the client reuse the connection for its next request
+		}
+		rw.writeStatusAndHeaders()
+		rw.written = true
+	}
+}
+
+// lookupHeader returns the value of a response header, matching its name case-insensitively since handlers
+// can set headers with any casing.
+func (rw *responseWriter) lookupHeader(key string) (string, bool) {
+	return lookupHeader(rw.headers, key)
+}
+
+// lookupHeader returns the value of the header named key, matched case-insensitively.
+func lookupHeader(headers map[string]string, key string) (string, bool) {
+	if value, found := headers[key]; found {
+		return value, true
+	}
+	for k, value := range headers {
+		if strings.EqualFold(k, key) {
+			return value, true
+		}
+	}
+	return "", false
+}
+
+// bodyAllowed reports whether a response with the status code can carry a body, and so needs its length
+// delimited (RFC 9112 section 6.3).
+func bodyAllowed(statusCode int) bool {
+	return statusCode >= 200 && statusCode != 204 && statusCode != 304
+}
+
 // writeStatusAndHeaders writes the HTTP status line and headers.
 func (rw *responseWriter) writeStatusAndHeaders() {
 	var buf strings.Builder
@@ -150,3 +337,38 @@ func (rw *responseWriter) writeStatusAndHeaders() {
 	buf.WriteString("\r\n")
 	rw.conn.Write([]byte(buf.String()))
 }
+
+// parseResponse parses raw HTTP/1.1 response bytes, as produced by responseWriter, into a Response.
+func parseResponse(raw []byte) (*Response, error) {
+	head, body, found := bytes.Cut(raw, []byte(CRLF+CRLF))
+	if !found {
+		return nil, fmt.Errorf("invalid response: missing header terminator")
+	}
+
+	lines := strings.Split(string(head), CRLF)
+	_, status, found := strings.Cut(lines[0], " ")
+	if !found {
+		return nil, fmt.Errorf("invalid status line: %s", lines[0])
+	}
+	code, _, _ := strings.Cut(status, " ")
+	statusCode, err := strconv.Atoi(code)
+	if err != nil {
+		return nil, fmt.Errorf("invalid status code: %s", code)
+	}
+
+	headers := make(map[string]string)
+	for _, line := range lines[1:] {
+		key, value, found := strings.Cut(line, ": ")
+		if !found {
+			return nil, fmt.Errorf("invalid header line: %s", line)
+		}
+		headers[key] = value
+	}
+
+	return &Response{
+		StatusCode: statusCode,
+		Status:     status,
+		Headers:    headers,
+		Body:       string(body),
+	}, nil
+}
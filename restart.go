@@ -0,0 +1,98 @@
+package ghast
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// envListenerFD is the environment variable through which Restart tells the new process the file descriptor of
+// the listener it inherits.
+const envListenerFD = "GHAST_LISTENER_FD"
+
+// envReadyFD is the environment variable through which Restart tells the new process the file descriptor of the
+// pipe on which it reports that it is serving.
+const envReadyFD = "GHAST_READY_FD"
+
+// inheritedListener returns the listener handed over by the process that started this one with Restart, if it
+// listens on addr, or nil. The environment variable naming it is cleared, so the listener is only taken once and
+// isn't passed on to processes started later; one listening elsewhere is closed, and addr is bound afresh.
+func inheritedListener(addr string) (net.Listener, error) {
+	value, ok := os.LookupEnv(envListenerFD)
+	if !ok {
+		return nil, nil
+	}
+	os.Unsetenv(envListenerFD)
+	fd, err := strconv.Atoi(value)
+	if err != nil || fd < 0 {
+		return nil, fmt.Errorf("ghast: invalid %s %q", envListenerFD, value)
+	}
+	file := os.NewFile(uintptr(fd), "listener")
+	defer file.Close() // FileListener duplicates the descriptor
+	ln, err := net.FileListener(file)
+	if err != nil {
+		return nil, fmt.Errorf("ghast: inherited listener: %w", err)
+	}
+	if !listensOn(ln, addr) {
+		ln.Close()
+		return nil, nil
+	}
+	return ln, nil
+}
+
+// notifyReady tells the process that started this one with Restart that it is serving, so that one can shut
+// down. The environment variable naming the pipe is cleared, so it is only written once.
+func notifyReady() {
+	value, ok := os.LookupEnv(envReadyFD)
+	if !ok {
+		return
+	}
+	os.Unsetenv(envReadyFD)
+	fd, err := strconv.Atoi(value)
+	if err != nil || fd < 0 {
+		return
+	}
+	file := os.NewFile(uintptr(fd), "ready")
+	file.Write([]byte{1})
+	file.Close()
+}
+
+// listensOn reports whether ln listens on addr, as given to Listen: a "unix:" socket path, or a TCP address
+// whose host and port, when given, are those of ln.
+func listensOn(ln net.Listener, addr string) bool {
+	if path, ok := strings.CutPrefix(addr, unixPrefix); ok {
+		unixAddr, ok := ln.Addr().(*net.UnixAddr)
+		return ok && unixAddr.Name == path
+	}
+	tcpAddr, ok := ln.Addr().(*net.TCPAddr)
+	if !ok {
+		return false
+	}
+	want, err := net.ResolveTCPAddr("tcp", addr)
+	if err != nil {
+		return false
+	}
+	if want.Port != 0 && want.Port != tcpAddr.Port {
+		return false
+	}
+	return want.IP == nil || want.IP.IsUnspecified() || want.IP.Equal(tcpAddr.IP)
+}
+
+// listenerFile returns a duplicate of the file descriptor of the socket listener opened by Listen, ListenTLS or
+// ListenFastCGI, for handing it over to a new process. A Unix domain socket file is no longer removed when the
+// listener closes, since the new process keeps listening on it.
+func (s *server) listenerFile() (*os.File, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	switch ln := s.socket.(type) {
+	case *net.TCPListener:
+		return ln.File()
+	case *net.UnixListener:
+		ln.SetUnlinkOnClose(false)
+		return ln.File()
+	}
+	return nil, errors.New("ghast: restart needs a TCP or Unix socket listener opened by Listen, ListenTLS or ListenFastCGI")
+}
@@ -0,0 +1,16 @@
+//go:build !unix
+
+package ghast
+
+import (
+	"errors"
+	"os"
+)
+
+// restartSignal is nil on platforms without a restart signal.
+var restartSignal os.Signal
+
+// Restart is not supported on platforms that can't pass sockets to new processes.
+func (g *Ghast) Restart() error {
+	return errors.New("ghast: restart is not supported on this platform")
+}
@@ -0,0 +1,186 @@
+//go:build unix
+
+package ghast
+
+import (
+	"context"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"syscall"
+	"testing"
+)
+
+// handOver sets up the environment as Restart does for the new process, with a duplicate of ln's descriptor
+// that inheritedListener takes ownership of.
+func handOver(t *testing.T, ln net.Listener) {
+	t.Helper()
+	file, err := ln.(*net.TCPListener).File()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer file.Close()
+	fd, err := syscall.Dup(int(file.Fd()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv(envListenerFD, strconv.Itoa(fd))
+}
+
+// TestInheritedListener tests that Listen serves on a listener handed over through the environment, as Restart
+// does, instead of opening its own
+func TestInheritedListener(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	handOver(t, ln)
+	ln.Close()
+
+	app := New()
+	app.Get("/", HandlerFunc(func(w ResponseWriter, r *Request) { w.Plain(200, "inherited") }))
+	addrs := make(chan string, 1)
+	app.server.onListen = func(addr net.Addr) { addrs <- addr.String() }
+	go app.Listen(ln.Addr().String())
+	addr := <-addrs
+	defer app.Shutdown(context.Background())
+
+	if addr != ln.Addr().String() {
+		t.Errorf("expected to serve on the inherited listener at %s, got %s", ln.Addr(), addr)
+	}
+	if _, found := os.LookupEnv(envListenerFD); found {
+		t.Errorf("expected %s to be cleared once the listener is taken", envListenerFD)
+	}
+	response := rawRoundTrip(t, addr, "GET / HTTP/1.1\r\nHost: localhost\r\nConnection: close\r\n\r\n")
+	if !strings.HasSuffix(response, "inherited") {
+		t.Errorf("expected the request to be served, got %q", response)
+	}
+
+	handedOver, err := app.server.listenerFile()
+	if err != nil {
+		t.Fatalf("expected the listener to be available for the next restart, got %v", err)
+	}
+	handedOver.Close()
+}
+
+// TestInheritedListenerOtherAddress tests that a listener handed over for another address is closed, and the
+// requested address bound instead
+func TestInheritedListenerOtherAddress(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	handOver(t, ln)
+
+	app := New()
+	addrs := make(chan string, 1)
+	app.server.onListen = func(addr net.Addr) { addrs <- addr.String() }
+	go app.Listen("127.0.0.2:0")
+	addr := <-addrs
+	defer app.Shutdown(context.Background())
+	if !strings.HasPrefix(addr, "127.0.0.2:") {
+		t.Errorf("expected a fresh listener rather than the inherited one at %s", addr)
+	}
+}
+
+// TestListensOn tests matching inherited listeners against listen addresses
+func TestListensOn(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	port := strconv.Itoa(ln.Addr().(*net.TCPAddr).Port)
+
+	for addr, want := range map[string]bool{
+		"127.0.0.1:" + port: true,
+		":" + port:          true,
+		"127.0.0.1:0":       true,
+		"127.0.0.2:" + port: false,
+		"127.0.0.1:1":       false,
+		"unix:/tmp/a.sock":  false,
+	} {
+		if got := listensOn(ln, addr); got != want {
+			t.Errorf("listensOn(%s) = %v, want %v", addr, got, want)
+		}
+	}
+}
+
+// TestRestartWithoutListener tests that Restart fails, and the application keeps going, without a listener
+// opened by Listen to hand over
+func TestRestartWithoutListener(t *testing.T) {
+	app := New()
+	if err := app.Restart(); err == nil {
+		t.Error("expected Restart to fail without a listener")
+	}
+	if app.server.shuttingDown() {
+		t.Error("expected the application not to be shut down")
+	}
+}
+
+// TestRestartHelperProcess is the new process started by the Restart tests: it serves "child" on the inherited
+// listener until it has answered a request, or exits straight away. It does nothing when run as a test.
+func TestRestartHelperProcess(t *testing.T) {
+	switch os.Getenv("GHAST_TEST_RESTART") {
+	case "exit":
+		os.Exit(1)
+	case "serve":
+		app := New(WithHideBanner())
+		app.Get("/", HandlerFunc(func(w ResponseWriter, r *Request) {
+			w.SetHeader("Connection", "close").Plain(200, "child")
+			go app.Shutdown(context.Background())
+		}))
+		app.Listen(os.Getenv("GHAST_TEST_RESTART_ADDR"))
+		os.Exit(0)
+	}
+}
+
+// restartTestApp starts an app serving "parent" and has Restart run the helper process in the given mode.
+func restartTestApp(t *testing.T, mode string) (*Ghast, string) {
+	app := New(WithHideBanner())
+	app.Get("/", HandlerFunc(func(w ResponseWriter, r *Request) { w.Plain(200, "parent") }))
+	addrs := make(chan string, 1)
+	app.server.onListen = func(addr net.Addr) { addrs <- addr.String() }
+	go app.Listen("127.0.0.1:0")
+	addr := <-addrs
+
+	t.Setenv("GHAST_TEST_RESTART", mode)
+	t.Setenv("GHAST_TEST_RESTART_ADDR", addr)
+	args := os.Args
+	os.Args = []string{args[0], "-test.run=^TestRestartHelperProcess$"}
+	t.Cleanup(func() { os.Args = args })
+	return app, addr
+}
+
+// TestRestart tests that the new process takes over the listener, and the old one shuts down once it serves
+func TestRestart(t *testing.T) {
+	app, addr := restartTestApp(t, "serve")
+	if err := app.Restart(); err != nil {
+		t.Fatalf("restart failed: %v", err)
+	}
+	if !app.server.shuttingDown() {
+		t.Error("expected the old process to shut down once the new one serves")
+	}
+	response := rawRoundTrip(t, addr, "GET / HTTP/1.1\r\nHost: localhost\r\nConnection: close\r\n\r\n")
+	if !strings.HasSuffix(response, "child") {
+		t.Errorf("expected the new process to serve, got %q", response)
+	}
+}
+
+// TestRestartChildExits tests that the old process keeps serving if the new one exits before serving
+func TestRestartChildExits(t *testing.T) {
+	app, addr := restartTestApp(t, "exit")
+	defer app.Shutdown(context.Background())
+	if err := app.Restart(); err == nil {
+		t.Error("expected Restart to fail when the new process exits")
+	}
+	if app.server.shuttingDown() {
+		t.Error("expected the application not to be shut down")
+	}
+	response := rawRoundTrip(t, addr, "GET / HTTP/1.1\r\nHost: localhost\r\nConnection: close\r\n\r\n")
+	if !strings.HasSuffix(response, "parent") {
+		t.Errorf("expected the old process to keep serving, got %q", response)
+	}
+}
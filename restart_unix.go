@@ -0,0 +1,97 @@
+//go:build unix
+
+package ghast
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"syscall"
+	"time"
+)
+
+// restartSignal is the signal on which ListenAndWaitForSignals restarts the application.
+var restartSignal os.Signal = syscall.SIGUSR2
+
+// restartReadyTimeout is how long Restart waits for the new process to start serving.
+const restartReadyTimeout = time.Minute
+
+// Restart replaces the running application with a new process of the current executable, without dropping
+// connections: the new process is started with the same arguments and environment and inherits the listening
+// socket, which it serves on once it calls Listen (or ListenTLS or ListenFastCGI). Once it reports that it is
+// serving, this one shuts down gracefully, finishing the requests in flight. Connections arriving in between
+// wait in the socket's backlog. Deploying is then a matter of replacing the binary and calling Restart, which
+// ListenAndWaitForSignals does on SIGUSR2:
+//
+//	go build -o /usr/local/bin/app . && kill -USR2 "$(pidof app)"
+//
+// If the new process fails to start, exits before serving, or isn't serving within a minute, it is killed and
+// Restart returns an error while this one keeps serving. Otherwise Restart returns the error from shutting down.
+// It is only supported on Unix.
+func (g *Ghast) Restart() error {
+	file, err := g.server.listenerFile()
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	executable, err := os.Executable()
+	if err != nil {
+		return err
+	}
+	ready, readyWriter, err := os.Pipe()
+	if err != nil {
+		return err
+	}
+	defer ready.Close()
+
+	// The listener and the write end of the pipe become descriptors 3 and 4 of the new process, after the
+	// standard streams. They are passed with ForkExec rather than os.StartProcess, which would put the
+	// listening socket, shared with this process's listener, in blocking mode.
+	pid, err := forkExec(executable, append(os.Environ(), envListenerFD+"=3", envReadyFD+"=4"), file, readyWriter)
+	readyWriter.Close() // Leaves the new process with the only write end, so reads see EOF once it exits
+	if err != nil {
+		return err
+	}
+	process, err := os.FindProcess(pid)
+	if err != nil {
+		return err
+	}
+	if err := waitReady(ready); err != nil {
+		process.Kill()
+		process.Wait()
+		return fmt.Errorf("ghast: restart: %w", err)
+	}
+	process.Release()
+	return g.Shutdown(context.Background())
+}
+
+// forkExec starts executable with the arguments of this process, the given environment, the standard streams
+// and then files as its descriptors, and returns its process ID.
+func forkExec(executable string, env []string, files ...*os.File) (int, error) {
+	fds := []uintptr{0, 1, 2}
+	for _, file := range files {
+		conn, err := file.SyscallConn()
+		if err != nil {
+			return 0, err
+		}
+		if err := conn.Control(func(fd uintptr) { fds = append(fds, fd) }); err != nil {
+			return 0, err
+		}
+	}
+	return syscall.ForkExec(executable, os.Args, &syscall.ProcAttr{Env: env, Files: fds})
+}
+
+// waitReady waits for the new process to report on ready that it is serving.
+func waitReady(ready *os.File) error {
+	ready.SetReadDeadline(time.Now().Add(restartReadyTimeout))
+	var b [1]byte
+	if _, err := ready.Read(b[:]); err != nil {
+		if errors.Is(err, io.EOF) {
+			return errors.New("new process exited before serving")
+		}
+		return fmt.Errorf("waiting for the new process to serve: %w", err)
+	}
+	return nil
+}
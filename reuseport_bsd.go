@@ -0,0 +1,8 @@
+//go:build aix || darwin || dragonfly || freebsd || netbsd || openbsd
+
+package ghast
+
+import "syscall"
+
+// soReusePort is the SO_REUSEPORT socket option.
+const soReusePort = syscall.SO_REUSEPORT
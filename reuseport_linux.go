@@ -0,0 +1,4 @@
+package ghast
+
+// soReusePort is SO_REUSEPORT, which package syscall doesn't define on every Linux architecture.
+const soReusePort = 0xf
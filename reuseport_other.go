@@ -0,0 +1,13 @@
+//go:build !(aix || darwin || dragonfly || freebsd || linux || netbsd || openbsd)
+
+package ghast
+
+import (
+	"errors"
+	"syscall"
+)
+
+// setReusePort fails on platforms without SO_REUSEPORT.
+func setReusePort(c syscall.RawConn) error {
+	return errors.New("ghast: SO_REUSEPORT is not supported on this platform")
+}
@@ -0,0 +1,16 @@
+//go:build aix || darwin || dragonfly || freebsd || linux || netbsd || openbsd
+
+package ghast
+
+import "syscall"
+
+// setReusePort sets SO_REUSEPORT on the socket of c.
+func setReusePort(c syscall.RawConn) error {
+	var err error
+	if controlErr := c.Control(func(fd uintptr) {
+		err = syscall.SetsockoptInt(int(fd), syscall.SOL_SOCKET, soReusePort, 1)
+	}); controlErr != nil {
+		return controlErr
+	}
+	return err
+}
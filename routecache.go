@@ -0,0 +1,69 @@
+package ghast
+
+import (
+	"container/list"
+	"sync"
+)
+
+// matchCache is a bounded LRU cache of the routes matched for request paths, so hot paths with parameters skip
+// the routing tree and its constraint matching. The router clears it whenever its routes change.
+type matchCache struct {
+	mu      sync.Mutex
+	size    int                        // Maximum number of entries
+	entries map[matchKey]*list.Element // Entries by method and path, holding *cachedMatch values
+	order   *list.List                 // Entries from most to least recently used
+}
+
+// matchKey identifies a cached match.
+type matchKey struct {
+	method, path string
+}
+
+// cachedMatch is a route matched for a method and path, along with the parameter values extracted from the path.
+type cachedMatch struct {
+	key    matchKey
+	route  *route
+	values []string
+}
+
+// newMatchCache creates a cache holding up to size matches.
+func newMatchCache(size int) *matchCache {
+	return &matchCache{size: size, entries: make(map[matchKey]*list.Element), order: list.New()}
+}
+
+// get returns the route cached for method and path, appending its parameter values to values.
+func (c *matchCache) get(method, path string, values []string) (*route, []string, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	elem, ok := c.entries[matchKey{method, path}]
+	if !ok {
+		return nil, values, false
+	}
+	c.order.MoveToFront(elem)
+	match := elem.Value.(*cachedMatch)
+	return match.route, append(values, match.values...), true
+}
+
+// add caches the route matched for method and path, evicting the least recently used entry if the cache is full.
+func (c *matchCache) add(method, path string, rt *route, values []string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	key := matchKey{method, path}
+	if _, ok := c.entries[key]; ok {
+		return
+	}
+	if c.order.Len() >= c.size {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*cachedMatch).key)
+	}
+	c.entries[key] = c.order.PushFront(&cachedMatch{key: key, route: rt, values: append([]string(nil), values...)})
+}
+
+// clear empties the cache.
+func (c *matchCache) clear() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	clear(c.entries)
+	c.order.Init()
+}
@@ -0,0 +1,28 @@
+package ghast
+
+import "testing"
+
+// TestMatchCacheEviction tests that the least recently used match is evicted once the cache is full
+func TestMatchCacheEviction(t *testing.T) {
+	a, b, c := &route{}, &route{}, &route{}
+	cache := newMatchCache(2)
+	cache.add(GET, "/a/1", a, []string{"1"})
+	cache.add(GET, "/b/2", b, []string{"2"})
+	cache.get(GET, "/a/1", nil) // "/a/1" is now the most recently used
+	cache.add(GET, "/c/3", c, []string{"3"})
+
+	if _, _, ok := cache.get(GET, "/b/2", nil); ok {
+		t.Error("expected /b/2 to be evicted")
+	}
+	if rt, values, ok := cache.get(GET, "/a/1", nil); !ok || rt != a || len(values) != 1 || values[0] != "1" {
+		t.Errorf("expected /a/1 to stay cached, got %v %v %v", rt, values, ok)
+	}
+	if _, _, ok := cache.get(POST, "/c/3", nil); ok {
+		t.Error("expected matches to be cached per method")
+	}
+
+	cache.clear()
+	if _, _, ok := cache.get(GET, "/a/1", nil); ok {
+		t.Error("expected clear to empty the cache")
+	}
+}
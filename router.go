@@ -1,13 +1,38 @@
 package ghast
 
 import (
-	"regexp"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"maps"
+	"net/http"
+	"net/url"
+	"path"
+	"reflect"
+	"runtime"
+	"slices"
+	"sort"
+	"strconv"
 	"strings"
+	"sync"
 )
 
 // Router interface defines the contract for HTTP routing and middleware management.
 type Router interface {
 	// Handle registers a handler for a specific HTTP method and path. The handler will be invoked when a request matches the method and path.
+	// Paths may contain ":name" segments, which match a single segment, and end with a "*name" segment, which matches the rest of the path
+	// including slashes (e.g. "/static/*filepath"). A parameter can be constrained with a regular expression that the whole segment must
+	// match (e.g. ":id(\d+)"); other values fall through to other routes or a 404. Trailing parameters marked with "?" are optional
+	// (e.g. "/articles/:year/:month?") and are empty when left out. Matched values are available through Request.Param,
+	// percent-decoded unless RouterOptions.RawParams is set.
+	// Handle panics if the template is invalid; see ValidateRoute.
+	//
+	// When several routes match a path, the most specific one wins, whatever the registration order: segment by segment
+	// from the left, a literal beats a constrained parameter, which beats an unconstrained parameter, which beats a
+	// wildcard. Constrained parameters at the same position are tried in registration order. If the preferred branch
+	// has no route for the rest of the path, the next one is tried, so "/users/new" and "/users/:id/posts" both match.
+	// Registering the same method and template twice replaces the earlier route.
 	Handle(method string, path string, handler Handler, middlewares ...Middleware)
 
 	// Express-like convenience methods for common HTTP verbs
@@ -19,61 +44,291 @@ type Router interface {
 	Head(path string, handler Handler, middlewares ...Middleware) Router
 	Options(path string, handler Handler, middlewares ...Middleware) Router
 
+	// Any registers the handler for every method ghast serves: GET, HEAD, POST, PUT, PATCH, DELETE and OPTIONS.
+	Any(path string, handler Handler, middlewares ...Middleware) Router
+
+	// Match registers the handler for each of the given methods.
+	Match(methods []string, path string, handler Handler, middlewares ...Middleware) Router
+
+	// Mount routes requests for prefix and every path below it, whatever their method, to a net/http handler (see
+	// WrapHTTP). The handler sees the full request path; wrap it with http.StripPrefix to remove the prefix.
+	Mount(prefix string, handler http.Handler, middlewares ...Middleware) Router
+
+	// StaticFS serves the files in fsys, such as an embed.FS, for GET and HEAD requests under prefix, as
+	// Ghast.StaticFS does.
+	//
+	//	r.StaticFS("/assets", assets)
+	StaticFS(prefix string, fsys fs.FS, middlewares ...Middleware) Router
+
+	// Resource registers the RESTful routes of a controller under path, for each action it implements (see
+	// ResourceIndexer and the related interfaces): GET path (Index), POST path (Create), GET path/:id (Show),
+	// PUT and PATCH path/:id (Update) and DELETE path/:id (Destroy). Resource panics if the controller implements
+	// none of them.
+	//
+	//	r.Resource("/users", &UserController{db: db}, requireAuth)
+	Resource(path string, controller any, middlewares ...Middleware) Router
+
+	// Redirect registers a route answering requests for from, whatever their method, with a redirect to to and
+	// the given 3xx status code. Parameters of from can be used in to ("/users/:id" to "/members/:id"), paths
+	// starting with "/" are relative to where the router is mounted, and the query string is passed on unless to
+	// has its own. Redirect panics if code is not a redirect status.
+	//
+	//	r.Redirect("/old-path", "/new-path", 301)
+	Redirect(from, to string, code int) Router
+
+	// Host routes requests whose Host header matches pattern to another router, before this router's own routes
+	// are tried. Patterns are matched case-insensitively and without the port; a pattern starting with "*."
+	// matches any subdomain of the rest (e.g. "*.example.com"). Hosts are tried in registration order.
+	Host(pattern string, router Router) Router
+
+	// Version serves router as an API version under the "/<version>" prefix, with the lifecycle headers and
+	// middleware of opts (see VersionOptions). Requests for other paths that match no route go to the version
+	// named in the version header or Accept header, if enabled on the application with SetVersionHeader or
+	// SetVersionFromAccept, or else to the latest version.
+	//
+	//	api.Version("v1", v1Router, ghast.VersionOptions{Sunset: sunset})
+	//	api.Version("v2", v2Router)
+	Version(version string, router Router, opts ...VersionOptions) Router
+
+	// Meta attaches a metadata entry to the routes registered by the previous registration call (Get, Any, Match and
+	// so on), so it can be read by middleware through Request.Route and by tooling through Routes and Lookup:
+	//
+	//	r.Get("/admin/users", listUsers).Meta("auth", "admin").Tag("admin", "v1")
+	Meta(key, value string) Router
+
+	// Tag adds tags to the routes registered by the previous registration call, like Meta.
+	Tag(tags ...string) Router
+
+	// Consumes restricts the routes registered by the previous registration call to requests whose Content-Type is
+	// one of the given media types, which may be wildcards such as "text/*". Other requests with a body or a
+	// Content-Type are rejected with 415 Unsupported Media Type before the handler runs.
+	//
+	//	r.Post("/users", createUser).Consumes("application/json")
+	Consumes(mediaTypes ...string) Router
+
+	// Produces restricts the routes registered by the previous registration call to requests whose Accept header
+	// allows one of the given media types. Other requests are rejected with 406 Not Acceptable before the handler
+	// runs; requests without an Accept header accept anything.
+	Produces(mediaTypes ...string) Router
+
 	// ServeHTTP processes an incoming HTTP request by matching it to the appropriate handler based on the request's method and path.
 	ServeHTTP(ResponseWriter, *Request)
 
 	// Use adds a middleware function to the router. Middleware functions are applied to all handlers registered with the router, allowing you to add common
-	// functionality (e.g., logging, authentication) across all routes without having to modify each handler individually.
+	// functionality (e.g., logging, authentication) across all routes without having to modify each handler individually. Routes registered before the
+	// call are wrapped as well, and so are the 404, 405 and other responses to requests that match no route.
 	Use(middleware Middleware) Router
+
+	// UsePath adds a middleware function that applies only to the routes whose path matches pattern. A pattern ending
+	// in "/*" covers a whole section ("/users/*" matches "/users", "/users/:id" and "/users/:id/posts"), other patterns
+	// may use path.Match globs ("/users/*/posts"), and plain patterns match one path exactly. Patterns are matched
+	// against the route templates as registered, including group prefixes.
+	UsePath(pattern string, middleware Middleware) Router
+
+	// Remove unregisters the route for method and path, as given when it was registered, and reports whether there
+	// was one. Requests already being handled by the route complete normally.
+	Remove(method, path string) bool
+
+	// Replace swaps the handler and route middleware of the route for method and path, keeping its metadata, and
+	// reports whether there was a route to replace. Like Remove, it is safe to call while requests are served.
+	Replace(method, path string, handler Handler, middlewares ...Middleware) bool
+
+	// Group returns a child router whose routes are registered on this router under the given path prefix, wrapped
+	// by the given middleware after this router's own. Groups can be nested; a nested group inherits the prefix and
+	// middleware of its parents.
+	Group(prefix string, middlewares ...Middleware) Router
+
+	// Routes returns a description of every registered route, sorted by path and method.
+	Routes() []RouteInfo
+
+	// DumpRoutes writes the routes returned by Routes to w as an indented JSON document, for diffing route tables
+	// between deployments or feeding them to other tools:
+	//
+	//	{"routes": [{"method": "GET", "path": "/users/:id", "params": ["id"], ...}]}
+	DumpRoutes(w io.Writer) error
+
+	// Lookup reports which route would handle a request for the given method and path, along with the route
+	// parameters extracted from the path, without invoking any handler.
+	Lookup(method, path string) (RouteInfo, map[string]string, bool)
+}
+
+// RouteInfo describes a registered route for introspection (startup route tables, debugging, tooling).
+type RouteInfo struct {
+	Method      string   `json:"method"`      // HTTP method (e.g., "GET")
+	Path        string   `json:"path"`        // Path template as registered (e.g., "/users/:id")
+	Params      []string `json:"params"`      // Parameter names in the order they appear in the path
+	Handler     string   `json:"handler"`     // Name of the handler function or type
+	Middlewares int      `json:"middlewares"` // Number of middleware wrapping the handler
+
+	Meta     map[string]string `json:"meta,omitempty"`     // Metadata attached with Router.Meta
+	Tags     []string          `json:"tags,omitempty"`     // Tags attached with Router.Tag
+	Consumes []string          `json:"consumes,omitempty"` // Request media types accepted, set with Router.Consumes
+	Produces []string          `json:"produces,omitempty"` // Response media types offered, set with Router.Produces
+	Mount    string            `json:"mount,omitempty"`    // Prefix of the mounted router or sub-application serving the route, "" for the root router
+}
+
+// RouterOptions configures how a router matches requests. The zero value is the default behavior.
+type RouterOptions struct {
+	// DisableMethodNotAllowed answers requests whose path only matches routes registered for other methods with
+	// 404 Not Found, instead of 405 Method Not Allowed and an Allow header.
+	DisableMethodNotAllowed bool
+
+	// TrailingSlash decides what happens to a request whose path matches no route as written, but would with a
+	// trailing slash added or removed (e.g. "/users/" for a route registered as "/users"). The default,
+	// TrailingSlashStrict, treats the two paths as different.
+	TrailingSlash TrailingSlashPolicy
+
+	// CaseInsensitive matches the literal segments of route templates regardless of case, so "/Users/123" matches
+	// "/users/:id". Parameter values keep the case of the request path.
+	CaseInsensitive bool
+
+	// DisableAutoHead stops HEAD requests from being served by the GET route of the path when no HEAD route is
+	// registered. By default they are, with the response body discarded.
+	DisableAutoHead bool
+
+	// DisableAutoOptions stops OPTIONS requests for a path without an OPTIONS route from being answered with a
+	// 204 and an Allow header listing the path's methods. By default they are, which also serves CORS preflight
+	// requests when the CORS middleware is installed on the app.
+	DisableAutoOptions bool
+
+	// StrictPaths rejects requests whose path is not in normalized form, such as "//users" or "/users/./42", with
+	// 400 Bad Request. By default such paths are normalized before matching, resolving "." and ".." segments and
+	// collapsing duplicate slashes, so "//users" matches "/users". The server normalizes paths before any
+	// middleware runs, so the router rejects paths that were normalized there as well.
+	StrictPaths bool
+
+	// RawParams keeps parameter values percent-encoded, exactly as they appear in the request path. By default
+	// they are decoded, so "/files/my%20report.pdf" gives "my report.pdf" for "/files/:name"; values with
+	// invalid escapes are kept as they are.
+	RawParams bool
+
+	// MatchCacheSize enables a cache of the routes matched for the most recently requested paths, holding up to
+	// this many entries, so busy paths with parameters skip matching the routing tree and parameter constraints.
+	// Only matches of routes with parameters are cached, and the cache is cleared whenever routes are added,
+	// removed or replaced. Zero disables the cache.
+	MatchCacheSize int
 }
 
+// TrailingSlashPolicy is how a router handles paths that differ from a route only by a trailing slash.
+type TrailingSlashPolicy int
+
+const (
+	TrailingSlashStrict   TrailingSlashPolicy = iota // Match the path as written; "/users/" does not match "/users"
+	TrailingSlashRedirect                            // Redirect to the registered form: 301 for GET and HEAD, 308 for other methods
+	TrailingSlashIgnore                              // Serve the route as if the path had been written in its registered form
+)
+
 type router struct {
-	routes      map[string]map[string]Handler // Nested map: first key is HTTP method (e.g., "GET", "POST"), second key is the path. Value is the Handler.
-	middlewares []Middleware                  // Middleware applied to all routes.
-	regexRoutes map[string]*pathRegex         // Regex patterns and params for routes with dynamic segments. Key is the path template.
+	mu          sync.RWMutex                 // Guards the fields below, so routes can be changed while requests are served.
+	options     RouterOptions                // Matching behavior set with NewRouterWithOptions.
+	routes      map[string]map[string]*route // Nested map: first key is HTTP method (e.g., "GET", "POST"), second key is the path. Value is the registered route.
+	middlewares []routerMiddleware           // Middleware added with Use and UsePath, in order.
+	tree        *node                        // Routing tree matching paths segment by segment, shared by every method.
+	hosts       []hostRoute                  // Routers selected by the Host header, in registration order.
+	versions    map[string]*versionRoutes    // Versions registered with Version, by the prefix of the router or group they were registered on.
+	last        []*route                     // Routes registered by the previous registration call, for Meta, Tag, Consumes and Produces.
+	unmatched   Handler                      // serveUnmatched wrapped in the middleware added with Use.
+	cache       *matchCache                  // Recent matches, if enabled with RouterOptions.MatchCacheSize.
+}
+
+// routerMiddleware is middleware added to a router, applied to the routes whose path matches pattern, or to
+// every route if pattern is empty.
+type routerMiddleware struct {
+	pattern    string
+	middleware Middleware
+}
+
+// hostRoute is a router registered with Host for the requests to matching hosts.
+type hostRoute struct {
+	pattern string
+	router  Router
 }
 
-// pathRegex stores compiled regex and parameter names for dynamic routes.
-type pathRegex struct {
-	regex  *regexp.Regexp // Compiled regex pattern for efficient matching.
-	params []string       // Parameter names in order they appear in regex captures.
+// route is a registered handler along with the information needed to describe it.
+type route struct {
+	handler     Handler        // Handler with all middleware applied, invoked for matching requests.
+	info        RouteInfo      // Description of the route for introspection.
+	base        Handler        // Handler as registered, before middleware.
+	groups      []*routerGroup // Groups the route was registered through, outermost first.
+	middlewares []Middleware   // Middleware given when the route was registered.
 }
 
+// maxParams is the number of parameter values matched without allocating.
+const maxParams = 8
+
 // NewRouter creates a new Router instance with empty routes and middleware.
 func NewRouter() Router {
-	return &router{
-		routes:      make(map[string]map[string]Handler),
-		regexRoutes: make(map[string]*pathRegex),
-		middlewares: []Middleware{},
+	return NewRouterWithOptions(RouterOptions{})
+}
+
+// NewRouterWithOptions creates a new Router instance with empty routes and middleware, matching requests as
+// configured by opts.
+//
+//	r := ghast.NewRouterWithOptions(ghast.RouterOptions{DisableMethodNotAllowed: true})
+func NewRouterWithOptions(opts RouterOptions) Router {
+	r := &router{
+		options:     opts,
+		routes:      make(map[string]map[string]*route),
+		middlewares: []routerMiddleware{},
+		tree:        &node{},
+	}
+	r.unmatched = HandlerFunc(r.serveUnmatched)
+	if opts.MatchCacheSize > 0 {
+		r.cache = newMatchCache(opts.MatchCacheSize)
 	}
+	return r
 }
 
-// Handle registers a handler for a specific HTTP method and path. It also compiles regex patterns for dynamic routes and applies middleware.
+// Handle registers a handler for a specific HTTP method and path. It also adds the path to the routing tree and applies middleware.
 func (r *router) Handle(method string, path string, handler Handler, middlewares ...Middleware) {
-	// Extract route parameters and compile regex pattern for dynamic routes.
-	params := extractRouteParams(path)
-	pattern := pathToRegex(path)
+	r.register([]string{method}, path, handler, nil, middlewares)
+}
 
-	// Compile the regex pattern once during registration for efficient matching.
-	compiledRegex := regexp.MustCompile(pattern)
-	r.regexRoutes[path] = &pathRegex{
-		regex:  compiledRegex,
-		params: params,
+// register registers a route for each method, through the given groups, and remembers them for Meta, Tag,
+// Consumes and Produces.
+func (r *router) register(methods []string, path string, handler Handler, groups []*routerGroup, middlewares []Middleware) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.clearCache()
+	r.last = r.last[:0]
+	for _, method := range methods {
+		r.last = append(r.last, r.handle(method, path, handler, groups, middlewares))
 	}
+}
 
-	// Collect middleware: global middleware + route-specific middleware.
-	middlewareCollection := []Middleware{}
-	middlewareCollection = append(middlewareCollection, r.middlewares...)
-	middlewareCollection = append(middlewareCollection, middlewares...)
+// handle registers a route and returns it.
+func (r *router) handle(method string, path string, handler Handler, groups []*routerGroup, middlewares []Middleware) *route {
+	segments, err := parseTemplate(path)
+	if err != nil {
+		panic(err)
+	}
 
-	// Apply middleware to the handler.
-	handler = chainMiddleware(handler, middlewareCollection)
+	rt := &route{
+		info: RouteInfo{
+			Method:  method,
+			Path:    path,
+			Params:  paramNames(segments),
+			Handler: handlerName(handler),
+		},
+		base:        handler,
+		groups:      groups,
+		middlewares: middlewares,
+	}
+	r.compose(rt)
 
 	// Register the handler for the specified method and path.
 	if r.routes[method] == nil {
-		r.routes[method] = make(map[string]Handler)
+		r.routes[method] = make(map[string]*route)
+	}
+	r.routes[method][path] = rt
+	for _, variant := range templateVariants(segments) {
+		n := r.tree.insert(variant, r.options.CaseInsensitive)
+		if n.routes == nil {
+			n.routes = make(map[string]*route)
+		}
+		n.routes[method] = rt
 	}
-	r.routes[method][path] = handler
+	return rt
 }
 
 // Express-like convenience methods for HTTP verbs
@@ -120,72 +375,746 @@ func (r *router) Options(path string, handler Handler, middlewares ...Middleware
 	return r
 }
 
+// anyMethods are the methods Any registers a handler for.
+var anyMethods = []string{GET, HEAD, POST, PUT, PATCH, DELETE, OPTIONS}
+
+// Any routes requests with any method to the specified path with the given handler. Returns the router for chaining.
+func (r *router) Any(path string, handler Handler, middlewares ...Middleware) Router {
+	return r.Match(anyMethods, path, handler, middlewares...)
+}
+
+// Match routes requests with any of the given methods to the specified path with the given handler. Returns the
+// router for chaining.
+func (r *router) Match(methods []string, path string, handler Handler, middlewares ...Middleware) Router {
+	r.register(methods, path, handler, nil, middlewares)
+	return r
+}
+
+// Meta attaches a metadata entry to the routes registered by the previous registration call. Returns the router
+// for chaining.
+func (r *router) Meta(key, value string) Router {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, rt := range r.last {
+		// Copy on write, so RouteInfo values handed out earlier are not modified.
+		meta := maps.Clone(rt.info.Meta)
+		if meta == nil {
+			meta = make(map[string]string)
+		}
+		meta[key] = value
+		rt.info.Meta = meta
+	}
+	return r
+}
+
+// Tag adds tags to the routes registered by the previous registration call. Returns the router for chaining.
+func (r *router) Tag(tags ...string) Router {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, rt := range r.last {
+		rt.info.Tags = append(slices.Clip(rt.info.Tags), tags...)
+	}
+	return r
+}
+
+// Consumes restricts the routes registered by the previous registration call to requests with one of the given
+// content types. Returns the router for chaining.
+func (r *router) Consumes(mediaTypes ...string) Router {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, rt := range r.last {
+		rt.info.Consumes = append(slices.Clip(rt.info.Consumes), mediaTypes...)
+	}
+	return r
+}
+
+// Produces restricts the routes registered by the previous registration call to requests accepting one of the
+// given media types. Returns the router for chaining.
+func (r *router) Produces(mediaTypes ...string) Router {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, rt := range r.last {
+		rt.info.Produces = append(slices.Clip(rt.info.Produces), mediaTypes...)
+	}
+	return r
+}
+
+// Mount routes requests for prefix and the paths below it to a net/http handler. Returns the router for chaining.
+//
+//	r.Mount("/debug/pprof", http.HandlerFunc(pprof.Index))
+func (r *router) Mount(prefix string, handler http.Handler, middlewares ...Middleware) Router {
+	mountHTTP(r, prefix, handler, middlewares)
+	return r
+}
+
+// mountHTTP registers a net/http handler on r for prefix and, through a wildcard, every path below it.
+func mountHTTP(r Router, prefix string, handler http.Handler, middlewares []Middleware) {
+	wrapped := WrapHTTP(handler)
+	prefix = joinPaths("", prefix)
+	r.Any(prefix, wrapped, middlewares...)
+	r.Any(joinPaths(prefix, "*path"), wrapped, middlewares...)
+}
+
+// StaticFS registers routes serving the files in fsys under prefix. Returns the router for chaining.
+func (r *router) StaticFS(prefix string, fsys fs.FS, middlewares ...Middleware) Router {
+	registerStatic(r.Handle, prefix, fsys, middlewares)
+	return r
+}
+
+// Resource registers the routes for the actions controller implements under path. Returns the router for
+// chaining.
+func (r *router) Resource(path string, controller any, middlewares ...Middleware) Router {
+	registerResource(r.Handle, path, controller, middlewares)
+	return r
+}
+
+// Redirect registers a route redirecting requests for from to to with the given status code. Returns the router
+// for chaining.
+func (r *router) Redirect(from, to string, code int) Router {
+	r.Any(from, newRedirectHandler(to, code))
+	return r
+}
+
+// Host routes requests for hosts matching pattern to router. Returns the router for chaining.
+//
+//	api := ghast.NewRouter()
+//	api.Get("/users", listUsers)
+//	r.Host("api.example.com", api)
+func (r *router) Host(pattern string, router Router) Router {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.hosts = append(r.hosts, hostRoute{pattern: pattern, router: router})
+	return r
+}
+
+// Version serves router as an API version under the "/<version>" prefix. Returns the router for chaining.
+func (r *router) Version(version string, router Router, opts ...VersionOptions) Router {
+	registerVersion(r, r, "", version, router, opts)
+	return r
+}
+
 // ServeHTTP processes an incoming HTTP request by matching it to the appropriate handler.
 func (r *router) ServeHTTP(w ResponseWriter, req *Request) {
-	// First, try exact path match.
-	if r.routes[req.Method] != nil {
-		if handler, ok := r.routes[req.Method][req.Path]; ok {
-			handler.ServeHTTP(w, req)
+	if err := req.cleanPath(); err != nil || (r.options.StrictPaths && req.unclean) {
+		w.Status(400)
+		w.Send([]byte("400 Bad Request"))
+		return
+	}
+
+	if hostRouter := r.hostRouter(req); hostRouter != nil {
+		hostRouter.ServeHTTP(w, req)
+		return
+	}
+
+	var buf [maxParams]string
+	if route, handler, values := r.resolve(req.Method, req.Path, buf[:0]); route != nil {
+		route.serve(w, req, handler, values)
+		return
+	}
+	if r.options.TrailingSlash == TrailingSlashIgnore && req.Path != "/" {
+		if route, handler, values := r.resolve(req.Method, toggleTrailingSlash(req.Path), buf[:0]); route != nil {
+			route.serve(w, req, handler, values)
 			return
 		}
 	}
 
-	// Try matching against regex routes (paths with dynamic segments).
-	for pathTemplate, route := range r.regexRoutes {
-		matches := route.regex.FindStringSubmatch(req.Path)
-		if len(matches) > 0 {
-			// Extract captured parameters from regex matches.
-			req.Params = make(map[string]string)
-			for i, paramName := range route.params {
-				if i+1 < len(matches) {
-					req.Params[paramName] = matches[i+1]
+	r.mu.RLock()
+	unmatched := r.unmatched
+	r.mu.RUnlock()
+	unmatched.ServeHTTP(w, req)
+}
+
+// serveUnmatched answers a request that matches no route: with a trailing slash redirect, the methods allowed
+// for its path, or a 404. It runs inside the middleware added with Use, so responses to unmatched requests get
+// the same treatment (request IDs, CORS headers, logging) as those of routes.
+func (r *router) serveUnmatched(w ResponseWriter, req *Request) {
+	if r.options.TrailingSlash == TrailingSlashRedirect && req.Path != "/" {
+		var buf [maxParams]string
+		alternate := toggleTrailingSlash(req.Path)
+		if route, _, _ := r.resolve(req.Method, alternate, buf[:0]); route != nil {
+			redirectTrailingSlash(w, req, alternate)
+			return
+		}
+	}
+
+	// The path may still be registered for other methods, in which case OPTIONS requests are answered with the
+	// methods allowed and others get a 405.
+	if req.Method == OPTIONS && !r.options.DisableAutoOptions {
+		if allowed := r.allowedMethods(req.Path); len(allowed) > 0 {
+			w.Status(204).SetHeader("Allow", strings.Join(append(allowed, OPTIONS), ", "))
+			return
+		}
+	}
+	if r.options.DisableMethodNotAllowed {
+		notFoundHandler(req).ServeHTTP(w, req)
+		return
+	}
+	if allowed := r.allowedMethods(req.Path); len(allowed) > 0 {
+		w.SetHeader("Allow", strings.Join(allowed, ", "))
+		methodNotAllowedHandler(req).ServeHTTP(w, req)
+		return
+	}
+
+	notFoundHandler(req).ServeHTTP(w, req)
+}
+
+// hostRouter returns the router registered with Host for the request's host, if any.
+func (r *router) hostRouter(req *Request) Router {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if len(r.hosts) == 0 {
+		return nil
+	}
+	if host, ok := req.Headers["Host"]; ok {
+		for _, hr := range r.hosts {
+			if hostAllowed(host, []string{hr.pattern}) {
+				return hr.router
+			}
+		}
+	}
+	return nil
+}
+
+// resolve finds the route for method and path like matchMethod, along with its handler as composed at the time.
+// The router is not locked while the handler runs, so handlers can change the routes themselves.
+func (r *router) resolve(method, path string, values []string) (*route, Handler, []string) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if r.cache != nil {
+		if route, values, ok := r.cache.get(method, path, values); ok {
+			r.decodeParams(values)
+			return route, route.handler, values
+		}
+	}
+	route, values := r.matchMethod(method, path, values)
+	if route == nil {
+		return nil, nil, values
+	}
+	if r.cache != nil && len(route.info.Params) > 0 {
+		r.cache.add(method, path, route, values)
+	}
+	r.decodeParams(values)
+	return route, route.handler, values
+}
+
+// clearCache empties the match cache, if enabled, after the routes have changed. The caller must hold r.mu.
+func (r *router) clearCache() {
+	if r.cache != nil {
+		r.cache.clear()
+	}
+}
+
+// decodeParams percent-decodes matched parameter values in place, unless the router keeps them raw.
+func (r *router) decodeParams(values []string) {
+	if r.options.RawParams {
+		return
+	}
+	for i, value := range values {
+		if strings.IndexByte(value, '%') < 0 {
+			continue
+		}
+		if decoded, err := url.PathUnescape(value); err == nil {
+			values[i] = decoded
+		}
+	}
+}
+
+// serve fills the request's params from the matched values and invokes handler, the route's composed handler,
+// unless the request's Content-Type or Accept header rules out the route.
+func (rt *route) serve(w ResponseWriter, req *Request, handler Handler, values []string) {
+	if len(rt.info.Consumes) > 0 && !consumable(req, rt.info.Consumes) {
+		w.Status(415)
+		w.Send([]byte("415 Unsupported Media Type"))
+		return
+	}
+	if len(rt.info.Produces) > 0 && !acceptable(req.GetHeader("Accept"), rt.info.Produces) {
+		w.Status(406)
+		w.Send([]byte("406 Not Acceptable"))
+		return
+	}
+
+	req.route = &rt.info
+	if len(rt.info.Params) > 0 {
+		// Reuse the request's params map, which pooled requests carry over between requests.
+		if req.Params == nil {
+			req.Params = make(map[string]string, len(rt.info.Params))
+		} else {
+			clear(req.Params)
+		}
+		rt.fillParams(req.Params, values)
+	}
+	handler.ServeHTTP(w, req)
+}
+
+// consumable reports whether the request's Content-Type is one of mediaTypes. Requests without a body or a
+// Content-Type have nothing to consume and are let through.
+func consumable(req *Request, mediaTypes []string) bool {
+	contentType := req.ContentType()
+	if contentType == "" {
+		return len(req.Body) == 0
+	}
+	for _, mediaType := range mediaTypes {
+		if mediaTypeMatches(mediaType, contentType) {
+			return true
+		}
+	}
+	return false
+}
+
+// acceptable reports whether an Accept header allows any of mediaTypes. An empty header accepts anything, and
+// media ranges with a quality of 0 are ignored.
+func acceptable(accept string, mediaTypes []string) bool {
+	if strings.TrimSpace(accept) == "" {
+		return true
+	}
+	for mediaRange := range strings.SplitSeq(accept, ",") {
+		mediaRange, params, _ := strings.Cut(mediaRange, ";")
+		if acceptQuality(params) == 0 {
+			continue
+		}
+		for _, mediaType := range mediaTypes {
+			if mediaTypeMatches(mediaRange, mediaType) || mediaTypeMatches(mediaType, mediaRange) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// acceptQuality returns the "q" parameter of a media range's parameters, 1 if it has none.
+func acceptQuality(params string) float64 {
+	for param := range strings.SplitSeq(params, ";") {
+		if key, value, ok := strings.Cut(param, "="); ok && strings.EqualFold(strings.TrimSpace(key), "q") {
+			q, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+			if err != nil {
+				return 0
+			}
+			return q
+		}
+	}
+	return 1
+}
+
+// mediaTypeMatches reports whether mediaType falls within pattern, which may be "*/*" or a wildcard subtype such
+// as "text/*". Parameters are not compared, and case is ignored.
+func mediaTypeMatches(pattern, mediaType string) bool {
+	pattern, _, _ = strings.Cut(pattern, ";")
+	mediaType, _, _ = strings.Cut(mediaType, ";")
+	pattern, mediaType = strings.TrimSpace(pattern), strings.TrimSpace(mediaType)
+	if pattern == "*/*" || strings.EqualFold(pattern, mediaType) {
+		return true
+	}
+	if prefix, ok := strings.CutSuffix(pattern, "/*"); ok {
+		typ, _, _ := strings.Cut(mediaType, "/")
+		return strings.EqualFold(prefix, typ)
+	}
+	return false
+}
+
+// toggleTrailingSlash removes the trailing slash from path, or adds one if it has none.
+func toggleTrailingSlash(path string) string {
+	if trimmed, ok := strings.CutSuffix(path, "/"); ok {
+		return trimmed
+	}
+	return path + "/"
+}
+
+// redirectTrailingSlash redirects the request to path, keeping the prefixes of the mounts the request went
+// through and its query string. GET and HEAD requests get a 301; others get a 308 so the method and body are
+// preserved.
+func redirectTrailingSlash(w ResponseWriter, req *Request, path string) {
+	location := req.mountPath + path + queryString(req)
+	status := 308
+	if req.Method == GET || req.Method == HEAD {
+		status = 301
+	}
+	w.Status(status).SetHeader("Location", location)
+}
+
+// queryString returns the request's query string as sent by the client, with its leading "?", or "" if it has
+// none. Requests built without one have it rebuilt from their query parameters, whose values are kept as sent,
+// percent-encoding included, so they are not encoded again.
+func queryString(req *Request) string {
+	if req.rawQuery != "" {
+		return "?" + req.rawQuery
+	}
+	if len(req.Queries) == 0 {
+		return ""
+	}
+	pairs := make([]string, 0, len(req.Queries))
+	for key, value := range req.Queries {
+		pairs = append(pairs, key+"="+value)
+	}
+	slices.Sort(pairs)
+	return "?" + strings.Join(pairs, "&")
+}
+
+// escapeParam percent-encodes a decoded parameter value for use in a path. The slashes of wildcard values
+// separate segments and are kept.
+func escapeParam(value string, wildcard bool) string {
+	if !wildcard {
+		return url.PathEscape(value)
+	}
+	segments := strings.Split(value, "/")
+	for i, segment := range segments {
+		segments[i] = url.PathEscape(segment)
+	}
+	return strings.Join(segments, "/")
+}
+
+// redirectHandler answers requests with a redirect to a target that may refer to the route's parameters.
+type redirectHandler struct {
+	target   string            // Redirect target as given to Router.Redirect
+	segments []templateSegment // Parsed segments of a path target, nil for URLs
+	code     int               // Redirect status code
+}
+
+// newRedirectHandler returns the handler for a Router.Redirect route, panicking on a status code that is not a
+// redirect.
+func newRedirectHandler(to string, code int) *redirectHandler {
+	if code < 300 || code > 399 {
+		panic(fmt.Sprintf("ghast: invalid redirect status code %d", code))
+	}
+	h := &redirectHandler{target: to, code: code}
+	if strings.HasPrefix(to, "/") {
+		path, _, _ := strings.Cut(to, "?")
+		segments, err := parseTemplate(path)
+		if err != nil {
+			panic(err)
+		}
+		h.segments = segments
+	}
+	return h
+}
+
+// ServeHTTP redirects the request to the handler's target, filled in with the request's route parameters.
+func (h *redirectHandler) ServeHTTP(w ResponseWriter, req *Request) {
+	location := h.target
+	if h.segments != nil {
+		var b strings.Builder
+		b.WriteString(req.mountPath)
+		for _, seg := range h.segments {
+			text := seg.text
+			if seg.kind != literalSegment {
+				if text = req.Param(seg.text); text == "" {
+					continue // Optional parameter left out
 				}
+				// Parameters are decoded, so they are escaped again to keep the target a single valid path
+				text = escapeParam(text, seg.kind == wildcardSegment)
 			}
+			b.WriteString("/")
+			b.WriteString(text)
+		}
+		if b.Len() == 0 {
+			b.WriteString("/")
+		}
+		location = b.String()
+		if _, query, found := strings.Cut(h.target, "?"); found {
+			location += "?" + query
+		}
+	}
+	if !strings.Contains(location, "?") {
+		location += queryString(req)
+	}
+	w.Status(h.code).SetHeader("Location", location)
+}
+
+// Lookup reports which route would handle a request for the given method and path, along with the route
+// parameters extracted from the path, without invoking any handler.
+func (r *router) Lookup(method, path string) (RouteInfo, map[string]string, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	route, values := r.matchMethod(method, path, nil)
+	if route == nil {
+		return RouteInfo{}, nil, false
+	}
+	r.decodeParams(values)
+	params := make(map[string]string)
+	route.fillParams(params, values)
+	return route.info, params, true
+}
+
+// match finds the route registered for method that matches path, along with the values of its parameters in
+// order, appended to values. Literal segments take priority over parameters, so exact paths win over routes
+// with dynamic segments.
+func (r *router) match(method, path string, values []string) (*route, []string) {
+	// Paths registered without parameters are found with a single map lookup.
+	if route, ok := r.routes[method][path]; ok && len(route.info.Params) == 0 {
+		return route, values
+	}
+
+	var matched *route
+	r.tree.walk(path, values, r.options.CaseInsensitive, func(n *node, captured []string) bool {
+		if route, ok := n.routes[method]; ok {
+			matched, values = route, captured
+			return true
+		}
+		return false
+	})
+	return matched, values
+}
 
-			// Look up and invoke the handler for this route.
-			if handler, ok := r.routes[req.Method][pathTemplate]; ok {
-				handler.ServeHTTP(w, req)
-				return
+// matchMethod is match with HEAD requests falling back to the GET route of the path, unless DisableAutoHead is set.
+func (r *router) matchMethod(method, path string, values []string) (*route, []string) {
+	route, matched := r.match(method, path, values)
+	if route == nil && method == HEAD && !r.options.DisableAutoHead {
+		route, matched = r.match(GET, path, values)
+	}
+	return route, matched
+}
+
+// fillParams stores the parameter values, in the order matched, into params under the route's parameter names.
+// Optional parameters left out of the path are stored as empty strings.
+func (rt *route) fillParams(params map[string]string, values []string) {
+	for i, paramName := range rt.info.Params {
+		if i < len(values) {
+			params[paramName] = values[i]
+		} else {
+			params[paramName] = ""
+		}
+	}
+}
+
+// allowedMethods returns the sorted list of methods that have a route matching the given path.
+func (r *router) allowedMethods(path string) []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	var allowed []string
+	r.tree.walk(path, nil, r.options.CaseInsensitive, func(n *node, _ []string) bool {
+		for method := range n.routes {
+			if !slices.Contains(allowed, method) {
+				allowed = append(allowed, method)
 			}
 		}
+		return false
+	})
+	if slices.Contains(allowed, GET) && !slices.Contains(allowed, HEAD) && !r.options.DisableAutoHead {
+		allowed = append(allowed, HEAD)
 	}
+	sort.Strings(allowed)
+	return allowed
+}
 
+// defaultNotFoundHandler writes the framework's plain 404 response.
+var defaultNotFoundHandler = HandlerFunc(func(w ResponseWriter, r *Request) {
 	w.Status(404)
 	w.Send([]byte("404 Not Found"))
+})
+
+// defaultMethodNotAllowedHandler writes the framework's plain 405 response.
+var defaultMethodNotAllowedHandler = HandlerFunc(func(w ResponseWriter, r *Request) {
+	w.Status(405)
+	w.Send([]byte("405 Method Not Allowed"))
+})
+
+// notFoundHandler resolves the 404 handler configured on the application handling the request,
+// falling back through parent applications to the default.
+func notFoundHandler(req *Request) Handler {
+	for app := req.app; app != nil; app = app.parent {
+		if app.notFoundHandler != nil {
+			return app.notFoundHandler
+		}
+	}
+	return defaultNotFoundHandler
+}
+
+// methodNotAllowedHandler resolves the 405 handler configured on the application handling the request,
+// falling back through parent applications to the default.
+func methodNotAllowedHandler(req *Request) Handler {
+	for app := req.app; app != nil; app = app.parent {
+		if app.methodNotAllowedHandler != nil {
+			return app.methodNotAllowedHandler
+		}
+	}
+	return defaultMethodNotAllowedHandler
 }
 
-// Use adds a middleware function to the router that applies to all routes.
+// Use adds a middleware function to the router that applies to all routes, including those already registered.
 func (r *router) Use(middleware Middleware) Router {
-	r.middlewares = append(r.middlewares, middleware)
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.middlewares = append(r.middlewares, routerMiddleware{middleware: middleware})
+	r.recompose()
 	return r
 }
 
-// extractRouteParams extracts parameter names from a path template.
-// Example: "/users/:id/posts/:postId" returns ["id", "postId"].
-func extractRouteParams(path string) []string {
-	var params []string
-	parts := strings.Split(path, "/")
-	for _, part := range parts {
-		if strings.HasPrefix(part, ":") {
-			paramName := strings.TrimPrefix(part, ":")
-			params = append(params, paramName)
+// UsePath adds a middleware function that applies to the routes whose path matches pattern, including those
+// already registered. Returns the router for chaining.
+func (r *router) UsePath(pattern string, middleware Middleware) Router {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.middlewares = append(r.middlewares, routerMiddleware{pattern: pattern, middleware: middleware})
+	r.recompose()
+	return r
+}
+
+// pathMatches reports whether a route path matches a UsePath pattern: a pattern ending in "/*" matches its
+// prefix and every path below it, other patterns with glob characters are matched with path.Match, and plain
+// patterns must equal the path.
+func pathMatches(pattern, routePath string) bool {
+	if prefix, ok := strings.CutSuffix(pattern, "/*"); ok {
+		return prefix == "" || routePath == prefix || strings.HasPrefix(routePath, prefix+"/")
+	}
+	if strings.ContainsAny(pattern, "*?[") {
+		matched, _ := path.Match(pattern, routePath)
+		return matched
+	}
+	return pattern == routePath
+}
+
+// composeUnmatched wraps serveUnmatched in the middleware added with Use. The caller must hold r.mu.
+func (r *router) composeUnmatched() {
+	var middlewares []Middleware
+	for _, rm := range r.middlewares {
+		if rm.pattern == "" {
+			middlewares = append(middlewares, rm.middleware)
 		}
 	}
-	return params
+	r.unmatched = chainMiddleware(HandlerFunc(r.serveUnmatched), middlewares)
 }
 
-// pathToRegex converts a path template to a regex pattern.
-// Example: "/users/:id/posts/:postId" returns "^/users/([^/]+)/posts/([^/]+)$".
-func pathToRegex(path string) string {
-	parts := strings.Split(path, "/")
-	var regexParts []string
-	for _, part := range parts {
-		if strings.HasPrefix(part, ":") {
-			// Replace :paramName with a capture group for non-slash characters.
-			regexParts = append(regexParts, "([^/]+)")
-		} else {
-			regexParts = append(regexParts, part)
+// compose wraps the route's handler in its middleware: the router's, then that of each group it was registered
+// through, outermost first, then its own.
+func (r *router) compose(rt *route) {
+	var middlewares []Middleware
+	for _, rm := range r.middlewares {
+		if rm.pattern == "" || pathMatches(rm.pattern, rt.info.Path) {
+			middlewares = append(middlewares, rm.middleware)
+		}
+	}
+	for _, group := range rt.groups {
+		middlewares = append(middlewares, group.middlewares...)
+	}
+	middlewares = append(middlewares, rt.middlewares...)
+
+	rt.handler = chainMiddleware(rt.base, middlewares)
+	rt.info.Middlewares = len(middlewares)
+}
+
+// recompose rebuilds the handler of every registered route and of unmatched requests, after middleware has been
+// added. The caller must hold r.mu.
+func (r *router) recompose() {
+	r.composeUnmatched()
+	for _, handlers := range r.routes {
+		for _, rt := range handlers {
+			r.compose(rt)
+		}
+	}
+}
+
+// Remove unregisters the route for method and path and reports whether there was one.
+func (r *router) Remove(method, path string) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	rt, ok := r.routes[method][path]
+	if !ok {
+		return false
+	}
+	delete(r.routes[method], path)
+	r.clearCache()
+	segments, _ := parseTemplate(path) // Valid, since the route was registered
+	for _, variant := range templateVariants(segments) {
+		// Optional parameters can make variants of different templates share a node; only remove this route.
+		if n := r.tree.find(variant, r.options.CaseInsensitive); n != nil && n.routes[method] == rt {
+			delete(n.routes, method)
+		}
+	}
+	r.last = slices.DeleteFunc(r.last, func(last *route) bool { return last == rt })
+	return true
+}
+
+// Replace swaps the handler and route middleware of the route for method and path and reports whether there
+// was one.
+func (r *router) Replace(method, path string, handler Handler, middlewares ...Middleware) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	old, ok := r.routes[method][path]
+	if !ok {
+		return false
+	}
+	// Requests in flight may still be reading the old route, so a new one takes its place instead.
+	rt := &route{info: old.info, base: handler, groups: old.groups, middlewares: middlewares}
+	rt.info.Handler = handlerName(handler)
+	r.compose(rt)
+	r.clearCache()
+
+	r.routes[method][path] = rt
+	segments, _ := parseTemplate(path)
+	for _, variant := range templateVariants(segments) {
+		if n := r.tree.find(variant, r.options.CaseInsensitive); n != nil && n.routes[method] == old {
+			n.routes[method] = rt
+		}
+	}
+	for i, last := range r.last {
+		if last == old {
+			r.last[i] = rt
+		}
+	}
+	return true
+}
+
+// Group returns a child router registering its routes on r under prefix, with the given middleware.
+func (r *router) Group(prefix string, middlewares ...Middleware) Router {
+	return &routerGroup{
+		root:        r,
+		prefix:      joinPaths("", prefix),
+		middlewares: middlewares,
+	}
+}
+
+// Routes returns a description of every registered route, sorted by path and method.
+func (r *router) Routes() []RouteInfo {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	var routes []RouteInfo
+	for _, handlers := range r.routes {
+		for _, route := range handlers {
+			routes = append(routes, route.info)
+		}
+	}
+	sortRoutes(routes)
+	return routes
+}
+
+// DumpRoutes writes the router's routes to w as JSON.
+func (r *router) DumpRoutes(w io.Writer) error {
+	return dumpRoutes(w, routeTable{Routes: r.Routes()})
+}
+
+// routeTable is the JSON document written by DumpRoutes.
+type routeTable struct {
+	Routes []RouteInfo `json:"routes"`           // Every route, sorted by path and method
+	Mounts []string    `json:"mounts,omitempty"` // Prefixes of the mounted routers and sub-applications, sorted
+}
+
+// dumpRoutes writes a route table to w as indented JSON.
+func dumpRoutes(w io.Writer, table routeTable) error {
+	if table.Routes == nil {
+		table.Routes = []RouteInfo{}
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(table)
+}
+
+// sortRoutes orders route descriptions by path, then by method.
+func sortRoutes(routes []RouteInfo) {
+	sort.Slice(routes, func(i, j int) bool {
+		if routes[i].Path != routes[j].Path {
+			return routes[i].Path < routes[j].Path
+		}
+		return routes[i].Method < routes[j].Method
+	})
+}
+
+// handlerName returns a readable name for a handler: the function name for function handlers
+// (e.g., "main.listUsers"), or the type name for other Handler implementations.
+func handlerName(handler Handler) string {
+	value := reflect.ValueOf(handler)
+	if value.Kind() == reflect.Func {
+		if fn := runtime.FuncForPC(value.Pointer()); fn != nil {
+			return fn.Name()
 		}
 	}
-	return "^" + strings.Join(regexParts, "/") + "$"
+	return fmt.Sprintf("%T", handler)
 }
@@ -2,44 +2,125 @@ package ghast
 
 import (
 	"bufio"
+	"context"
+	"crypto/tls"
+	"errors"
 	"fmt"
-	"log"
+	"io"
+	"io/fs"
+	"log/slog"
 	"net"
+	"os"
+	"strconv"
 	"strings"
+	"sync"
+	"time"
 )
 
+// defaultMaxRequestLineSize is the default limit on the length of the request line, in bytes.
+const defaultMaxRequestLineSize = 8 << 10
+
+// defaultMaxHeaderBytes is the default limit on the total size of the header lines of a request, in bytes.
+const defaultMaxHeaderBytes = 1 << 20
+
+// defaultMaxRequestBodySize is the default limit on the size of a request body, in bytes.
+const defaultMaxRequestBodySize = 10 << 20
+
+// defaultReadHeaderTimeout is the default time allowed to read the request line and headers of a request.
+const defaultReadHeaderTimeout = 10 * time.Second
+
+// defaultIdleTimeout is the default time a keep-alive connection may wait for its next request.
+const defaultIdleTimeout = 2 * time.Minute
+
+// errLineTooLong is returned by readLine for lines longer than its limit.
+var errLineTooLong = errors.New("line too long")
+
 // server represents an HTTP server that uses a Router to handle requests.
 // It manages TCP listening, connection handling, request parsing, and routing across multiple routers.
 // The server includes a root router for direct route registration and supports sub-routers with path prefixes.
 type server struct {
 	addr     string
-	listener net.Listener // TODO: Add listener for graceful shutdown
-	isDone   bool         // TODO: Add shutdown signal
+	mu       sync.Mutex        // Guards listener, socket, isDone and conns, which are shared between Listen and Shutdown
+	listener net.Listener      // Active listener, closed by Shutdown to stop the accept loop
+	socket   net.Listener      // Socket listener opened by listen, under any TLS or PROXY protocol wrapping, handed over by Restart
+	isDone   bool              // Set by Shutdown so the accept loop can tell a closed listener from a failure
+	conns    map[net.Conn]bool // Open connections and whether each is idle, waiting for its next request
+	wg       sync.WaitGroup    // Counts open connections, waited on (and force-closed if needed) by Shutdown
 
-	config *serverConfig // TODO: Add server configuration options (timeouts, max connections, etc.)
+	config *serverConfig // Server configuration, set through the options passed to New
 
 	requestHandler RequestHandler // Core request handling function that processes incoming requests and routes them
+	handler        Handler        // requestHandler as a Handler, under the RequestTimeout deadline if one is set
+
+	onListen func(addr net.Addr) // Optional callback invoked once the listener is open, used for the startup report
 
-	// TODO: Add fields for future improvements:
-	// - listener net.Listener (for graceful shutdown)
-	// - done chan struct{} (shutdown signal)
-	// - wg sync.WaitGroup (wait for goroutines)
-	// - config ServerConfig (timeouts, max connections, etc.)
+	http2     *http2Server // Server for connections negotiating HTTP/2, started by the first one
+	http2Once sync.Once
+
+	slots chan struct{} // Semaphore holding a slot per connection being served, when MaxConnections is set
 }
 
 // serverConfig holds configuration options for the server.
 // TODO: Implement and use this for:
-// - ReadTimeout / WriteTimeout
-// - MaxConnections / MaxRequestBodySize
-// - TLS/HTTPS support
+// - WriteTimeout
 // - Custom error handlers
 // - Access logging configuration
 type serverConfig struct {
 	// Placeholder for future configuration
-	Address                 string      // Server listen address (e.g., ":8080")
-	HidePort                bool        // Option to hide port in logs or responses
-	GracefulShutdownTimeout int         // Timeout in seconds for graceful shutdown
-	OnShutdownError         func(error) // Optional callback for shutdown errors
+	Address                 string                // Server listen address (e.g., ":8080")
+	HidePort                bool                  // Option to hide port in logs or responses
+	GracefulShutdownTimeout int                   // Seconds Shutdown waits for open connections before force-closing them; 0 waits as long as its context allows
+	OnShutdownError         func(error)           // Optional callback receiving the errors of Shutdown, joined
+	HideBanner              bool                  // Suppress the startup banner (the listen address is still logged)
+	PrintRoutes             bool                  // Print a table of all registered routes at startup
+	Logger                  *slog.Logger          // Logger for the server's own messages, set with SetLogger (default: slog.Default)
+	AllowedHosts            []string              // Hosts the server answers for, set with WithAllowedHosts (default: any)
+	MaxRequestLineSize      int                   // Maximum length of the request line in bytes; longer ones get 414 (default: 8 KB)
+	MaxHeaderBytes          int                   // Maximum total size of the header lines in bytes; larger ones get 431 (default: 1 MB)
+	MaxRequestBodySize      int                   // Maximum size of a request body in bytes; larger ones get 413 (default: 10 MB)
+	ReadHeaderTimeout       time.Duration         // Time allowed to read the request line and headers of a request, set with WithReadHeaderTimeout (default: 10s)
+	ReadTimeout             time.Duration         // Time allowed to read the body of a request, set with WithReadTimeout (default: no limit)
+	RequestTimeout          time.Duration         // Time a request's handler is given to respond, set with WithRequestTimeout (default: no limit)
+	IdleTimeout             time.Duration         // Time a keep-alive connection may wait for its next request, set with WithIdleTimeout (default: 2m)
+	RouterOptions           RouterOptions         // Options of the application's root router, set with WithRouterOptions
+	DisableHTTP2            bool                  // Serve TLS connections with HTTP/1.1 only, set with WithDisableHTTP2
+	H2C                     bool                  // Serve cleartext HTTP/2, by prior knowledge or by upgrade, set with WithH2C
+	MaxConnections          int                   // Maximum number of connections served at once, set with WithMaxConnections (default: no limit)
+	ConnectionLimitPolicy   ConnectionLimitPolicy // What happens to new connections over MaxConnections
+	BadRequestBody          string                // Body of the 400 responses to malformed requests, set with WithBadRequestBody (default: "400 Bad Request")
+	BadRequestContentType   string                // Content type of BadRequestBody
+	UnixSocketMode          fs.FileMode           // Permissions of the socket file when listening on a Unix domain socket, set with WithUnixSocketMode
+	ListenConfig            net.ListenConfig      // Configuration of the listeners opened by Listen, ListenTLS and ListenFastCGI, set with WithListenConfig
+	ReusePort               bool                  // Set SO_REUSEPORT on TCP listeners, set with WithReusePort
+	DisableNoDelay          bool                  // Turn off TCP_NODELAY on accepted TCP connections, set with WithDisableNoDelay
+	ProxyProtocol           bool                  // Read a PROXY protocol header from every connection, set with WithProxyProtocol
+	Workers                 int                   // Size of the worker pool serving connections, set with WithWorkerPool (default: a goroutine per connection)
+}
+
+// ConnectionLimitPolicy is how the server handles new connections while MaxConnections are being served.
+type ConnectionLimitPolicy int
+
+const (
+	ConnectionLimitQueue  ConnectionLimitPolicy = iota // Stop accepting until a connection closes; new clients wait in the listen backlog
+	ConnectionLimitReject                              // Answer the connection with 503 Service Unavailable and close it
+)
+
+// defaultServerConfig returns the configuration used when no options are given.
+func defaultServerConfig() *serverConfig {
+	c := &serverConfig{
+		Address:                 ":8080",
+		HidePort:                false,
+		GracefulShutdownTimeout: 30,
+		MaxRequestLineSize:      defaultMaxRequestLineSize,
+		MaxHeaderBytes:          defaultMaxHeaderBytes,
+		MaxRequestBodySize:      defaultMaxRequestBodySize,
+		ReadHeaderTimeout:       defaultReadHeaderTimeout,
+		IdleTimeout:             defaultIdleTimeout,
+	}
+	c.OnShutdownError = func(err error) {
+		c.logger().Error("Error during shutdown", "err", err)
+	}
+	return c
 }
 
 type RequestHandler interface {
@@ -49,105 +130,417 @@ type RequestHandler interface {
 // newServer creates a new server with a default root router and empty sub-router map.
 func newServer(handler RequestHandler, config *serverConfig) *server {
 	if config == nil {
-		config = &serverConfig{
-			Address:                 ":8080",
-			HidePort:                false,
-			GracefulShutdownTimeout: 30,
-			OnShutdownError: func(err error) {
-				log.Printf("Error during shutdown: %v", err)
-			},
-		}
+		config = defaultServerConfig()
 	}
-	return &server{
+	s := &server{
 		config:         config,
 		requestHandler: handler,
+		handler:        HandlerFunc(handler.handleRequest),
 	}
+	if config.RequestTimeout > 0 {
+		s.handler = withRequestTimeout(config.RequestTimeout)(s.handler)
+	}
+	if config.MaxConnections > 0 {
+		s.slots = make(chan struct{}, config.MaxConnections)
+	}
+	return s
 }
 
-// Listen starts the HTTP server on the given address (e.g., ":8080").
+// Listen starts the HTTP server on the given address (e.g., ":8080", or "unix:/var/run/ghast.sock").
 func (s *server) Listen(addr string) error {
 	s.addr = addr
 
-	ln, err := net.Listen("tcp", addr)
+	ln, err := s.listen(addr)
 	if err != nil {
 		return err
 	}
+	return s.Serve(ln)
+}
+
+// Serve accepts connections on ln and serves them until Shutdown is called. The listener is closed on return.
+func (s *server) Serve(ln net.Listener) error {
 	defer ln.Close()
+	if s.addr == "" {
+		s.addr = ln.Addr().String()
+	}
 
+	s.mu.Lock()
+	if s.isDone {
+		s.mu.Unlock()
+		return nil
+	}
 	s.listener = ln // Store listener for graceful shutdown support
+	s.mu.Unlock()
 
-	log.Printf("🌪️  Ghast server listening on %s", addr)
+	notifyReady()
+	if s.onListen != nil {
+		s.onListen(ln.Addr())
+	} else {
+		s.config.logger().Info("🌪️  Ghast server listening", "addr", s.addr)
+	}
+
+	var jobs chan<- net.Conn
+	if s.config.Workers > 0 {
+		jobs = s.startWorkers(s.config.Workers)
+		defer close(jobs)
+	}
 
+	// Queued connections take their slot before they are accepted, so at the limit new clients wait in the
+	// listen backlog rather than in an accepted connection nobody reads.
+	queue := s.slots != nil && s.config.ConnectionLimitPolicy == ConnectionLimitQueue
 	for {
+		if queue {
+			s.slots <- struct{}{}
+		}
 		conn, err := ln.Accept()
 		if err != nil {
-			// TODO: Implement graceful shutdown check
-			// if s.isDone() { return nil }
-			log.Printf("Error accepting connection: %v", err)
+			if queue {
+				<-s.slots
+			}
+			if s.shuttingDown() || errors.Is(err, net.ErrClosed) {
+				return nil
+			}
+			s.config.logger().Error("Error accepting connection", "err", err)
 			continue
 		}
 
-		// TODO: Add connection pooling / limiting
 		// TODO: Add per-connection metrics and logging
-		go s.handleConnection(conn)
+		if s.slots != nil && !queue {
+			select {
+			case s.slots <- struct{}{}:
+			default:
+				go rejectConnection(conn, 503)
+				continue
+			}
+		}
+		if jobs != nil {
+			jobs <- conn
+		} else {
+			go s.serveConn(conn)
+		}
+	}
+}
+
+// startWorkers starts a pool of n goroutines serving the connections sent on the returned channel, until it is
+// closed. Sending blocks while every worker is busy, so the accept loop stops accepting.
+func (s *server) startWorkers(n int) chan<- net.Conn {
+	jobs := make(chan net.Conn)
+	for range n {
+		go func() {
+			for conn := range jobs {
+				s.serveConn(conn)
+			}
+		}()
+	}
+	return jobs
+}
+
+// idleTimeout returns how long a keep-alive connection may wait for its next request. A connection holding a
+// MaxConnections slot or a pool worker is always given a limit, so idle clients can't keep new ones out for good.
+func (s *server) idleTimeout() time.Duration {
+	if s.config.IdleTimeout == 0 && (s.slots != nil || s.config.Workers > 0) {
+		return defaultIdleTimeout
+	}
+	return s.config.IdleTimeout
+}
+
+// serveConn serves a connection and releases its MaxConnections slot, if any, once it closes.
+func (s *server) serveConn(conn net.Conn) {
+	if s.slots != nil {
+		defer func() { <-s.slots }()
+	}
+	s.handleConnection(conn)
+}
+
+// rejectConnection answers a connection the server will not serve with statusCode, without reading its request,
+// and closes it. Its write side is closed first and the request drained briefly, so the client reads the
+// response instead of a connection reset.
+func rejectConnection(conn net.Conn, statusCode int) {
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(time.Second))
+	rejectRequest(conn, statusCode)
+	if cw, ok := conn.(interface{ CloseWrite() error }); ok && cw.CloseWrite() == nil {
+		io.Copy(io.Discard, io.LimitReader(conn, 256<<10))
+	}
+}
+
+// Shutdown stops the server from accepting new connections by closing its listener, closes the keep-alive
+// connections waiting for their next request, then waits for the requests in flight to finish. If ctx is done
+// first, or GracefulShutdownTimeout passes, the remaining connections are force-closed and the context error is
+// returned along with any errors from closing the listener and connections.
+func (s *server) Shutdown(ctx context.Context) error {
+	var errs []error
+
+	s.mu.Lock()
+	s.isDone = true
+	if s.listener != nil {
+		if err := s.listener.Close(); err != nil && !errors.Is(err, net.ErrClosed) {
+			errs = append(errs, err)
+		}
+	}
+	errs = append(errs, s.closeConnections(true)...)
+	s.mu.Unlock()
+
+	if timeout := s.config.GracefulShutdownTimeout; timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, time.Duration(timeout)*time.Second)
+		defer cancel()
+	}
+
+	s.mu.Lock()
+	if s.http2 != nil {
+		go s.http2.shutdown(ctx)
+	}
+	s.mu.Unlock()
+
+	done := make(chan struct{})
+	go func() {
+		s.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-ctx.Done():
+		errs = append(errs, ctx.Err())
+		s.mu.Lock()
+		errs = append(errs, s.closeConnections(false)...)
+		s.mu.Unlock()
 	}
+	return errors.Join(errs...)
 }
 
-// Shutdown gracefully shuts down the server.
-// TODO: Implement this to:
-// - Signal all goroutines to stop accepting connections
-// - Wait for existing requests to complete
-// - Close the listener
-// - Return after all connections are closed
-func (s *server) Shutdown() error {
-	// Placeholder for graceful shutdown implementation
-	return nil
+// trackConn adds a connection to the set of open connections, or removes it. It reports false, without adding
+// the connection, once Shutdown has been called; the caller should then close the connection.
+func (s *server) trackConn(conn net.Conn, add bool) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !add {
+		if _, ok := s.conns[conn]; ok {
+			delete(s.conns, conn)
+			s.wg.Done()
+		}
+		return true
+	}
+	if s.isDone {
+		return false
+	}
+	if s.conns == nil {
+		s.conns = make(map[net.Conn]bool)
+	}
+	s.conns[conn] = false
+	s.wg.Add(1)
+	return true
+}
+
+// setIdle marks a connection as waiting for its next request, or as serving one. It reports false when the
+// connection is to become idle during shutdown, in which case the caller should close it instead.
+func (s *server) setIdle(conn net.Conn, idle bool) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if idle && s.isDone {
+		return false
+	}
+	s.conns[conn] = idle
+	return true
+}
+
+// closeConnections force-closes the open connections, or only the idle ones, and returns the errors
+// encountered. The caller must hold s.mu.
+func (s *server) closeConnections(idleOnly bool) []error {
+	var errs []error
+	for conn, idle := range s.conns {
+		if idleOnly && !idle {
+			continue
+		}
+		if err := conn.Close(); err != nil && !errors.Is(err, net.ErrClosed) {
+			errs = append(errs, err)
+		}
+	}
+	return errs
+}
+
+// shuttingDown reports whether Shutdown has been called.
+func (s *server) shuttingDown() bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.isDone
 }
 
 // handleConnection processes a single TCP connection and handles HTTP requests.
 // It focuses purely on TCP connection I/O: reading request headers/body, parsing, and extracting metadata.
 func (s *server) handleConnection(conn net.Conn) {
+	if !s.trackConn(conn, true) {
+		conn.Close()
+		return
+	}
+	defer s.trackConn(conn, false)
 	defer conn.Close()
 
+	if tlsConn, ok := conn.(*tls.Conn); ok {
+		// Complete the handshake before reading, to find out which protocol the client negotiated
+		if !s.setIdle(conn, true) {
+			return
+		}
+		setReadTimeout(conn, s.config.ReadHeaderTimeout)
+		if err := tlsConn.Handshake(); err != nil {
+			return
+		}
+		s.setIdle(conn, false)
+		setReadTimeout(conn, 0)
+		if tlsConn.ConnectionState().NegotiatedProtocol == "h2" {
+			s.serveHTTP2(tlsConn)
+			return
+		}
+	}
+
 	reader := bufio.NewReader(conn)
 
-	for {
-		// Read HTTP request headers
-		var headerLines []string
+	for first := true; ; first = false {
+		// Wait for the next request as an idle connection, which Shutdown closes right away, until its first
+		// byte arrives
+		if !s.setIdle(conn, true) {
+			return
+		}
+		if first {
+			setReadTimeout(conn, s.config.ReadHeaderTimeout)
+		} else {
+			setReadTimeout(conn, s.idleTimeout())
+		}
+		if _, err := reader.Peek(1); err != nil {
+			return
+		}
+		s.setIdle(conn, false)
+
+		// Bound the time to read the rest of the request line and headers, so a client trickling them in can't
+		// hold the connection
+		setReadTimeout(conn, s.config.ReadHeaderTimeout)
+		if first && s.config.H2C && hasH2CPreface(reader) {
+			setReadTimeout(conn, 0)
+			s.serveHTTP2(&bufferedConn{Conn: conn, reader: reader})
+			return
+		}
+
+		// Read the request line, bounded so a pathological URI can't grow the buffer without limit
+		requestLine, err := readLine(reader, s.config.MaxRequestLineSize)
+		if errors.Is(err, errLineTooLong) {
+			s.rejectRequest(conn, 414)
+			return
+		}
+		if errors.Is(err, os.ErrDeadlineExceeded) {
+			s.rejectRequest(conn, 408)
+			return
+		}
+		if err != nil || requestLine == "\r\n" {
+			return
+		}
+
+		// Read HTTP request headers, bounded in total like the request line
+		headerLines := []string{strings.TrimRight(requestLine, "\r\n")}
+		headerBytes := 0
 		for {
-			line, err := reader.ReadString('\n')
+			remaining := s.config.MaxHeaderBytes - headerBytes
+			if s.config.MaxHeaderBytes > 0 && remaining <= 0 {
+				s.rejectRequest(conn, 431)
+				return
+			}
+			line, err := readLine(reader, remaining)
+			if errors.Is(err, errLineTooLong) {
+				s.rejectRequest(conn, 431)
+				return
+			}
+			if errors.Is(err, os.ErrDeadlineExceeded) {
+				s.rejectRequest(conn, 408)
+				return
+			}
 			if err != nil {
 				return
 			}
 			if line == "\r\n" {
 				break
 			}
+			headerBytes += len(line)
 			headerLines = append(headerLines, strings.TrimRight(line, "\r\n"))
 		}
 
-		if len(headerLines) == 0 {
+		// Parse the request into a pooled Request, recycled once the response is finished
+		req := acquireRequest()
+		if err := parseRequestInto(req, strings.Join(headerLines, "\r\n")); err != nil {
+			releaseRequest(req)
+			// Tell the client why instead of silently dropping the connection
+			s.rejectRequest(conn, 400)
+			return
+		}
+		if statusCode := checkBodyFraming(req); statusCode != 0 {
+			releaseRequest(req)
+			s.rejectRequest(conn, statusCode)
+			return
+		}
+		if statusCode := s.checkHost(req); statusCode != 0 {
+			releaseRequest(req)
+			s.rejectRequest(conn, statusCode)
 			return
 		}
 
-		// Parse the request
-		req, err := parseRequest(strings.Join(headerLines, "\r\n"))
-		if err != nil {
-			// TODO: Send proper error response to client
+		// With h2c, a cleartext request can upgrade its connection to HTTP/2 and be answered as its first stream
+		if _, isTLS := conn.(*tls.Conn); s.config.H2C && !isTLS && isH2CUpgrade(req, headerLines[0]) {
+			settings, _ := h2cSettings(req)
+			upgraded := &h2cUpgradeConn{Conn: conn, reader: reader, settings: settings, request: h2cUpgradeRequest(req, headerLines[0])}
+			releaseRequest(req)
+			if _, err := io.WriteString(conn, h2cSwitchingProtocols); err != nil {
+				return
+			}
+			setReadTimeout(conn, 0)
+			s.serveHTTP2(upgraded)
 			return
 		}
 
+		// Expect: 100-continue asks for a go-ahead before the body is sent, given once the request is known to be
+		// acceptable; other expectations can't be met (RFC 9110 section 10.1.1)
+		expectContinue := false
+		if expect, found := req.Headers["Expect"]; found && req.Version != "HTTP/1.0" {
+			if !strings.EqualFold(strings.TrimSpace(expect), "100-continue") {
+				releaseRequest(req)
+				s.rejectRequest(conn, 417)
+				return
+			}
+			expectContinue = true
+		}
+
 		// Read request body if Content-Length is present
-		if contentLength := req.Headers["Content-Length"]; contentLength != "" {
-			var length int
-			fmt.Sscanf(contentLength, "%d", &length)
+		if contentLength, found := req.Headers["Content-Length"]; found {
+			length, err := parseContentLength(contentLength)
+			if err != nil {
+				releaseRequest(req)
+				s.rejectRequest(conn, 400)
+				return
+			}
+			if s.config.MaxRequestBodySize > 0 && length > s.config.MaxRequestBodySize {
+				// Refuse before reading (or allocating for) the body
+				releaseRequest(req)
+				s.rejectRequest(conn, 413)
+				return
+			}
 			if length > 0 {
-				// TODO: Add configurable max body size limit
-				bodyBytes := make([]byte, length)
-				reader.Read(bodyBytes)
-				req.Body = string(bodyBytes)
+				if expectContinue {
+					if _, err := io.WriteString(conn, "HTTP/1.1 100 Continue\r\n\r\n"); err != nil {
+						releaseRequest(req)
+						return
+					}
+				}
+				req.Body = make([]byte, length)
+				setReadTimeout(conn, s.config.ReadTimeout)
+				if _, err := io.ReadFull(reader, req.Body); err != nil {
+					releaseRequest(req)
+					if errors.Is(err, os.ErrDeadlineExceeded) {
+						s.rejectRequest(conn, 408)
+					}
+					return
+				}
 			}
 		}
 
+		setReadTimeout(conn, 0)
+
 		// Extract client IP for logging or middleware use.
 		// Very basic implementation - in production, handle proxies and X-Forwarded-For headers.
 		// See echo's ip.go for reference: https://github.com/labstack/echo/blob/master/ip.go
@@ -159,25 +552,268 @@ func (s *server) handleConnection(conn net.Conn) {
 		}
 
 		// Create response writer and serve the request through routing logic
-		rw := newResponseWriter(conn)
-		s.requestHandler.handleRequest(rw, req)
+		ctx, cancel := context.WithCancel(context.Background())
+		req.ctx = ctx
+		rw := acquireResponseWriter(conn)
+		rw.req = req
+		if !shouldKeepAlive(req) || s.shuttingDown() {
+			// Tell the client up front, so it doesn't send another request on a connection about to close
+			rw.SetHeader("Connection", "close")
+		}
+		s.handler.ServeHTTP(rw, req)
+		rw.finish()
+		cancel()
 
-		// Check for connection keep-alive
-		if shouldKeepAlive(req) {
+		// Check for connection keep-alive. Handlers and middleware can end the connection by setting
+		// Connection: close on the response, as responses without a length (such as event streams) do, and a
+		// draining server closes connections once their in-flight request is served.
+		keepAlive := shouldKeepAlive(req) && !responseCloses(rw.headers) && !s.shuttingDown()
+		releaseResponseWriter(rw)
+		releaseRequest(req)
+		if keepAlive {
 			continue
 		} else {
 			return
 		}
 
-		// TODO: Add request timeout handling
-		// TODO: Add support for HTTP/1.1 100 Continue
 	}
 }
 
-// shouldKeepAlive checks the Connection header to determine if the connection should be kept alive.
+// serveHTTP2 serves an HTTP/2 connection, negotiated through TLS or opened with the cleartext preface, until it
+// is closed.
+func (s *server) serveHTTP2(conn net.Conn) {
+	s.mu.Lock()
+	s.http2Once.Do(func() {
+		s.http2 = newHTTP2Server(s.handler, s.config)
+	})
+	s.mu.Unlock()
+	s.http2.serve(conn)
+}
+
+// h2cPreface is the connection preface of HTTP/2, which clients with prior knowledge of HTTP/2 support send
+// first on a cleartext connection.
+const h2cPreface = "PRI * HTTP/2.0\r\n\r\nSM\r\n\r\n"
+
+// hasH2CPreface reports whether the data buffered by r starts with the HTTP/2 preface, without consuming it.
+// It only waits for the whole preface once the first bytes match, since no HTTP/1 method is "PRI".
+func hasH2CPreface(r *bufio.Reader) bool {
+	if start, err := r.Peek(3); err != nil || string(start) != "PRI" {
+		return false
+	}
+	preface, err := r.Peek(len(h2cPreface))
+	return err == nil && string(preface) == h2cPreface
+}
+
+// bufferedConn is a connection whose first bytes have already been read into a buffer.
+type bufferedConn struct {
+	net.Conn
+	reader *bufio.Reader
+}
+
+func (c *bufferedConn) Read(p []byte) (int, error) {
+	return c.reader.Read(p)
+}
+
+// parseContentLength parses a Content-Length value strictly: one or more ASCII digits, with no sign, spaces or
+// other characters, that fit in an int. Lenient parsing (such as accepting "12abc" as 12) would let the server
+// and a proxy in front of it disagree on where the body ends.
+func parseContentLength(value string) (int, error) {
+	if value == "" {
+		return 0, errors.New("empty Content-Length")
+	}
+	for _, c := range []byte(value) {
+		if c < '0' || c > '9' {
+			return 0, fmt.Errorf("invalid Content-Length %q", value)
+		}
+	}
+	length, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, fmt.Errorf("invalid Content-Length %q: %w", value, err)
+	}
+	return length, nil
+}
+
+// readLine reads a line including its terminating newline. A limit greater than zero caps the line length;
+// a longer line returns errLineTooLong once the limit is passed, without buffering the rest of it.
+func readLine(r *bufio.Reader, limit int) (string, error) {
+	var line []byte
+	for {
+		chunk, err := r.ReadSlice('\n')
+		line = append(line, chunk...)
+		if limit > 0 && len(line) > limit {
+			return "", errLineTooLong
+		}
+		if errors.Is(err, bufio.ErrBufferFull) {
+			continue
+		}
+		if err != nil {
+			return "", err
+		}
+		return string(line), nil
+	}
+}
+
+// setReadTimeout sets the read deadline of conn to timeout from now, or clears it if timeout is zero.
+func setReadTimeout(conn net.Conn, timeout time.Duration) {
+	if timeout > 0 {
+		conn.SetReadDeadline(time.Now().Add(timeout))
+	} else {
+		conn.SetReadDeadline(time.Time{})
+	}
+}
+
+// rejectRequest writes a plain text error response for a request that won't be served and tells the client the
+// connection is closing.
+func rejectRequest(conn net.Conn, statusCode int) {
+	writeRejection(conn, statusCode, "text/plain", fmt.Sprintf("%d %s", statusCode, httpStatusText(statusCode)))
+}
+
+// rejectRequest rejects a request like the rejectRequest function, with BadRequestBody for 400 responses if set.
+func (s *server) rejectRequest(conn net.Conn, statusCode int) {
+	if statusCode == 400 && s.config.BadRequestBody != "" {
+		writeRejection(conn, statusCode, s.config.BadRequestContentType, s.config.BadRequestBody)
+		return
+	}
+	rejectRequest(conn, statusCode)
+}
+
+// writeRejection writes the error response of a rejected request, closing the connection.
+func writeRejection(conn net.Conn, statusCode int, contentType, body string) {
+	rw := newResponseWriter(conn)
+	rw.Status(statusCode).SetHeader("Connection", "close").SetHeader("Content-Type", contentType)
+	rw.SendString(body)
+}
+
+// checkBodyFraming rejects requests whose body length is ambiguous, which a proxy in front of the server could
+// read differently (request smuggling, RFC 9112 section 6.3). A request with both Transfer-Encoding and
+// Content-Length gets 400; one with only Transfer-Encoding gets 501, since the server reads bodies by
+// Content-Length alone and would otherwise parse the body as the next request.
+func checkBodyFraming(req *Request) int {
+	if _, found := req.Headers["Transfer-Encoding"]; !found {
+		return 0
+	}
+	if _, found := req.Headers["Content-Length"]; found {
+		return 400
+	}
+	return 501
+}
+
+// checkHost validates the Host header and returns the status code to reject the request with, or 0 to serve
+// it. HTTP/1.1 requests must carry a syntactically valid Host (400 otherwise), and when AllowedHosts is set the
+// host must be one of them (421 Misdirected Request otherwise), so generated URLs can't be poisoned.
+func (s *server) checkHost(req *Request) int {
+	host, found := req.Headers["Host"]
+	if !found {
+		if req.Version == "HTTP/1.0" {
+			return 0 // Host is optional before HTTP/1.1
+		}
+		return 400
+	}
+	if !isValidHost(host) {
+		return 400
+	}
+	if len(s.config.AllowedHosts) > 0 && !hostAllowed(host, s.config.AllowedHosts) {
+		return 421
+	}
+	return 0
+}
+
+// isValidHost reports whether host matches the uri-host [ ":" port ] syntax of RFC 9110: a registered name or
+// IPv4 address, or an IPv6 literal in brackets, optionally followed by a numeric port.
+func isValidHost(host string) bool {
+	if host == "" {
+		return false
+	}
+	name, port := host, ""
+	if strings.HasPrefix(host, "[") {
+		end := strings.IndexByte(host, ']')
+		if end < 0 || net.ParseIP(host[1:end]) == nil {
+			return false
+		}
+		name, port = "", host[end+1:]
+		if port != "" {
+			if port[0] != ':' {
+				return false
+			}
+			port = port[1:]
+		}
+	} else if i := strings.LastIndexByte(host, ':'); i >= 0 {
+		name, port = host[:i], host[i+1:]
+	}
+	for _, c := range []byte(port) {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	for _, c := range []byte(name) {
+		if !isHostNameChar(c) {
+			return false
+		}
+	}
+	return true
+}
+
+// isHostNameChar reports whether c may appear in a registered name: unreserved characters, sub-delimiters and
+// percent-encoding.
+func isHostNameChar(c byte) bool {
+	switch {
+	case 'a' <= c && c <= 'z', 'A' <= c && c <= 'Z', '0' <= c && c <= '9':
+		return true
+	}
+	return strings.IndexByte("-._~%!$&'()*+,;=", c) >= 0
+}
+
+// hostAllowed reports whether host, ignoring any port, matches one of the allowed hosts. Matching is
+// case-insensitive, and an entry starting with "*." matches any subdomain of the rest.
+func hostAllowed(host string, allowed []string) bool {
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	host = strings.TrimSuffix(strings.Trim(host, "[]"), ".")
+	for _, pattern := range allowed {
+		if suffix, ok := strings.CutPrefix(pattern, "*."); ok {
+			if len(host) > len(suffix)+1 && strings.EqualFold(host[len(host)-len(suffix)-1:], "."+suffix) {
+				return true
+			}
+		} else if strings.EqualFold(host, pattern) {
+			return true
+		}
+	}
+	return false
+}
+
+// responseCloses reports whether the response headers include the "close" connection option.
+func responseCloses(headers map[string]string) bool {
+	for key, value := range headers {
+		if strings.EqualFold(key, "Connection") && hasToken(value, "close") {
+			return true
+		}
+	}
+	return false
+}
+
+// hasToken reports whether the comma-separated header value contains token, compared case-insensitively.
+func hasToken(value, token string) bool {
+	for part := range strings.SplitSeq(value, ",") {
+		if strings.EqualFold(strings.TrimSpace(part), token) {
+			return true
+		}
+	}
+	return false
+}
+
+// shouldKeepAlive reports whether the client wants the connection kept open after this request (RFC 9112
+// section 9.3): HTTP/1.1 connections are persistent unless the client sends Connection: close, and HTTP/1.0
+// connections only when it sends Connection: keep-alive.
 func shouldKeepAlive(req *Request) bool {
 	connHeader := req.Headers["Connection"]
-	return strings.EqualFold(connHeader, "keep-alive")
+	if hasToken(connHeader, "close") {
+		return false
+	}
+	if req.Version == "HTTP/1.0" {
+		return hasToken(connHeader, "keep-alive")
+	}
+	return true
 }
 
 // Note: Request parsing (headers, query params, etc.) is delegated to ParseRequest()
@@ -1,8 +1,19 @@
 package ghast
 
 import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"io"
 	"log"
+	"net"
+	"net/http"
+	"strings"
+	"sync/atomic"
 	"testing"
+	"time"
 )
 
 type testHandler struct{}
@@ -39,3 +50,1133 @@ func CreateNewServerWithDefaultConfigTest(t *testing.T) {
 		t.Error("Expected server to have a non-nil request handler")
 	}
 }
+
+// startTestApp starts app on a random loopback port and returns the address it listens on.
+func startTestApp(t *testing.T, app *Ghast) string {
+	t.Helper()
+	addrs := make(chan string, 1)
+	app.server.onListen = func(addr net.Addr) { addrs <- addr.String() }
+	go app.Listen("127.0.0.1:0")
+
+	select {
+	case addr := <-addrs:
+		return addr
+	case <-time.After(2 * time.Second):
+		t.Fatal("server did not start listening")
+		return ""
+	}
+}
+
+// TestShutdownForceClosesOnContextDeadline tests that connections still open at the deadline are closed
+func TestShutdownForceClosesOnContextDeadline(t *testing.T) {
+	app := New()
+	addr := startTestApp(t, app)
+
+	conn, err := net.Dial("tcp", addr)
+	if err != nil {
+		t.Fatalf("dial failed: %v", err)
+	}
+	defer conn.Close()
+	// Send an incomplete request so the connection stays open
+	conn.Write([]byte("GET / HTTP/1.1\r\n"))
+	time.Sleep(50 * time.Millisecond)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	err = app.Shutdown(ctx)
+
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("expected deadline exceeded, got %v", err)
+	}
+	conn.SetReadDeadline(time.Now().Add(time.Second))
+	if _, err := conn.Read(make([]byte, 1)); err == nil {
+		t.Error("expected connection to be closed by shutdown")
+	}
+}
+
+// TestShutdownWaitsForConnections tests that Shutdown returns once open connections finish
+func TestShutdownWaitsForConnections(t *testing.T) {
+	app := New()
+	app.Get("/", HandlerFunc(func(w ResponseWriter, r *Request) {
+		time.Sleep(100 * time.Millisecond)
+		w.Plain(200, "done")
+	}))
+	addr := startTestApp(t, app)
+
+	conn, err := net.Dial("tcp", addr)
+	if err != nil {
+		t.Fatalf("dial failed: %v", err)
+	}
+	defer conn.Close()
+	conn.Write([]byte("GET / HTTP/1.1\r\nHost: localhost\r\nConnection: close\r\n\r\n"))
+	time.Sleep(20 * time.Millisecond)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	if err := app.Shutdown(ctx); err != nil {
+		t.Errorf("expected clean shutdown, got %v", err)
+	}
+
+	response, _ := io.ReadAll(conn)
+	if !strings.Contains(string(response), "done") {
+		t.Errorf("in-flight request was not completed: %q", response)
+	}
+}
+
+// TestShutdownClosesIdleConnections tests that keep-alive connections waiting for a request don't hold up Shutdown
+func TestShutdownClosesIdleConnections(t *testing.T) {
+	app := New()
+	app.Get("/", HandlerFunc(func(w ResponseWriter, r *Request) { w.Plain(200, "ok") }))
+	addr := startTestApp(t, app)
+
+	conn, err := net.Dial("tcp", addr)
+	if err != nil {
+		t.Fatalf("dial failed: %v", err)
+	}
+	defer conn.Close()
+	conn.Write([]byte("GET / HTTP/1.1\r\nHost: localhost\r\n\r\n"))
+	conn.SetReadDeadline(time.Now().Add(time.Second))
+	if _, err := conn.Read(make([]byte, 512)); err != nil {
+		t.Fatalf("read failed: %v", err)
+	}
+
+	start := time.Now()
+	if err := app.Shutdown(context.Background()); err != nil {
+		t.Errorf("expected clean shutdown, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Shutdown waited %v for an idle connection", elapsed)
+	}
+	if _, err := conn.Read(make([]byte, 1)); err == nil {
+		t.Error("expected the idle connection to be closed")
+	}
+}
+
+// TestShutdownTimeout tests that the graceful shutdown timeout bounds the wait and errors reach OnShutdownError
+func TestShutdownTimeout(t *testing.T) {
+	app := New()
+	var reported error
+	app.config.GracefulShutdownTimeout = 1
+	app.config.OnShutdownError = func(err error) { reported = err }
+	addr := startTestApp(t, app)
+
+	conn, err := net.Dial("tcp", addr)
+	if err != nil {
+		t.Fatalf("dial failed: %v", err)
+	}
+	defer conn.Close()
+	conn.Write([]byte("GET / HTTP/1.1\r\n")) // Incomplete, so the connection stays busy
+	time.Sleep(50 * time.Millisecond)
+
+	err = app.Shutdown(context.Background())
+	if !errors.Is(err, context.DeadlineExceeded) || reported != err {
+		t.Errorf("expected deadline exceeded passed to OnShutdownError, got %v and %v", err, reported)
+	}
+}
+
+// TestH2C tests that cleartext HTTP/2 clients with prior knowledge are served, and h2c upgrades get HTTP/1.1
+func TestH2C(t *testing.T) {
+	app := New(WithH2C())
+	app.Get("/users/:id", HandlerFunc(func(w ResponseWriter, r *Request) { w.Plain(200, "user "+r.Param("id")) }))
+	addr := startTestApp(t, app)
+	defer app.Shutdown(context.Background())
+
+	protocols := new(http.Protocols)
+	protocols.SetUnencryptedHTTP2(true)
+	client := &http.Client{Transport: &http.Transport{Protocols: protocols}}
+	defer client.CloseIdleConnections()
+	res, err := client.Get("http://" + addr + "/users/7")
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	body, _ := io.ReadAll(res.Body)
+	res.Body.Close()
+	if res.Proto != "HTTP/2.0" || string(body) != "user 7" {
+		t.Errorf("expected HTTP/2.0 with user 7, got %s %q", res.Proto, body)
+	}
+
+	// Without Upgrade and HTTP2-Settings as connection options, or with a body, the request isn't upgraded
+	for _, raw := range []string{
+		"GET /users/8 HTTP/1.1\r\nHost: localhost\r\nUpgrade: h2c\r\nHTTP2-Settings: AAMAAABkAAQCAAAAAAIAAAAA\r\n" +
+			"Connection: close\r\n\r\n",
+		"POST /users/8 HTTP/1.1\r\nHost: localhost\r\nUpgrade: h2c\r\nHTTP2-Settings: AAMAAABkAAQCAAAAAAIAAAAA\r\n" +
+			"Connection: Upgrade, HTTP2-Settings, close\r\nContent-Length: 2\r\n\r\n{}",
+	} {
+		response := rawRoundTrip(t, addr, raw)
+		if !strings.HasPrefix(response, "HTTP/1.1 ") || strings.HasPrefix(response, "HTTP/1.1 101") {
+			t.Errorf("expected the request to be answered over HTTP/1.1, got %q", response)
+		}
+	}
+}
+
+// TestH2CUpgrade tests that a request upgrading to h2c gets 101 Switching Protocols and its response as stream 1
+func TestH2CUpgrade(t *testing.T) {
+	app := New(WithH2C())
+	app.Get("/users/:id", HandlerFunc(func(w ResponseWriter, r *Request) {
+		w.Plain(200, "user "+r.Param("id")+" "+r.GetHeader("X-Trace"))
+	}))
+	addr := startTestApp(t, app)
+	defer app.Shutdown(context.Background())
+
+	conn, err := net.Dial("tcp", addr)
+	if err != nil {
+		t.Fatalf("dial failed: %v", err)
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(2 * time.Second))
+	conn.Write([]byte("GET /users/8?full=1 HTTP/1.1\r\nHost: localhost\r\nX-Trace: abc\r\n" +
+		"Connection: Upgrade, HTTP2-Settings\r\nUpgrade: h2c\r\nHTTP2-Settings: AAMAAABkAAQCAAAAAAIAAAAA\r\n\r\n"))
+
+	reader := bufio.NewReader(conn)
+	status, _ := reader.ReadString('\n')
+	if status != "HTTP/1.1 101 Switching Protocols\r\n" {
+		t.Fatalf("expected 101 Switching Protocols, got %q", status)
+	}
+	for line := status; line != "\r\n"; {
+		if line, err = reader.ReadString('\n'); err != nil {
+			t.Fatalf("reading 101 response: %v", err)
+		}
+	}
+
+	// Open the HTTP/2 connection with the preface and an empty SETTINGS frame, then read stream 1's response
+	conn.Write([]byte(h2cPreface + "\x00\x00\x00\x04\x00\x00\x00\x00\x00"))
+	var body []byte
+	for {
+		var header [9]byte
+		if _, err := io.ReadFull(reader, header[:]); err != nil {
+			t.Fatalf("reading frame: %v", err)
+		}
+		payload := make([]byte, int(header[0])<<16|int(header[1])<<8|int(header[2]))
+		if _, err := io.ReadFull(reader, payload); err != nil {
+			t.Fatalf("reading frame: %v", err)
+		}
+		frameType, flags, stream := header[3], header[4], binary.BigEndian.Uint32(header[5:])&0x7fffffff
+		if frameType == 0x7 { // GOAWAY
+			t.Fatalf("connection closed with GOAWAY %x", payload)
+		}
+		if stream != 1 {
+			continue
+		}
+		if frameType == 0x0 { // DATA
+			body = append(body, payload...)
+		}
+		if flags&http2FlagEndStream != 0 {
+			break
+		}
+	}
+	if string(body) != "user 8 abc" {
+		t.Errorf("expected the upgraded request's response on stream 1, got %q", body)
+	}
+}
+
+// TestH2CUpgradeSettings tests that the HTTP2-Settings of an upgrade request apply from the start of the
+// connection, here limiting the first stream's flow-control window to 4 bytes, and are not acknowledged
+func TestH2CUpgradeSettings(t *testing.T) {
+	app := New(WithH2C())
+	app.Get("/", HandlerFunc(func(w ResponseWriter, r *Request) { w.Plain(200, "0123456789") }))
+	addr := startTestApp(t, app)
+	defer app.Shutdown(context.Background())
+
+	conn, err := net.Dial("tcp", addr)
+	if err != nil {
+		t.Fatalf("dial failed: %v", err)
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(2 * time.Second))
+	conn.Write([]byte("GET / HTTP/1.1\r\nHost: localhost\r\n" +
+		"Connection: Upgrade, HTTP2-Settings\r\nUpgrade: h2c\r\nHTTP2-Settings: AAQAAAAE\r\n\r\n"))
+
+	reader := bufio.NewReader(conn)
+	for line := ""; line != "\r\n"; {
+		if line, err = reader.ReadString('\n'); err != nil {
+			t.Fatalf("reading 101 response: %v", err)
+		}
+	}
+	conn.Write([]byte(h2cPreface + "\x00\x00\x00\x04\x00\x00\x00\x00\x00"))
+
+	acks := 0
+	for {
+		var header [9]byte
+		if _, err := io.ReadFull(reader, header[:]); err != nil {
+			t.Fatalf("reading frame: %v", err)
+		}
+		payload := make([]byte, int(header[0])<<16|int(header[1])<<8|int(header[2]))
+		if _, err := io.ReadFull(reader, payload); err != nil {
+			t.Fatalf("reading frame: %v", err)
+		}
+		frameType, flags, stream := header[3], header[4], binary.BigEndian.Uint32(header[5:])&0x7fffffff
+		switch {
+		case frameType == 0x7: // GOAWAY
+			t.Fatalf("connection closed with GOAWAY %x", payload)
+		case frameType == http2FrameSettings && flags&0x1 != 0:
+			acks++
+		case frameType == 0x0 && stream == 1: // DATA
+			if string(payload) != "0123" || flags&http2FlagEndStream != 0 {
+				t.Errorf("expected the first 4 bytes of the body within the window, got %q (flags %x)", payload, flags)
+			}
+			if acks != 1 {
+				t.Errorf("expected only the client's SETTINGS frame to be acknowledged, got %d acknowledgements", acks)
+			}
+			return
+		}
+	}
+}
+
+// TestH2CUpgradeRequestFrames tests the encoding of upgraded requests, split into CONTINUATION frames when large
+func TestH2CUpgradeRequestFrames(t *testing.T) {
+	req := &Request{Method: "GET", Version: "HTTP/1.1", Headers: map[string]string{
+		"Host": "example.com", "Connection": "Upgrade, HTTP2-Settings, X-Hop", "Upgrade": "h2c",
+		"Http2-Settings": "", "X-Hop": "1", "X-Large": strings.Repeat("a", http2MaxFrameSize),
+	}}
+	frames := h2cUpgradeRequest(req, "GET /a?b=c HTTP/1.1")
+
+	var block []byte
+	for i := 0; len(frames) > 0; i++ {
+		length := int(frames[0])<<16 | int(frames[1])<<8 | int(frames[2])
+		frameType, flags := frames[3], frames[4]
+		wantType := byte(http2FrameHeaders)
+		if i > 0 {
+			wantType = http2FrameContinuation
+		}
+		if frameType != wantType || binary.BigEndian.Uint32(frames[5:9]) != 1 || length > http2MaxFrameSize {
+			t.Fatalf("frame %d: unexpected type %d, stream or length %d", i, frameType, length)
+		}
+		block = append(block, frames[9:9+length]...)
+		frames = frames[9+length:]
+		if (len(frames) == 0) != (flags&http2FlagEndHeaders != 0) {
+			t.Fatalf("frame %d: END_HEADERS should be set on the last frame only", i)
+		}
+	}
+
+	want := appendHPACKField(nil, ":method", "GET")
+	want = appendHPACKField(want, ":scheme", "http")
+	want = appendHPACKField(want, ":authority", "example.com")
+	want = appendHPACKField(want, ":path", "/a?b=c")
+	want = appendHPACKField(want, "x-large", strings.Repeat("a", http2MaxFrameSize))
+	if !bytes.Equal(block, want) {
+		t.Error("expected pseudo-headers and non-connection headers only, lowercased")
+	}
+	if got := appendHPACKString(nil, strings.Repeat("a", 300))[:3]; !bytes.Equal(got, []byte{127, 173, 1}) {
+		t.Errorf("unexpected encoding of a 300-byte length: %v", got)
+	}
+}
+
+// TestMalformedRequestGets400 tests that unparseable requests get a 400 response before the connection closes
+func TestMalformedRequestGets400(t *testing.T) {
+	app := New()
+	addr := startTestApp(t, app)
+	defer app.Shutdown(context.Background())
+
+	conn, err := net.Dial("tcp", addr)
+	if err != nil {
+		t.Fatalf("dial failed: %v", err)
+	}
+	defer conn.Close()
+	conn.Write([]byte("GET  \r\n\r\n"))
+	conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+
+	response, _ := io.ReadAll(conn)
+	if !strings.HasPrefix(string(response), "HTTP/1.1 400 Bad Request") {
+		t.Errorf("expected 400 response, got %q", response)
+	}
+}
+
+// TestBadRequestBody tests that the configured body is sent with the 400 responses to malformed requests
+func TestBadRequestBody(t *testing.T) {
+	app := New(WithBadRequestBody("application/json", `{"error":"bad request"}`))
+	addr := startTestApp(t, app)
+	defer app.Shutdown(context.Background())
+
+	for name, raw := range map[string]string{
+		"unparseable":            "GET  \r\n\r\n",
+		"invalid content length": "POST / HTTP/1.1\r\nHost: localhost\r\nContent-Length: abc\r\n\r\n",
+	} {
+		response := rawRoundTrip(t, addr, raw)
+		if !strings.HasPrefix(response, "HTTP/1.1 400 Bad Request") || !strings.Contains(response, "Content-Type: application/json") ||
+			!strings.HasSuffix(response, `{"error":"bad request"}`) {
+			t.Errorf("%s: expected 400 with the configured body, got %q", name, response)
+		}
+	}
+
+	response := rawRoundTrip(t, addr, "GET / HTTP/1.1\r\nHost: localhost\r\nTransfer-Encoding: chunked\r\n\r\n")
+	if !strings.HasPrefix(response, "HTTP/1.1 501") || strings.Contains(response, "bad request") {
+		t.Errorf("expected other rejections to keep their own body, got %q", response)
+	}
+}
+
+// rawRoundTrip sends raw bytes to addr and returns everything the server writes until it closes the connection.
+func rawRoundTrip(t *testing.T, addr, raw string) string {
+	t.Helper()
+	conn, err := net.Dial("tcp", addr)
+	if err != nil {
+		t.Fatalf("dial failed: %v", err)
+	}
+	defer conn.Close()
+	conn.Write([]byte(raw))
+	conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	response, _ := io.ReadAll(conn)
+	return string(response)
+}
+
+// TestCleanRequestPath tests that dot segments and duplicate slashes are resolved and traversal is rejected
+func TestCleanRequestPath(t *testing.T) {
+	tests := []struct {
+		path, want string
+		wantErr    bool
+	}{
+		{path: "/", want: "/"},
+		{path: "/a/b", want: "/a/b"},
+		{path: "/a//b/../c", want: "/a/c"},
+		{path: "//a/./b/", want: "/a/b/"},
+		{path: "/a/b/..", want: "/a/"},
+		{path: "/a/..", want: "/"},
+		{path: "/.well-known/x", want: "/.well-known/x"},
+		{path: "*", want: "*"},
+		{path: "/..", wantErr: true},
+		{path: "/a/../../etc/passwd", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := cleanRequestPath(tt.path)
+		if tt.wantErr {
+			if !errors.Is(err, errPathTraversal) {
+				t.Errorf("cleanRequestPath(%q): expected traversal error, got %q, %v", tt.path, got, err)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("cleanRequestPath(%q) = %q, %v; want %q", tt.path, got, err, tt.want)
+		}
+	}
+}
+
+// TestPathNormalizedBeforeRouting tests that dot segments can't route around path-scoped middleware
+func TestPathNormalizedBeforeRouting(t *testing.T) {
+	app := New()
+	admin := app.Group("/admin", func(next Handler) Handler {
+		return HandlerFunc(func(w ResponseWriter, r *Request) { w.Plain(403, "forbidden") })
+	})
+	admin.Get("/secret", HandlerFunc(func(w ResponseWriter, r *Request) { w.Plain(200, "secret") }))
+	app.Get("/public/:name", HandlerFunc(func(w ResponseWriter, r *Request) { w.Plain(200, "public") }))
+	addr := startTestApp(t, app)
+	defer app.Shutdown(context.Background())
+
+	response := rawRoundTrip(t, addr, "GET /public/..//admin/secret HTTP/1.1\r\nHost: localhost\r\nConnection: close\r\n\r\n")
+	if !strings.HasPrefix(response, "HTTP/1.1 403") {
+		t.Errorf("expected the admin middleware to run, got %q", response)
+	}
+
+	response = rawRoundTrip(t, addr, "GET /public/../../etc/passwd HTTP/1.1\r\nHost: localhost\r\nConnection: close\r\n\r\n")
+	if !strings.HasPrefix(response, "HTTP/1.1 400") {
+		t.Errorf("expected 400 for traversal above the root, got %q", response)
+	}
+}
+
+// TestHostHeaderValidation tests that HTTP/1.1 requests need a valid Host and that AllowedHosts is enforced
+func TestHostHeaderValidation(t *testing.T) {
+	app := New(WithAllowedHosts("example.com", "*.example.org"))
+	app.Get("/", HandlerFunc(func(w ResponseWriter, r *Request) { w.Plain(200, "ok") }))
+	addr := startTestApp(t, app)
+	defer app.Shutdown(context.Background())
+
+	tests := []struct {
+		name, head, wantStatus string
+	}{
+		{"allowed host", "GET / HTTP/1.1\r\nHost: example.com", "200"},
+		{"allowed host with port", "GET / HTTP/1.1\r\nHost: EXAMPLE.com:8080", "200"},
+		{"allowed subdomain", "GET / HTTP/1.1\r\nHost: api.example.org", "200"},
+		{"wildcard excludes apex", "GET / HTTP/1.1\r\nHost: example.org", "421"},
+		{"unknown host", "GET / HTTP/1.1\r\nHost: evil.test", "421"},
+		{"missing host", "GET / HTTP/1.1", "400"},
+		{"invalid host", "GET / HTTP/1.1\r\nHost: exa mple.com", "400"},
+		{"invalid port", "GET / HTTP/1.1\r\nHost: example.com:80x", "400"},
+		{"HTTP/1.0 without host", "GET / HTTP/1.0", "200"},
+	}
+	for _, tt := range tests {
+		response := rawRoundTrip(t, addr, tt.head+"\r\nConnection: close\r\n\r\n")
+		if status, _, _ := strings.Cut(strings.TrimPrefix(strings.TrimPrefix(response, "HTTP/1.1 "), "HTTP/1.0 "), " "); status != tt.wantStatus {
+			t.Errorf("%s: expected %s, got %q", tt.name, tt.wantStatus, response)
+		}
+	}
+}
+
+// TestIsValidHost tests the Host header syntax check
+func TestIsValidHost(t *testing.T) {
+	for _, host := range []string{"localhost", "example.com:443", "127.0.0.1", "[::1]", "[::1]:8080", "xn--bcher-kva.example"} {
+		if !isValidHost(host) {
+			t.Errorf("expected %q to be valid", host)
+		}
+	}
+	for _, host := range []string{"", "a b", "example.com:http", "[::1", "[nope]", "[::1]x", "user@host", "a/b"} {
+		if isValidHost(host) {
+			t.Errorf("expected %q to be invalid", host)
+		}
+	}
+}
+
+// TestAbsoluteFormTarget tests that absolute-URI targets are routed by path and take their host from the authority
+func TestAbsoluteFormTarget(t *testing.T) {
+	app := New()
+	app.Get("/users/:id", HandlerFunc(func(w ResponseWriter, r *Request) {
+		w.Plain(200, r.Param("id")+" "+r.Query("tab")+" "+r.GetHeader("Host"))
+	}))
+	app.Get("/", HandlerFunc(func(w ResponseWriter, r *Request) { w.Plain(200, "root") }))
+	addr := startTestApp(t, app)
+	defer app.Shutdown(context.Background())
+
+	response := rawRoundTrip(t, addr, "GET http://api.example.com/users/7?tab=posts HTTP/1.1\r\nHost: other.test\r\nConnection: close\r\n\r\n")
+	if !strings.HasPrefix(response, "HTTP/1.1 200") || !strings.HasSuffix(response, "7 posts api.example.com") {
+		t.Errorf("unexpected response %q", response)
+	}
+
+	response = rawRoundTrip(t, addr, "GET HTTPS://example.com HTTP/1.1\r\nConnection: close\r\n\r\n")
+	if !strings.HasSuffix(response, "root") {
+		t.Errorf("expected an empty path to route to /, got %q", response)
+	}
+
+	response = rawRoundTrip(t, addr, "GET http://user@example.com/ HTTP/1.1\r\nConnection: close\r\n\r\n")
+	if !strings.HasPrefix(response, "HTTP/1.1 400") {
+		t.Errorf("expected 400 for userinfo in the authority, got %q", response)
+	}
+}
+
+// TestOptionsAsterisk tests that OPTIONS * is answered for the server as a whole and * is rejected for other methods
+func TestOptionsAsterisk(t *testing.T) {
+	app := New()
+	addr := startTestApp(t, app)
+	defer app.Shutdown(context.Background())
+
+	response := rawRoundTrip(t, addr, "OPTIONS * HTTP/1.1\r\nHost: localhost\r\nConnection: close\r\n\r\n")
+	if !strings.HasPrefix(response, "HTTP/1.1 200") || !strings.Contains(response, "Allow: "+serverMethods) {
+		t.Errorf("unexpected OPTIONS * response %q", response)
+	}
+
+	response = rawRoundTrip(t, addr, "GET * HTTP/1.1\r\nHost: localhost\r\nConnection: close\r\n\r\n")
+	if !strings.HasPrefix(response, "HTTP/1.1 400") {
+		t.Errorf("expected 400 for GET *, got %q", response)
+	}
+}
+
+// TestRequestSmugglingRejected tests that ambiguous body framing gets an error response and a closed connection
+func TestRequestSmugglingRejected(t *testing.T) {
+	app := New()
+	app.Post("/", HandlerFunc(func(w ResponseWriter, r *Request) { w.Plain(200, "served") }))
+	addr := startTestApp(t, app)
+	defer app.Shutdown(context.Background())
+
+	tests := []struct {
+		name, headers, wantStatus string
+	}{
+		{"CL and TE", "Content-Length: 5\r\nTransfer-Encoding: chunked", "400"},
+		{"conflicting CL", "Content-Length: 5\r\nContent-Length: 6", "400"},
+		{"TE only", "Transfer-Encoding: chunked", "501"},
+	}
+	for _, tt := range tests {
+		// The trailing request would be served if the server misread where the first one ends
+		raw := "POST / HTTP/1.1\r\nHost: localhost\r\n" + tt.headers + "\r\n\r\n0\r\n\r\nPOST / HTTP/1.1\r\nHost: localhost\r\n\r\n"
+		response := rawRoundTrip(t, addr, raw)
+		if !strings.HasPrefix(response, "HTTP/1.1 "+tt.wantStatus) || !strings.Contains(response, "Connection: close") {
+			t.Errorf("%s: expected %s and a closed connection, got %q", tt.name, tt.wantStatus, response)
+		}
+		if strings.Contains(response, "served") {
+			t.Errorf("%s: smuggled request was served: %q", tt.name, response)
+		}
+	}
+
+	response := rawRoundTrip(t, addr, "POST / HTTP/1.1\r\nHost: localhost\r\nContent-Length: 2\r\nContent-Length: 2\r\nConnection: close\r\n\r\nhi")
+	if !strings.HasPrefix(response, "HTTP/1.1 200") {
+		t.Errorf("expected identical Content-Length values to be accepted, got %q", response)
+	}
+}
+
+// TestObsoleteLineFoldingRejected tests that folded header lines get 400 instead of being misparsed
+func TestObsoleteLineFoldingRejected(t *testing.T) {
+	if _, err := ParseRequest("GET / HTTP/1.1\r\nX-Long: first\r\n second"); err == nil || !strings.Contains(err.Error(), "folding") {
+		t.Errorf("expected a line folding error, got %v", err)
+	}
+
+	app := New()
+	app.Get("/", HandlerFunc(func(w ResponseWriter, r *Request) { w.Plain(200, "ok") }))
+	addr := startTestApp(t, app)
+	defer app.Shutdown(context.Background())
+
+	response := rawRoundTrip(t, addr, "GET / HTTP/1.1\r\nHost: localhost\r\nX-Long: first\r\n\tsecond\r\n\r\n")
+	if !strings.HasPrefix(response, "HTTP/1.1 400") || !strings.Contains(response, "Connection: close") {
+		t.Errorf("expected 400 and a closed connection, got %q", response)
+	}
+}
+
+// TestResponseConnectionClose tests that handlers can close a keep-alive connection and that draining closes it
+func TestResponseConnectionClose(t *testing.T) {
+	release := make(chan struct{})
+	app := New()
+	app.Get("/bye", HandlerFunc(func(w ResponseWriter, r *Request) {
+		w.SetHeader("connection", "Close").Plain(200, "bye")
+	}))
+	app.Get("/slow", HandlerFunc(func(w ResponseWriter, r *Request) {
+		<-release
+		w.Plain(200, "slow")
+	}))
+	addr := startTestApp(t, app)
+
+	response := rawRoundTrip(t, addr, "GET /bye HTTP/1.1\r\nHost: localhost\r\nConnection: keep-alive\r\n\r\n")
+	if !strings.HasSuffix(response, "bye") {
+		t.Errorf("expected the handler's response before the connection closed, got %q", response)
+	}
+
+	conn, err := net.Dial("tcp", addr)
+	if err != nil {
+		t.Fatalf("dial failed: %v", err)
+	}
+	defer conn.Close()
+	conn.Write([]byte("GET /slow HTTP/1.1\r\nHost: localhost\r\nConnection: keep-alive\r\n\r\n"))
+	time.Sleep(20 * time.Millisecond)
+
+	shutdown := make(chan error, 1)
+	go func() { shutdown <- app.Shutdown(context.Background()) }()
+	time.Sleep(20 * time.Millisecond)
+	close(release)
+
+	conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	drained, _ := io.ReadAll(conn)
+	if !strings.HasSuffix(string(drained), "slow") {
+		t.Errorf("expected the in-flight request to finish and the connection to close, got %q", drained)
+	}
+	if err := <-shutdown; err != nil {
+		t.Errorf("expected clean shutdown, got %v", err)
+	}
+}
+
+// TestRequestLineTooLong tests that request lines beyond the configured limit get 414
+func TestRequestLineTooLong(t *testing.T) {
+	app := New(WithMaxRequestLineSize(64))
+	app.Get("/short", HandlerFunc(func(w ResponseWriter, r *Request) { w.Plain(200, "ok") }))
+	addr := startTestApp(t, app)
+	defer app.Shutdown(context.Background())
+
+	response := rawRoundTrip(t, addr, "GET /"+strings.Repeat("a", 100)+" HTTP/1.1\r\nHost: localhost\r\n\r\n")
+	if !strings.HasPrefix(response, "HTTP/1.1 414 URI Too Long") || !strings.Contains(response, "Connection: close") {
+		t.Errorf("expected 414 and a closed connection, got %q", response)
+	}
+
+	response = rawRoundTrip(t, addr, "GET /short HTTP/1.1\r\nHost: localhost\r\nConnection: close\r\n\r\n")
+	if !strings.HasPrefix(response, "HTTP/1.1 200") {
+		t.Errorf("expected short request lines to be served, got %q", response)
+	}
+}
+
+// TestRequestBodyTooLarge tests that bodies beyond the configured size get 413 without being read
+func TestRequestBodyTooLarge(t *testing.T) {
+	app := New(WithMaxRequestBodySize(16))
+	app.Post("/", HandlerFunc(func(w ResponseWriter, r *Request) { w.Plain(200, string(r.Body)) }))
+	addr := startTestApp(t, app)
+	defer app.Shutdown(context.Background())
+
+	response := rawRoundTrip(t, addr, "POST / HTTP/1.1\r\nHost: localhost\r\nContent-Length: 1073741824\r\n\r\nabc")
+	if !strings.HasPrefix(response, "HTTP/1.1 413 Content Too Large") || !strings.Contains(response, "Connection: close") {
+		t.Errorf("expected 413 and a closed connection, got %q", response)
+	}
+
+	response = rawRoundTrip(t, addr, "POST / HTTP/1.1\r\nHost: localhost\r\nContent-Length: 5\r\nConnection: close\r\n\r\nhello")
+	if !strings.HasPrefix(response, "HTTP/1.1 200") || !strings.HasSuffix(response, "hello") {
+		t.Errorf("expected small bodies to be served, got %q", response)
+	}
+}
+
+// TestHeadersTooLarge tests that headers beyond the configured size get 431, whether in one line or many
+func TestHeadersTooLarge(t *testing.T) {
+	app := New(WithMaxHeaderBytes(256))
+	app.Get("/", HandlerFunc(func(w ResponseWriter, r *Request) { w.Plain(200, "ok") }))
+	addr := startTestApp(t, app)
+	defer app.Shutdown(context.Background())
+
+	for name, headers := range map[string]string{
+		"long line":  "X-Big: " + strings.Repeat("a", 300) + "\r\n",
+		"many lines": strings.Repeat("X-Small: abcdefghij\r\n", 20),
+	} {
+		response := rawRoundTrip(t, addr, "GET / HTTP/1.1\r\nHost: localhost\r\n"+headers+"\r\n")
+		if !strings.HasPrefix(response, "HTTP/1.1 431 Request Header Fields Too Large") || !strings.Contains(response, "Connection: close") {
+			t.Errorf("%s: expected 431 and a closed connection, got %q", name, response)
+		}
+	}
+
+	response := rawRoundTrip(t, addr, "GET / HTTP/1.1\r\nHost: localhost\r\nX-Small: abcdefghij\r\nConnection: close\r\n\r\n")
+	if !strings.HasPrefix(response, "HTTP/1.1 200") {
+		t.Errorf("expected small headers to be served, got %q", response)
+	}
+}
+
+// TestReadLineLimit tests that readLine stops at the limit even when the line exceeds the reader's buffer
+func TestReadLineLimit(t *testing.T) {
+	long := strings.Repeat("x", 10000) + "\r\n"
+	if line, err := readLine(bufio.NewReaderSize(strings.NewReader(long), 16), 0); err != nil || line != long {
+		t.Errorf("expected the whole line without a limit, got %d bytes, %v", len(line), err)
+	}
+	if _, err := readLine(bufio.NewReaderSize(strings.NewReader(long), 16), 5000); !errors.Is(err, errLineTooLong) {
+		t.Errorf("expected errLineTooLong, got %v", err)
+	}
+}
+
+// TestParseContentLength tests that only plain non-negative decimal lengths are accepted
+func TestParseContentLength(t *testing.T) {
+	for value, want := range map[string]int{"0": 0, "42": 42, "007": 7} {
+		if got, err := parseContentLength(value); err != nil || got != want {
+			t.Errorf("parseContentLength(%q) = %d, %v; want %d", value, got, err, want)
+		}
+	}
+	for _, value := range []string{"", "-1", "+5", " 5", "5 ", "12abc", "0x10", "1,2", "99999999999999999999999"} {
+		if _, err := parseContentLength(value); err == nil {
+			t.Errorf("parseContentLength(%q): expected an error", value)
+		}
+	}
+}
+
+// TestInvalidContentLengthGets400 tests that the server rejects malformed Content-Length values
+func TestInvalidContentLengthGets400(t *testing.T) {
+	app := New()
+	app.Post("/", HandlerFunc(func(w ResponseWriter, r *Request) { w.Plain(200, "served") }))
+	addr := startTestApp(t, app)
+	defer app.Shutdown(context.Background())
+
+	for _, value := range []string{"-5", "3abc", "+3"} {
+		response := rawRoundTrip(t, addr, "POST / HTTP/1.1\r\nHost: localhost\r\nContent-Length: "+value+"\r\n\r\nabc")
+		if !strings.HasPrefix(response, "HTTP/1.1 400") || strings.Contains(response, "served") {
+			t.Errorf("Content-Length %q: expected 400, got %q", value, response)
+		}
+	}
+}
+
+// TestKeepAliveDefaults tests that HTTP/1.1 connections persist by default and HTTP/1.0 ones close
+func TestKeepAliveDefaults(t *testing.T) {
+	app := New()
+	app.Get("/plain", HandlerFunc(func(w ResponseWriter, r *Request) { w.Plain(200, "ok") }))
+	app.Get("/empty", HandlerFunc(func(w ResponseWriter, r *Request) { w.Status(201) }))
+	app.Get("/stream", HandlerFunc(func(w ResponseWriter, r *Request) { w.SendString("unframed") }))
+	addr := startTestApp(t, app)
+	defer app.Shutdown(context.Background())
+
+	conn, err := net.Dial("tcp", addr)
+	if err != nil {
+		t.Fatalf("dial failed: %v", err)
+	}
+	defer conn.Close()
+	conn.Write([]byte("GET /plain HTTP/1.1\r\nHost: localhost\r\n\r\nGET /empty HTTP/1.1\r\nHost: localhost\r\n\r\nGET /stream HTTP/1.1\r\nHost: localhost\r\n\r\n"))
+	conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	response, _ := io.ReadAll(conn)
+	if got := strings.Count(string(response), "HTTP/1.1 "); got != 3 {
+		t.Fatalf("expected three responses on one connection, got %d: %q", got, response)
+	}
+	if !strings.Contains(string(response), "HTTP/1.1 201 Created\r\nContent-Length: 0\r\n\r\n") {
+		t.Errorf("expected an empty response to be framed with Content-Length: 0, got %q", response)
+	}
+	if !strings.HasSuffix(string(response), "unframed") || !strings.Contains(string(response), "Connection: close") {
+		t.Errorf("expected a response without a length to close the connection, got %q", response)
+	}
+
+	response10 := rawRoundTrip(t, addr, "GET /plain HTTP/1.0\r\n\r\nGET /plain HTTP/1.0\r\n\r\n")
+	if got := strings.Count(response10, "HTTP/1.1 "); got != 1 || !strings.Contains(response10, "Connection: close") {
+		t.Errorf("expected HTTP/1.0 connections to close after one response, got %q", response10)
+	}
+}
+
+// TestMaxConnections tests that connections over the limit are rejected with 503, or queued until one closes
+func TestMaxConnections(t *testing.T) {
+	for _, policy := range []ConnectionLimitPolicy{ConnectionLimitReject, ConnectionLimitQueue} {
+		app := New(WithMaxConnections(1, policy))
+		app.Get("/", HandlerFunc(func(w ResponseWriter, r *Request) { w.Plain(200, "ok") }))
+		addr := startTestApp(t, app)
+
+		held, err := net.Dial("tcp", addr)
+		if err != nil {
+			t.Fatalf("dial failed: %v", err)
+		}
+		held.Write([]byte("GET / HTTP/1.1\r\nHost: localhost\r\n\r\n"))
+		held.SetReadDeadline(time.Now().Add(2 * time.Second))
+		if line, _ := bufio.NewReader(held).ReadString('\n'); !strings.HasPrefix(line, "HTTP/1.1 200") {
+			t.Fatalf("expected the first connection to be served, got %q", line)
+		}
+
+		if policy == ConnectionLimitReject {
+			response := rawRoundTrip(t, addr, "GET / HTTP/1.1\r\nHost: localhost\r\n\r\n")
+			if !strings.HasPrefix(response, "HTTP/1.1 503 Service Unavailable") || !strings.Contains(response, "Connection: close") {
+				t.Errorf("expected 503 over the limit, got %q", response)
+			}
+			held.Close()
+		} else {
+			go func() {
+				time.Sleep(50 * time.Millisecond)
+				held.Close()
+			}()
+			response := rawRoundTrip(t, addr, "GET / HTTP/1.1\r\nHost: localhost\r\nConnection: close\r\n\r\n")
+			if !strings.HasPrefix(response, "HTTP/1.1 200") {
+				t.Errorf("expected the queued connection to be served once the first closed, got %q", response)
+			}
+		}
+		app.Shutdown(context.Background())
+	}
+}
+
+// countingListener counts the connections accepted from its listener.
+type countingListener struct {
+	net.Listener
+	accepted atomic.Int32
+}
+
+func (l *countingListener) Accept() (net.Conn, error) {
+	conn, err := l.Listener.Accept()
+	if err == nil {
+		l.accepted.Add(1)
+	}
+	return conn, err
+}
+
+// TestMaxConnectionsQueueBacklog tests that queued clients wait in the listen backlog, not as accepted connections
+func TestMaxConnectionsQueueBacklog(t *testing.T) {
+	app := New(WithMaxConnections(1, ConnectionLimitQueue))
+	app.Get("/", HandlerFunc(func(w ResponseWriter, r *Request) { w.Plain(200, "ok") }))
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	counting := &countingListener{Listener: ln}
+	go app.Serve(counting)
+	defer app.Shutdown(context.Background())
+	addr := ln.Addr().String()
+
+	held, err := net.Dial("tcp", addr)
+	if err != nil {
+		t.Fatalf("dial failed: %v", err)
+	}
+	held.Write([]byte("GET / HTTP/1.1\r\nHost: localhost\r\n\r\n"))
+	held.SetReadDeadline(time.Now().Add(2 * time.Second))
+	if line, _ := bufio.NewReader(held).ReadString('\n'); !strings.HasPrefix(line, "HTTP/1.1 200") {
+		t.Fatalf("expected the first connection to be served, got %q", line)
+	}
+
+	queued, err := net.Dial("tcp", addr)
+	if err != nil {
+		t.Fatalf("dial failed: %v", err)
+	}
+	defer queued.Close()
+	queued.Write([]byte("GET / HTTP/1.1\r\nHost: localhost\r\nConnection: close\r\n\r\n"))
+	time.Sleep(50 * time.Millisecond)
+	if n := counting.accepted.Load(); n != 1 {
+		t.Errorf("expected only the first connection to be accepted at the limit, got %d", n)
+	}
+
+	held.Close()
+	queued.SetReadDeadline(time.Now().Add(2 * time.Second))
+	if line, _ := bufio.NewReader(queued).ReadString('\n'); !strings.HasPrefix(line, "HTTP/1.1 200") {
+		t.Errorf("expected the queued connection to be served once the first closed, got %q", line)
+	}
+}
+
+// TestWorkerPool tests that a worker pool serves connections, holding new ones back while every worker is busy
+func TestWorkerPool(t *testing.T) {
+	app := New(WithWorkerPool(1))
+	app.Get("/", HandlerFunc(func(w ResponseWriter, r *Request) { w.Plain(200, "ok") }))
+	addr := startTestApp(t, app)
+	defer app.Shutdown(context.Background())
+
+	held, err := net.Dial("tcp", addr)
+	if err != nil {
+		t.Fatalf("dial failed: %v", err)
+	}
+	held.Write([]byte("GET / HTTP/1.1\r\nHost: localhost\r\n\r\n"))
+	held.SetReadDeadline(time.Now().Add(2 * time.Second))
+	if line, _ := bufio.NewReader(held).ReadString('\n'); !strings.HasPrefix(line, "HTTP/1.1 200") {
+		t.Fatalf("expected the first connection to be served, got %q", line)
+	}
+
+	start := time.Now()
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		held.Close()
+	}()
+	response := rawRoundTrip(t, addr, "GET / HTTP/1.1\r\nHost: localhost\r\nConnection: close\r\n\r\n")
+	if !strings.HasPrefix(response, "HTTP/1.1 200") {
+		t.Errorf("expected the waiting connection to be served once the worker was free, got %q", response)
+	}
+	if elapsed := time.Since(start); elapsed < 50*time.Millisecond {
+		t.Errorf("expected the waiting connection to be held until the worker was free, served after %v", elapsed)
+	}
+}
+
+// TestExpectContinue tests that Expect: 100-continue gets an interim response before the body is read, and that
+// requests refused anyway or with other expectations get their final response without it
+func TestExpectContinue(t *testing.T) {
+	app := New(WithMaxRequestBodySize(16))
+	app.Post("/", HandlerFunc(func(w ResponseWriter, r *Request) { w.Plain(200, string(r.Body)) }))
+	addr := startTestApp(t, app)
+	defer app.Shutdown(context.Background())
+
+	conn, err := net.Dial("tcp", addr)
+	if err != nil {
+		t.Fatalf("dial failed: %v", err)
+	}
+	defer conn.Close()
+	conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	conn.Write([]byte("POST / HTTP/1.1\r\nHost: localhost\r\nContent-Length: 5\r\nExpect: 100-continue\r\nConnection: close\r\n\r\n"))
+	reader := bufio.NewReader(conn)
+	if line, _ := reader.ReadString('\n'); line != "HTTP/1.1 100 Continue\r\n" {
+		t.Fatalf("expected 100 Continue before the body was sent, got %q", line)
+	}
+	reader.ReadString('\n')
+	conn.Write([]byte("hello"))
+	response, _ := io.ReadAll(reader)
+	if !strings.HasPrefix(string(response), "HTTP/1.1 200") || !strings.HasSuffix(string(response), "hello") {
+		t.Errorf("expected the body to be served after 100 Continue, got %q", response)
+	}
+
+	refused := rawRoundTrip(t, addr, "POST / HTTP/1.1\r\nHost: localhost\r\nContent-Length: 1024\r\nExpect: 100-continue\r\n\r\n")
+	if !strings.HasPrefix(refused, "HTTP/1.1 413") {
+		t.Errorf("expected 413 without 100 Continue for a body too large, got %q", refused)
+	}
+
+	refused = rawRoundTrip(t, addr, "POST / HTTP/1.1\r\nHost: localhost\r\nContent-Length: 5\r\nExpect: something-else\r\n\r\nhello")
+	if !strings.HasPrefix(refused, "HTTP/1.1 417 Expectation Failed") {
+		t.Errorf("expected 417 for an unknown expectation, got %q", refused)
+	}
+}
+
+// TestServeListener tests serving on a caller-provided listener, here one handing over in-memory pipes, until
+// Shutdown closes it
+func TestServeListener(t *testing.T) {
+	app := New()
+	app.server.onListen = func(net.Addr) {}
+	app.Get("/", HandlerFunc(func(w ResponseWriter, r *Request) { w.Plain(200, "in memory") }))
+
+	ln := &connListener{conns: make(chan net.Conn), closed: make(chan struct{})}
+	served := make(chan error, 1)
+	go func() { served <- app.Serve(ln) }()
+
+	client, server := net.Pipe()
+	ln.conns <- server
+	client.SetDeadline(time.Now().Add(2 * time.Second))
+	client.Write([]byte("GET / HTTP/1.1\r\nHost: localhost\r\nConnection: close\r\n\r\n"))
+	response, _ := io.ReadAll(client)
+	client.Close()
+	if !strings.HasPrefix(string(response), "HTTP/1.1 200") || !strings.HasSuffix(string(response), "in memory") {
+		t.Errorf("expected the request to be served, got %q", response)
+	}
+
+	if err := app.Shutdown(context.Background()); err != nil {
+		t.Fatalf("shutdown failed: %v", err)
+	}
+	select {
+	case err := <-served:
+		if err != nil {
+			t.Errorf("expected Serve to return nil after Shutdown, got %v", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Error("expected Serve to return after Shutdown")
+	}
+}
+
+// TestReadTimeouts tests that clients trickling in their headers or body get 408, and that idle keep-alive
+// connections are closed
+func TestReadTimeouts(t *testing.T) {
+	app := New(WithReadHeaderTimeout(100*time.Millisecond), WithReadTimeout(100*time.Millisecond), WithIdleTimeout(100*time.Millisecond))
+	app.Post("/", HandlerFunc(func(w ResponseWriter, r *Request) { w.Plain(200, string(r.Body)) }))
+	addr := startTestApp(t, app)
+	defer app.Shutdown(context.Background())
+
+	for name, partial := range map[string]string{
+		"headers": "POST / HTTP/1.1\r\nHost: local",
+		"body":    "POST / HTTP/1.1\r\nHost: localhost\r\nContent-Length: 10\r\n\r\nab",
+	} {
+		conn, err := net.Dial("tcp", addr)
+		if err != nil {
+			t.Fatalf("dial failed: %v", err)
+		}
+		conn.Write([]byte(partial))
+		conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+		response, _ := io.ReadAll(conn)
+		conn.Close()
+		if !strings.HasPrefix(string(response), "HTTP/1.1 408 Request Timeout") {
+			t.Errorf("%s: expected 408 for a slow client, got %q", name, response)
+		}
+	}
+
+	conn, err := net.Dial("tcp", addr)
+	if err != nil {
+		t.Fatalf("dial failed: %v", err)
+	}
+	defer conn.Close()
+	conn.Write([]byte("POST / HTTP/1.1\r\nHost: localhost\r\nContent-Length: 2\r\n\r\nok"))
+	conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	start := time.Now()
+	response, _ := io.ReadAll(conn)
+	if !strings.HasPrefix(string(response), "HTTP/1.1 200") {
+		t.Errorf("expected the request to be served, got %q", response)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("expected the idle connection to be closed after the idle timeout, took %v", elapsed)
+	}
+}
+
+// TestRequestTimeout tests that requests whose handler doesn't respond in time get 503, with their context
+// canceled, while quick ones are served
+func TestRequestTimeout(t *testing.T) {
+	app := New(WithRequestTimeout(50 * time.Millisecond))
+	canceled := make(chan error, 1)
+	app.Get("/slow", HandlerFunc(func(w ResponseWriter, r *Request) {
+		<-r.Context().Done()
+		canceled <- r.Context().Err()
+		w.Plain(200, "too late")
+	}))
+	app.Get("/fast", HandlerFunc(func(w ResponseWriter, r *Request) { w.Plain(200, "fast") }))
+	addr := startTestApp(t, app)
+	defer app.Shutdown(context.Background())
+
+	response := rawRoundTrip(t, addr, "GET /slow HTTP/1.1\r\nHost: localhost\r\nConnection: close\r\n\r\n")
+	if !strings.HasPrefix(response, "HTTP/1.1 503") || strings.Contains(response, "too late") {
+		t.Errorf("expected 503 for a handler past the timeout, got %q", response)
+	}
+	select {
+	case err := <-canceled:
+		if !errors.Is(err, context.DeadlineExceeded) {
+			t.Errorf("expected the request context to pass its deadline, got %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Error("expected the request context to be canceled")
+	}
+
+	response = rawRoundTrip(t, addr, "GET /fast HTTP/1.1\r\nHost: localhost\r\nConnection: close\r\n\r\n")
+	if !strings.HasPrefix(response, "HTTP/1.1 200") || !strings.HasSuffix(response, "fast") {
+		t.Errorf("expected quick requests to be served, got %q", response)
+	}
+}
+
+// TestRequestTimeoutSSE tests that event streams are flushed as they go under a request timeout, and aren't
+// ended by it
+func TestRequestTimeoutSSE(t *testing.T) {
+	app := New(WithRequestTimeout(50 * time.Millisecond))
+	release := make(chan struct{})
+	app.SSE("/events", func(s *SSEStream, r *Request) {
+		s.SendData("one")
+		<-release
+		time.Sleep(100 * time.Millisecond) // Past the request timeout
+		if err := s.Context().Err(); err != nil {
+			s.SendData("canceled: " + err.Error())
+			return
+		}
+		s.SendData("two")
+	}, SSEOptions{Heartbeat: -1})
+	addr := startTestApp(t, app)
+	defer app.Shutdown(context.Background())
+
+	conn, err := net.Dial("tcp", addr)
+	if err != nil {
+		t.Fatalf("dial failed: %v", err)
+	}
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(2 * time.Second))
+	conn.Write([]byte("GET /events HTTP/1.1\r\nHost: localhost\r\n\r\n"))
+
+	reader := bufio.NewReader(conn)
+	var received strings.Builder
+	for !strings.Contains(received.String(), "data: one\n\n") {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			t.Fatalf("expected the first event before the handler returned, got %q: %v", received.String(), err)
+		}
+		received.WriteString(line)
+	}
+	close(release)
+	rest, _ := io.ReadAll(reader)
+	if !strings.HasSuffix(string(rest), "data: two\n\n") {
+		t.Errorf("expected the stream to outlive the request timeout, got %q", rest)
+	}
+}
+
+// TestMaxConnectionsIdleTimeout tests that an idle keep-alive connection gives up its slot after the idle
+// timeout, so new clients are served again
+func TestMaxConnectionsIdleTimeout(t *testing.T) {
+	app := New(WithMaxConnections(1, ConnectionLimitReject), WithIdleTimeout(50*time.Millisecond))
+	app.Get("/", HandlerFunc(func(w ResponseWriter, r *Request) { w.Plain(200, "ok") }))
+	addr := startTestApp(t, app)
+	defer app.Shutdown(context.Background())
+
+	idle, err := net.Dial("tcp", addr)
+	if err != nil {
+		t.Fatalf("dial failed: %v", err)
+	}
+	defer idle.Close()
+	idle.Write([]byte("GET / HTTP/1.1\r\nHost: localhost\r\n\r\n"))
+	idle.SetReadDeadline(time.Now().Add(2 * time.Second))
+	if response, _ := io.ReadAll(idle); !strings.HasPrefix(string(response), "HTTP/1.1 200") {
+		t.Fatalf("expected the first connection to be served and closed once idle, got %q", response)
+	}
+
+	response := rawRoundTrip(t, addr, "GET / HTTP/1.1\r\nHost: localhost\r\nConnection: close\r\n\r\n")
+	if !strings.HasPrefix(response, "HTTP/1.1 200") {
+		t.Errorf("expected a new client to be served once the idle connection closed, got %q", response)
+	}
+}
+
+// TestMaxConnectionsDefaultIdleTimeout tests that connections holding a slot get an idle timeout even when it
+// is disabled
+func TestMaxConnectionsDefaultIdleTimeout(t *testing.T) {
+	s := newServer(&testHandler{}, &serverConfig{MaxConnections: 1})
+	if timeout := s.idleTimeout(); timeout != defaultIdleTimeout {
+		t.Errorf("expected the default idle timeout, got %v", timeout)
+	}
+}
+
+// TestWorkerPoolIdleTimeout tests that a worker serving an idle keep-alive connection is freed after the idle
+// timeout, so new clients are served
+func TestWorkerPoolIdleTimeout(t *testing.T) {
+	app := New(WithWorkerPool(1), WithIdleTimeout(50*time.Millisecond))
+	app.Get("/", HandlerFunc(func(w ResponseWriter, r *Request) { w.Plain(200, "ok") }))
+	addr := startTestApp(t, app)
+	defer app.Shutdown(context.Background())
+
+	idle, err := net.Dial("tcp", addr)
+	if err != nil {
+		t.Fatalf("dial failed: %v", err)
+	}
+	defer idle.Close()
+	idle.Write([]byte("GET / HTTP/1.1\r\nHost: localhost\r\n\r\n"))
+	idle.SetReadDeadline(time.Now().Add(2 * time.Second))
+	if line, _ := bufio.NewReader(idle).ReadString('\n'); !strings.HasPrefix(line, "HTTP/1.1 200") {
+		t.Fatalf("expected the first connection to be served, got %q", line)
+	}
+
+	// The idle connection is left open by the client; the server closes it and serves the next one
+	response := rawRoundTrip(t, addr, "GET / HTTP/1.1\r\nHost: localhost\r\nConnection: close\r\n\r\n")
+	if !strings.HasPrefix(response, "HTTP/1.1 200") {
+		t.Errorf("expected the waiting client to be served once the idle connection timed out, got %q", response)
+	}
+
+	if timeout := newServer(&testHandler{}, &serverConfig{Workers: 1}).idleTimeout(); timeout != defaultIdleTimeout {
+		t.Errorf("expected pool workers to get the default idle timeout when it is disabled, got %v", timeout)
+	}
+}
+
+// TestSetHeaderLineBreaks tests that line breaks in header values can't inject headers into the response
+func TestSetHeaderLineBreaks(t *testing.T) {
+	app := New()
+	app.Get("/", HandlerFunc(func(w ResponseWriter, r *Request) {
+		w.SetHeader("X-Echo", "x\r\nSet-Cookie: pwned=1").Plain(200, "ok")
+	}))
+	addr := startTestApp(t, app)
+	defer app.Shutdown(context.Background())
+
+	response := rawRoundTrip(t, addr, "GET / HTTP/1.1\r\nHost: localhost\r\nConnection: close\r\n\r\n")
+	if strings.Contains(response, "\r\nSet-Cookie") || !strings.Contains(response, "X-Echo: x  Set-Cookie: pwned=1\r\n") {
+		t.Errorf("expected the line break to be replaced in the header value, got %q", response)
+	}
+}
+
+// TestTrailingSlashRedirectRawQuery tests that trailing slash redirects pass the query string on as sent
+func TestTrailingSlashRedirectRawQuery(t *testing.T) {
+	app := New(WithRouterOptions(RouterOptions{TrailingSlash: TrailingSlashRedirect}))
+	app.Get("/users", HandlerFunc(func(w ResponseWriter, r *Request) {}))
+	addr := startTestApp(t, app)
+	defer app.Shutdown(context.Background())
+
+	response := rawRoundTrip(t, addr, "GET /users/?q=a%20b HTTP/1.1\r\nHost: localhost\r\nConnection: close\r\n\r\n")
+	if !strings.Contains(response, "Location: /users?q=a%20b\r\n") {
+		t.Errorf("expected a redirect to /users?q=a%%20b, got %q", response)
+	}
+}
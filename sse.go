@@ -0,0 +1,200 @@
+package ghast
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+// defaultSSEHeartbeat is how often an idle event stream sends a comment to keep proxies from closing it.
+const defaultSSEHeartbeat = 15 * time.Second
+
+// ErrStreamClosed is returned by SSEStream.Send once the client has disconnected or the handler has returned.
+var ErrStreamClosed = errors.New("ghast: event stream closed")
+
+// SSEHandler handles a Server-Sent Events connection. The stream stays open until the handler returns.
+type SSEHandler func(s *SSEStream, r *Request)
+
+// SSEOptions configures an event stream registered with Ghast.SSE.
+type SSEOptions struct {
+	Heartbeat  time.Duration // Interval between keep-alive comments; defaults to 15 seconds, negative disables them
+	Retry      time.Duration // Reconnection delay suggested to the client when the stream opens; zero leaves the browser default
+	Middleware []Middleware  // Middleware applied to the stream route
+}
+
+// SSEEvent is a single Server-Sent Event.
+type SSEEvent struct {
+	ID    string        // Event ID, echoed back by the client in Last-Event-ID when it reconnects
+	Event string        // Event type; empty means the default "message" type
+	Data  string        // Event payload; multi-line data is sent as multiple data fields
+	Retry time.Duration // Reconnection delay for the client; zero leaves it unchanged
+}
+
+// SSEStream writes Server-Sent Events to a single client.
+type SSEStream struct {
+	w           ResponseWriter
+	ctx         context.Context
+	cancel      context.CancelFunc
+	mu          sync.Mutex // Serializes writes from the handler and the heartbeat
+	closed      bool
+	lastEventID string
+}
+
+// SSE registers a GET route that streams Server-Sent Events. The event-stream headers are written before the
+// handler runs, a heartbeat comment is sent periodically while the stream is idle, and the stream's context is
+// canceled once a write fails because the client went away.
+//
+// Example:
+//
+//	app.SSE("/events", func(s *ghast.SSEStream, r *ghast.Request) {
+//	    for {
+//	        select {
+//	        case <-s.Context().Done():
+//	            return
+//	        case msg := <-messages:
+//	            s.Send(ghast.SSEEvent{ID: msg.ID, Data: msg.Text})
+//	        }
+//	    }
+//	})
+func (g *Ghast) SSE(path string, handler SSEHandler, opts ...SSEOptions) *Ghast {
+	var options SSEOptions
+	if len(opts) > 0 {
+		options = opts[0]
+	}
+	if options.Heartbeat == 0 {
+		options.Heartbeat = defaultSSEHeartbeat
+	}
+	return g.Get(path, sseHandler(handler, options), options.Middleware...)
+}
+
+// sseHandler adapts an SSEHandler to a Handler.
+func sseHandler(handler SSEHandler, options SSEOptions) Handler {
+	return HandlerFunc(func(w ResponseWriter, r *Request) {
+		// The stream responds as soon as it opens, so the server's RequestTimeout doesn't end it, unlike other
+		// cancellations of the request context
+		ctx, cancel := context.WithCancel(context.WithoutCancel(r.Context()))
+		defer cancel()
+		stop := context.AfterFunc(r.Context(), func() {
+			if !isRequestTimeout(r.Context()) {
+				cancel()
+			}
+		})
+		defer stop()
+
+		s := &SSEStream{
+			w:           w,
+			ctx:         ctx,
+			cancel:      cancel,
+			lastEventID: r.GetHeader("Last-Event-ID"),
+		}
+
+		w.SetHeader("Content-Type", "text/event-stream")
+		w.SetHeader("Cache-Control", "no-cache")
+		w.SetHeader("Connection", "close") // The stream has no length, so it ends when the connection does
+		w.SetHeader("X-Accel-Buffering", "no")
+		w.Status(200)
+		if options.Retry > 0 {
+			s.write(fmt.Sprintf("retry: %d\n\n", options.Retry.Milliseconds()))
+		} else {
+			s.flush()
+		}
+
+		if options.Heartbeat > 0 {
+			go s.heartbeat(options.Heartbeat)
+		}
+
+		handler(s, r.WithContext(ctx))
+
+		s.mu.Lock()
+		s.closed = true
+		s.mu.Unlock()
+	})
+}
+
+// Context returns the stream's context, which is canceled when the client disconnects or the handler returns.
+func (s *SSEStream) Context() context.Context {
+	return s.ctx
+}
+
+// LastEventID returns the Last-Event-ID sent by a reconnecting client, so the handler can resume the stream
+// after the last event the client received. It is empty for new connections.
+func (s *SSEStream) LastEventID() string {
+	return s.lastEventID
+}
+
+// Send writes an event to the client. Line breaks in the ID or event type are replaced with spaces, as for
+// header values, so values taken from a request can't end the field early and inject fields of their own.
+func (s *SSEStream) Send(event SSEEvent) error {
+	var buf strings.Builder
+	if event.ID != "" {
+		fmt.Fprintf(&buf, "id: %s\n", headerSafe(event.ID))
+	}
+	if event.Event != "" {
+		fmt.Fprintf(&buf, "event: %s\n", headerSafe(event.Event))
+	}
+	if event.Retry > 0 {
+		fmt.Fprintf(&buf, "retry: %d\n", event.Retry.Milliseconds())
+	}
+	// Clients end a line at CR, LF or CRLF, so every form starts a new data field
+	for _, line := range strings.Split(sseLineBreaks.Replace(event.Data), "\n") {
+		fmt.Fprintf(&buf, "data: %s\n", line)
+	}
+	buf.WriteString("\n")
+	return s.write(buf.String())
+}
+
+// sseLineBreaks normalizes the line breaks an event stream recognizes to LF.
+var sseLineBreaks = strings.NewReplacer("\r\n", "\n", "\r", "\n")
+
+// SendData writes an event of the default type carrying only data.
+func (s *SSEStream) SendData(data string) error {
+	return s.Send(SSEEvent{Data: data})
+}
+
+// heartbeat sends a comment line at the given interval until the stream's context is done.
+func (s *SSEStream) heartbeat(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-s.ctx.Done():
+			return
+		case <-ticker.C:
+			s.write(": heartbeat\n\n")
+		}
+	}
+}
+
+// write sends raw event-stream data, canceling the stream if the write fails.
+func (s *SSEStream) write(data string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.closed || s.ctx.Err() != nil {
+		return ErrStreamClosed
+	}
+	if _, err := s.w.SendString(data); err != nil {
+		s.cancel()
+		return err
+	}
+	return s.flushLocked()
+}
+
+// flush sends the headers and any buffered data to the client.
+func (s *SSEStream) flush() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.flushLocked()
+}
+
+func (s *SSEStream) flushLocked() error {
+	if f, ok := s.w.(Flusher); ok {
+		if err := f.Flush(); err != nil {
+			s.cancel()
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,74 @@
+package ghast
+
+import (
+	"fmt"
+	"sync"
+)
+
+// appState holds shared dependencies registered with Ghast.Set (database pools, clients, config, etc.).
+// Values are usually registered during setup and read concurrently by handlers, so access is guarded
+// by a read/write lock.
+type appState struct {
+	mu     sync.RWMutex
+	values map[string]any
+}
+
+// Set stores a shared dependency under the given key so handlers can retrieve it from the request
+// with Lookup or MustGet, instead of relying on package-level globals. Returns the app for chaining.
+//
+// Example:
+//
+//	app.Set("db", pool)
+//
+//	app.Get("/users", ghast.HandlerFunc(func(w ghast.ResponseWriter, r *ghast.Request) {
+//	    db := ghast.MustGet[*sql.DB](r, "db")
+//	    ...
+//	}))
+func (g *Ghast) Set(key string, value any) *Ghast {
+	g.state.mu.Lock()
+	defer g.state.mu.Unlock()
+	if g.state.values == nil {
+		g.state.values = make(map[string]any)
+	}
+	g.state.values[key] = value
+	return g
+}
+
+// Value returns the dependency stored under key, and whether it was found. Mounted sub-applications
+// fall back to the application they are mounted in when the key is not registered on them directly.
+func (g *Ghast) Value(key string) (any, bool) {
+	g.state.mu.RLock()
+	value, ok := g.state.values[key]
+	g.state.mu.RUnlock()
+	if !ok && g.parent != nil {
+		return g.parent.Value(key)
+	}
+	return value, ok
+}
+
+// Lookup retrieves the dependency stored under key on the application handling the request.
+// It returns false if the key is missing or the stored value is not of type T.
+func Lookup[T any](r *Request, key string) (T, bool) {
+	var zero T
+	if r.app == nil {
+		return zero, false
+	}
+	value, ok := r.app.Value(key)
+	if !ok {
+		return zero, false
+	}
+	typed, ok := value.(T)
+	return typed, ok
+}
+
+// MustGet retrieves the dependency stored under key on the application handling the request.
+// It panics if the key is missing or the stored value is not of type T, which usually indicates a
+// wiring mistake at startup rather than a recoverable request error.
+func MustGet[T any](r *Request, key string) T {
+	value, ok := Lookup[T](r, key)
+	if !ok {
+		var zero T
+		panic(fmt.Sprintf("ghast: no value of type %T registered under key %q", zero, key))
+	}
+	return value
+}
@@ -0,0 +1,261 @@
+package ghast
+
+import (
+	"crypto/sha256"
+	"embed"
+	"encoding/hex"
+	"errors"
+	"html/template"
+	"io/fs"
+	"mime"
+	"net/http"
+	"path"
+	"runtime/debug"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// StaticFS serves the files in fsys under the given path prefix, typically an embed.FS bundled into the binary:
+//
+//	//go:embed public
+//	var public embed.FS
+//
+//	assets, _ := fs.Sub(public, "public")
+//	app.StaticFS("/assets", assets) // GET /assets/app.js serves public/app.js
+//
+// Content-Type is derived from the file extension. Each file gets an ETag, so clients revalidate with
+// If-None-Match and get 304 Not Modified: for an embed.FS, which can only change with a new build, it is taken
+// from the VCS revision the binary was built from when available, and otherwise from the file's content. When
+// the client accepts it and a precompressed variant exists next to the file (app.js.br or app.js.gz), that
+// variant is served with the matching Content-Encoding. Requests for a directory serve its index.html. Only GET
+// and HEAD are allowed.
+//
+// Routers and groups have their own StaticFS, for serving files under their prefix and middleware.
+func (g *Ghast) StaticFS(prefix string, fsys fs.FS, middlewares ...Middleware) *Ghast {
+	g.addRouteGroup(routeGroup{
+		prefix:      joinPaths("", prefix),
+		middlewares: middlewares,
+		handler:     newStaticHandler(fsys, ""),
+	})
+	return g
+}
+
+// registerStatic registers GET and HEAD routes serving the files in fsys for prefix and the paths below it,
+// through handle.
+func registerStatic(handle func(method, path string, handler Handler, middlewares ...Middleware), prefix string, fsys fs.FS, middlewares []Middleware) {
+	handler := newStaticHandler(fsys, "filepath")
+	for _, path := range []string{joinPaths("", prefix), joinPaths(prefix, "*filepath")} {
+		handle(GET, path, handler, middlewares...)
+		handle(HEAD, path, handler, middlewares...)
+	}
+}
+
+// precompressedEncodings lists the precompressed variants StaticFS looks for, in order of preference.
+var precompressedEncodings = []struct {
+	encoding  string
+	extension string
+}{
+	{"br", ".br"},
+	{"gzip", ".gz"},
+}
+
+// staticHandler serves files from an fs.FS. Paths are relative to the mount prefix, which dispatch strips, or
+// taken from a wildcard parameter for handlers registered as routes.
+type staticHandler struct {
+	fsys     fs.FS
+	param    string // Wildcard parameter holding the file path, or "" to use the request path
+	embedded bool   // Whether fsys is embedded in the binary, so the build's revision identifies its files
+}
+
+// newStaticHandler creates a handler serving fsys. Files are taken to be embedded if fsys is an embed.FS, or if
+// its root has no modification time, as with fs.Sub of an embed.FS; files on disk always have one.
+func newStaticHandler(fsys fs.FS, param string) *staticHandler {
+	_, embedded := fsys.(embed.FS)
+	if info, err := fs.Stat(fsys, "."); err == nil && info.ModTime().IsZero() {
+		embedded = true
+	}
+	return &staticHandler{fsys: fsys, param: param, embedded: embedded}
+}
+
+// ServeHTTP implements Handler.
+func (h *staticHandler) ServeHTTP(w ResponseWriter, r *Request) {
+	if r.Method != GET && r.Method != HEAD {
+		w.SetHeader("Allow", "GET, HEAD")
+		w.Status(405)
+		w.Send([]byte("405 Method Not Allowed"))
+		return
+	}
+
+	requested := r.Path
+	if h.param != "" {
+		requested = r.Param(h.param)
+	}
+	name := strings.TrimPrefix(path.Clean("/"+requested), "/")
+	if name == "" {
+		name = "."
+	}
+	if info, err := fs.Stat(h.fsys, name); err == nil && info.IsDir() {
+		name = path.Join(name, "index.html")
+	}
+	data, err := fs.ReadFile(h.fsys, name)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) || errors.Is(err, fs.ErrInvalid) {
+			notFoundHandler(r).ServeHTTP(w, r)
+			return
+		}
+		w.Status(500)
+		w.Send([]byte("500 Internal Server Error"))
+		return
+	}
+
+	contentType := mime.TypeByExtension(path.Ext(name))
+	if contentType == "" {
+		contentType = http.DetectContentType(data)
+	}
+	w.SetHeader("Content-Type", contentType)
+	w.SetHeader("Vary", "Accept-Encoding")
+
+	// Each encoding of a file is a different representation, so it gets its own ETag.
+	variant := name
+	if encoding, extension, compressed := h.precompressed(name, r.GetHeader("Accept-Encoding")); compressed != nil {
+		w.SetHeader("Content-Encoding", encoding)
+		variant, data = name+extension, compressed
+	}
+
+	etag := h.etag(variant, data)
+	w.SetHeader("ETag", etag)
+	if etagMatches(r.GetHeader("If-None-Match"), etag) {
+		w.Status(304)
+		return
+	}
+
+	w.SetHeader("Content-Length", strconv.Itoa(len(data)))
+	w.Status(200)
+	if r.Method == HEAD {
+		return
+	}
+	w.Send(data)
+}
+
+// precompressed returns the first precompressed variant of name that the client accepts.
+func (h *staticHandler) precompressed(name, acceptEncoding string) (encoding, extension string, data []byte) {
+	for _, variant := range precompressedEncodings {
+		if !acceptsEncoding(acceptEncoding, variant.encoding) {
+			continue
+		}
+		if data, err := fs.ReadFile(h.fsys, name+variant.extension); err == nil {
+			return variant.encoding, variant.extension, data
+		}
+	}
+	return "", "", nil
+}
+
+// etag returns the ETag for a file: for embedded files, the build's VCS revision combined with the file name
+// when known, and otherwise a hash of the content, so files edited on disk get a new ETag.
+func (h *staticHandler) etag(name string, data []byte) string {
+	if revision := buildRevision(); h.embedded && revision != "" {
+		sum := sha256.Sum256([]byte(revision + "\x00" + name))
+		return `"` + hex.EncodeToString(sum[:8]) + `"`
+	}
+	sum := sha256.Sum256(data)
+	return `"` + hex.EncodeToString(sum[:8]) + `"`
+}
+
+// buildRevision returns the VCS revision the binary was built from, or "" if it is unknown or the working tree
+// had uncommitted changes (in which case embedded files may differ between builds of the same revision).
+var buildRevision = sync.OnceValue(func() string {
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return ""
+	}
+	var revision string
+	for _, setting := range info.Settings {
+		switch setting.Key {
+		case "vcs.revision":
+			revision = setting.Value
+		case "vcs.modified":
+			if setting.Value == "true" {
+				return ""
+			}
+		}
+	}
+	return revision
+})
+
+// etagMatches reports whether an If-None-Match header matches etag.
+func etagMatches(ifNoneMatch, etag string) bool {
+	if ifNoneMatch == "" {
+		return false
+	}
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		if candidate == "*" || candidate == etag {
+			return true
+		}
+	}
+	return false
+}
+
+// acceptsEncoding reports whether an Accept-Encoding header allows the given content coding.
+func acceptsEncoding(acceptEncoding, encoding string) bool {
+	for _, part := range strings.Split(acceptEncoding, ",") {
+		coding, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		if !strings.EqualFold(strings.TrimSpace(coding), encoding) {
+			continue
+		}
+		return strings.ReplaceAll(strings.TrimSpace(params), " ", "") != "q=0"
+	}
+	return false
+}
+
+// LoadTemplatesFS parses the HTML templates in fsys, such as an embed.FS, matching any of the given glob
+// patterns in every directory (default "*.html" and "*.tmpl"). Templates are named by their path relative to
+// the root of fsys, so "users/show.html" and "posts/show.html" don't collide as they do with
+// template.ParseFS.
+//
+// Example:
+//
+//	//go:embed views
+//	var views embed.FS
+//
+//	tmpl := template.Must(ghast.LoadTemplatesFS(views))
+//
+//	var page strings.Builder
+//	if err := tmpl.ExecuteTemplate(&page, "views/users/show.html", user); err != nil {
+//	    return err
+//	}
+//	return w.HTML(200, page.String())
+func LoadTemplatesFS(fsys fs.FS, patterns ...string) (*template.Template, error) {
+	return loadTemplatesFS(template.New(""), fsys, patterns...)
+}
+
+// loadTemplatesFS parses the templates in fsys matching patterns into root.
+func loadTemplatesFS(root *template.Template, fsys fs.FS, patterns ...string) (*template.Template, error) {
+	if len(patterns) == 0 {
+		patterns = []string{"*.html", "*.tmpl"}
+	}
+	err := fs.WalkDir(fsys, ".", func(name string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		for _, pattern := range patterns {
+			if matched, _ := path.Match(pattern, path.Base(name)); !matched {
+				continue
+			}
+			data, err := fs.ReadFile(fsys, name)
+			if err != nil {
+				return err
+			}
+			if _, err := root.New(name).Parse(string(data)); err != nil {
+				return err
+			}
+			break
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return root, nil
+}
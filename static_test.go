@@ -0,0 +1,158 @@
+package ghast
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func newStaticTestFS() fstest.MapFS {
+	return fstest.MapFS{
+		"index.html":     {Data: []byte("<h1>home</h1>")},
+		"css/app.css":    {Data: []byte("body{color:red}")},
+		"js/app.js":      {Data: []byte("console.log('hi')")},
+		"js/app.js.gz":   {Data: []byte("gzipped")},
+		"js/app.js.br":   {Data: []byte("brotli")},
+		"views/a.html":   {Data: []byte(`{{define "title"}}A{{end}}a:{{template "title"}}`)},
+		"views/x/a.html": {Data: []byte(`nested {{.}}`)},
+	}
+}
+
+// TestStaticFS tests content types, directory indexes, ETag revalidation and missing files
+func TestStaticFS(t *testing.T) {
+	app := New()
+	app.StaticFS("/assets", newStaticTestFS())
+
+	res := app.Test(&Request{Method: GET, Path: "/assets/css/app.css"})
+	if res.StatusCode != 200 || res.Body != "body{color:red}" {
+		t.Fatalf("unexpected response: %d %q", res.StatusCode, res.Body)
+	}
+	if !strings.HasPrefix(res.Header("Content-Type"), "text/css") {
+		t.Errorf("expected text/css, got %q", res.Header("Content-Type"))
+	}
+	etag := res.Header("ETag")
+	if etag == "" {
+		t.Fatal("expected an ETag")
+	}
+
+	res = app.Test(&Request{Method: GET, Path: "/assets/css/app.css", Headers: map[string]string{"If-None-Match": etag}})
+	if res.StatusCode != 304 || res.Body != "" {
+		t.Errorf("expected 304 with empty body, got %d %q", res.StatusCode, res.Body)
+	}
+
+	res = app.Test(&Request{Method: GET, Path: "/assets"})
+	if res.StatusCode != 200 || res.Body != "<h1>home</h1>" {
+		t.Errorf("expected index.html, got %d %q", res.StatusCode, res.Body)
+	}
+
+	res = app.Test(&Request{Method: GET, Path: "/assets/../../etc/passwd"})
+	if res.StatusCode != 400 {
+		t.Errorf("expected 400 for a path outside the root, got %d", res.StatusCode)
+	}
+
+	res = app.Test(&Request{Method: POST, Path: "/assets/js/app.js"})
+	if res.StatusCode != 405 || res.Header("Allow") != "GET, HEAD" {
+		t.Errorf("expected 405 with Allow header, got %d %q", res.StatusCode, res.Header("Allow"))
+	}
+}
+
+// TestStaticFSRouter tests serving files from routers, router groups and app groups
+func TestStaticFSRouter(t *testing.T) {
+	files := NewRouter()
+	files.StaticFS("/assets", newStaticTestFS())
+	files.Group("/v2").StaticFS("/assets", newStaticTestFS())
+
+	app := New()
+	app.Route("/files", files)
+	app.Group("/site").StaticFS("/", newStaticTestFS())
+
+	for _, path := range []string{"/files/assets/js/app.js", "/files/v2/assets/js/app.js", "/site/js/app.js"} {
+		res := app.Test(&Request{Method: GET, Path: path})
+		if res.StatusCode != 200 || res.Body != "console.log('hi')" {
+			t.Errorf("%s: unexpected response: %d %q", path, res.StatusCode, res.Body)
+		}
+	}
+
+	res := app.Test(&Request{Method: GET, Path: "/site"})
+	if res.StatusCode != 200 || res.Body != "<h1>home</h1>" {
+		t.Errorf("expected index.html, got %d %q", res.StatusCode, res.Body)
+	}
+
+	res = app.Test(&Request{Method: HEAD, Path: "/files/assets/css/app.css"})
+	if res.StatusCode != 200 || res.Body != "" || res.Header("ETag") == "" {
+		t.Errorf("unexpected HEAD response: %d %q %q", res.StatusCode, res.Body, res.Header("ETag"))
+	}
+
+	res = app.Test(&Request{Method: GET, Path: "/files/assets/missing.js"})
+	if res.StatusCode != 404 {
+		t.Errorf("expected 404, got %d", res.StatusCode)
+	}
+}
+
+// TestStaticFSETagChanges tests that files changed on disk get a new ETag
+func TestStaticFSETagChanges(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "app.js")
+	if err := os.WriteFile(file, []byte("one"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	app := New()
+	app.StaticFS("/assets", os.DirFS(dir))
+
+	first := app.Test(&Request{Method: GET, Path: "/assets/app.js"})
+	if err := os.WriteFile(file, []byte("two"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	res := app.Test(&Request{Method: GET, Path: "/assets/app.js", Headers: map[string]string{"If-None-Match": first.Header("ETag")}})
+	if res.StatusCode != 200 || res.Body != "two" {
+		t.Errorf("expected the changed file, got %d %q", res.StatusCode, res.Body)
+	}
+	if res.Header("ETag") == first.Header("ETag") {
+		t.Errorf("expected a new ETag, got %q again", res.Header("ETag"))
+	}
+}
+
+// TestStaticFSPrecompressed tests that precompressed variants are chosen by Accept-Encoding
+func TestStaticFSPrecompressed(t *testing.T) {
+	app := New()
+	app.StaticFS("/assets", newStaticTestFS())
+
+	tests := []struct {
+		acceptEncoding string
+		encoding       string
+		body           string
+	}{
+		{"gzip, deflate, br", "br", "brotli"},
+		{"gzip", "gzip", "gzipped"},
+		{"br;q=0, gzip", "gzip", "gzipped"},
+		{"", "", "console.log('hi')"},
+	}
+	for _, tt := range tests {
+		res := app.Test(&Request{Method: GET, Path: "/assets/js/app.js", Headers: map[string]string{"Accept-Encoding": tt.acceptEncoding}})
+		if res.Header("Content-Encoding") != tt.encoding || res.Body != tt.body {
+			t.Errorf("Accept-Encoding %q: expected %q encoding with body %q, got %q %q",
+				tt.acceptEncoding, tt.encoding, tt.body, res.Header("Content-Encoding"), res.Body)
+		}
+		if !strings.Contains(res.Header("Content-Type"), "javascript") {
+			t.Errorf("expected JavaScript content type for the original file, got %q", res.Header("Content-Type"))
+		}
+	}
+}
+
+// TestLoadTemplatesFS tests that templates are named by their relative path
+func TestLoadTemplatesFS(t *testing.T) {
+	tmpl, err := LoadTemplatesFS(newStaticTestFS())
+	if err != nil {
+		t.Fatalf("load failed: %v", err)
+	}
+
+	var out strings.Builder
+	tmpl.ExecuteTemplate(&out, "views/a.html", nil)
+	out.WriteString("|")
+	tmpl.ExecuteTemplate(&out, "views/x/a.html", "b")
+	if out.String() != "a:A|nested b" {
+		t.Errorf("unexpected output %q", out.String())
+	}
+}
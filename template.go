@@ -0,0 +1,218 @@
+package ghast
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"html/template"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"strings"
+	"sync"
+)
+
+// Renderer renders named views. It is set on an application with SetRenderer and used by ResponseWriter.Render.
+type Renderer interface {
+	Render(w io.Writer, name string, data any) error
+}
+
+// TemplateOptions configures a TemplateRenderer.
+type TemplateOptions struct {
+	Layout     string           // Layout every page is rendered inside, e.g. "layouts/base.html"; empty renders pages on their own
+	Funcs      template.FuncMap // Functions available to every template
+	Reload     bool             // Re-parse the templates on every render so edits show up without a restart (for development)
+	FS         fs.FS            // File system to load templates from, such as an embed.FS; defaults to the OS file system
+	Extensions []string         // File extensions treated as templates; defaults to .html and .tmpl
+}
+
+// TemplateRenderer renders html/template pages with a shared layout and partials. The template directory is
+// laid out as:
+//
+//	layouts/   layouts, which render the page with {{template "content" .}}
+//	partials/  templates shared by every page, included with {{template "partials/nav.html" .}}
+//	...        everything else is a page, named by its path relative to the directory (e.g. "users/show.html")
+//
+// With a layout, pages define the blocks the layout uses ({{define "content"}}...{{end}}). Each page is parsed
+// into its own template set, so pages can define the same block names.
+type TemplateRenderer struct {
+	fsys    fs.FS
+	options TemplateOptions
+
+	mu    sync.RWMutex
+	pages map[string]*template.Template
+}
+
+// NewTemplateRenderer loads the templates in dir (a path within options.FS when it is set) and returns a
+// renderer for them. Parse errors are reported immediately, even when Reload is set.
+//
+// Example:
+//
+//	renderer, err := ghast.NewTemplateRenderer("views", ghast.TemplateOptions{
+//	    Layout: "layouts/base.html",
+//	    Funcs:  template.FuncMap{"upper": strings.ToUpper},
+//	    Reload: os.Getenv("APP_ENV") == "development",
+//	})
+//	if err != nil {
+//	    log.Fatal(err)
+//	}
+//	app.SetRenderer(renderer)
+//
+//	app.Get("/users/:id", ghast.HandlerE(func(w ghast.ResponseWriter, r *ghast.Request) error {
+//	    return w.Render(200, "users/show.html", user)
+//	}))
+func NewTemplateRenderer(dir string, options TemplateOptions) (*TemplateRenderer, error) {
+	fsys := options.FS
+	if fsys == nil {
+		fsys = os.DirFS(dir)
+	} else if dir != "" && dir != "." {
+		sub, err := fs.Sub(fsys, dir)
+		if err != nil {
+			return nil, err
+		}
+		fsys = sub
+	}
+	if len(options.Extensions) == 0 {
+		options.Extensions = []string{".html", ".tmpl"}
+	}
+
+	tr := &TemplateRenderer{fsys: fsys, options: options}
+	pages, err := tr.parse()
+	if err != nil {
+		return nil, err
+	}
+	tr.pages = pages
+	return tr, nil
+}
+
+// Render executes the named page, inside the layout if one is configured.
+func (tr *TemplateRenderer) Render(w io.Writer, name string, data any) error {
+	pages := tr.current()
+	if tr.options.Reload {
+		var err error
+		if pages, err = tr.parse(); err != nil {
+			return err
+		}
+		tr.mu.Lock()
+		tr.pages = pages
+		tr.mu.Unlock()
+	}
+
+	page, ok := pages[name]
+	if !ok {
+		return fmt.Errorf("ghast: template %q not found", name)
+	}
+	if tr.options.Layout != "" {
+		return page.ExecuteTemplate(w, tr.options.Layout, data)
+	}
+	return page.ExecuteTemplate(w, name, data)
+}
+
+func (tr *TemplateRenderer) current() map[string]*template.Template {
+	tr.mu.RLock()
+	defer tr.mu.RUnlock()
+	return tr.pages
+}
+
+// parse loads the layouts and partials into a base set and clones it for each page.
+func (tr *TemplateRenderer) parse() (map[string]*template.Template, error) {
+	var shared, pages []string
+	err := fs.WalkDir(tr.fsys, ".", func(name string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() || !tr.isTemplate(name) {
+			return err
+		}
+		if strings.HasPrefix(name, "layouts/") || strings.HasPrefix(name, "partials/") {
+			shared = append(shared, name)
+		} else {
+			pages = append(pages, name)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if tr.options.Layout != "" && !contains(shared, tr.options.Layout) {
+		return nil, fmt.Errorf("ghast: layout %q not found in layouts/", tr.options.Layout)
+	}
+
+	base := template.New("").Funcs(tr.options.Funcs)
+	for _, name := range shared {
+		if err := tr.parseFile(base, name); err != nil {
+			return nil, err
+		}
+	}
+
+	parsed := make(map[string]*template.Template, len(pages))
+	for _, name := range pages {
+		page, err := base.Clone()
+		if err != nil {
+			return nil, err
+		}
+		if err := tr.parseFile(page, name); err != nil {
+			return nil, err
+		}
+		parsed[name] = page
+	}
+	return parsed, nil
+}
+
+// parseFile parses a template file into set under its relative path.
+func (tr *TemplateRenderer) parseFile(set *template.Template, name string) error {
+	data, err := fs.ReadFile(tr.fsys, name)
+	if err != nil {
+		return err
+	}
+	_, err = set.New(name).Parse(string(data))
+	return err
+}
+
+func (tr *TemplateRenderer) isTemplate(name string) bool {
+	return contains(tr.options.Extensions, path.Ext(name))
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// SetRenderer sets the renderer used by ResponseWriter.Render. Mounted sub-applications without their own
+// renderer use their parent's. Returns the app for chaining.
+func (g *Ghast) SetRenderer(renderer Renderer) *Ghast {
+	g.renderer = renderer
+	return g
+}
+
+// errNoRenderer is returned by Render when no application handling the request has a renderer.
+var errNoRenderer = errors.New("ghast: no renderer configured; call SetRenderer")
+
+// rendererFor resolves the renderer configured on the application handling the request, falling back
+// through parent applications.
+func rendererFor(req *Request) Renderer {
+	if req == nil {
+		return nil
+	}
+	for app := req.app; app != nil; app = app.parent {
+		if app.renderer != nil {
+			return app.renderer
+		}
+	}
+	return nil
+}
+
+// renderView renders a view into a buffer, so a template error can still be turned into an error response
+// instead of a half-written page.
+func renderView(renderer Renderer, name string, data any) ([]byte, error) {
+	if renderer == nil {
+		return nil, errNoRenderer
+	}
+	var buf bytes.Buffer
+	if err := renderer.Render(&buf, name, data); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
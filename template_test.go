@@ -0,0 +1,112 @@
+package ghast
+
+import (
+	"html/template"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func newTemplateTestFS() fstest.MapFS {
+	return fstest.MapFS{
+		"views/layouts/base.html":  {Data: []byte(`<title>{{block "title" .}}App{{end}}</title>{{template "partials/nav.html" .}}<main>{{template "content" .}}</main>`)},
+		"views/partials/nav.html":  {Data: []byte(`<nav>{{.User | upper}}</nav>`)},
+		"views/users/show.html":    {Data: []byte(`{{define "title"}}User{{end}}{{define "content"}}<p>{{.Name}}</p>{{end}}`)},
+		"views/posts/show.html":    {Data: []byte(`{{define "content"}}<p>post {{.Name}}</p>{{end}}`)},
+		"views/notes.txt":          {Data: []byte(`not a template`)},
+		"standalone/page.html":     {Data: []byte(`<p>{{.}}</p>`)},
+		"standalone/partials/x.md": {Data: []byte(`ignored`)},
+	}
+}
+
+// TestTemplateRendererLayout tests that pages render inside the layout with partials and custom functions
+func TestTemplateRendererLayout(t *testing.T) {
+	renderer, err := NewTemplateRenderer("views", TemplateOptions{
+		FS:     newTemplateTestFS(),
+		Layout: "layouts/base.html",
+		Funcs:  template.FuncMap{"upper": strings.ToUpper},
+	})
+	if err != nil {
+		t.Fatalf("NewTemplateRenderer failed: %v", err)
+	}
+
+	app := New()
+	app.SetRenderer(renderer)
+	app.Get("/users/:id", HandlerE(func(w ResponseWriter, r *Request) error {
+		return w.Render(200, "users/show.html", map[string]string{"User": "alice", "Name": r.Param("id")})
+	}))
+	app.Get("/posts/:id", HandlerE(func(w ResponseWriter, r *Request) error {
+		return w.Render(200, "posts/show.html", map[string]string{"User": "bob", "Name": r.Param("id")})
+	}))
+	app.Get("/missing", HandlerE(func(w ResponseWriter, r *Request) error {
+		return w.Render(200, "missing.html", nil)
+	}))
+
+	res := app.Test(&Request{Method: GET, Path: "/users/7"})
+	if res.Body != `<title>User</title><nav>ALICE</nav><main><p>7</p></main>` {
+		t.Errorf("unexpected user page %q", res.Body)
+	}
+	if res.Header("Content-Type") != "text/html; charset=utf-8" {
+		t.Errorf("expected text/html content type, got %q", res.Header("Content-Type"))
+	}
+
+	res = app.Test(&Request{Method: GET, Path: "/posts/9"})
+	if res.Body != `<title>App</title><nav>BOB</nav><main><p>post 9</p></main>` {
+		t.Errorf("unexpected post page %q", res.Body)
+	}
+
+	res = app.Test(&Request{Method: GET, Path: "/missing"})
+	if res.StatusCode != 500 {
+		t.Errorf("expected 500 for a missing template, got %d %q", res.StatusCode, res.Body)
+	}
+}
+
+// TestTemplateRendererReload tests that templates are re-read on every render when Reload is set
+func TestTemplateRendererReload(t *testing.T) {
+	dir := t.TempDir()
+	page := filepath.Join(dir, "index.html")
+	os.WriteFile(page, []byte(`v1 {{.}}`), 0o644)
+
+	renderer, err := NewTemplateRenderer(dir, TemplateOptions{Reload: true})
+	if err != nil {
+		t.Fatalf("NewTemplateRenderer failed: %v", err)
+	}
+	var out strings.Builder
+	renderer.Render(&out, "index.html", "a")
+
+	os.WriteFile(page, []byte(`v2 {{.}}`), 0o644)
+	out.WriteString("|")
+	renderer.Render(&out, "index.html", "b")
+
+	if out.String() != "v1 a|v2 b" {
+		t.Errorf("expected reloaded template, got %q", out.String())
+	}
+}
+
+// TestRenderRendererResolution tests that mounted apps use their parent's renderer and that Render fails when
+// no renderer is configured
+func TestRenderRendererResolution(t *testing.T) {
+	app := New()
+	sub := New()
+	renderer, _ := NewTemplateRenderer("standalone", TemplateOptions{FS: newTemplateTestFS()})
+	app.SetRenderer(renderer)
+	app.Mount("/sub", sub)
+	sub.Get("/page", HandlerE(func(w ResponseWriter, r *Request) error {
+		return w.Render(201, "page.html", "inherited")
+	}))
+	if res := app.Test(&Request{Method: GET, Path: "/sub/page"}); res.StatusCode != 201 || res.Body != "<p>inherited</p>" {
+		t.Errorf("expected the parent's renderer to be used, got %d %q", res.StatusCode, res.Body)
+	}
+
+	bare := New()
+	var renderErr error
+	bare.Get("/", HandlerFunc(func(w ResponseWriter, r *Request) {
+		renderErr = w.Render(200, "page.html", nil)
+	}))
+	bare.Test(&Request{Method: GET, Path: "/"})
+	if renderErr != errNoRenderer {
+		t.Errorf("expected errNoRenderer, got %v", renderErr)
+	}
+}
@@ -0,0 +1,305 @@
+package ghast
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// ErrHandlerTimeout is returned by the ResponseWriter methods of a handler that has run past its WithTimeout
+// deadline, or past the server's RequestTimeout before starting its response. The client gets a 503 instead.
+var ErrHandlerTimeout = errors.New("ghast: handler timeout")
+
+// WithTimeout returns middleware that gives a handler d to respond. The handler's request context is canceled
+// at the deadline; if the handler has not returned by then, the client gets 503 Service Unavailable and
+// whatever the handler writes afterwards is discarded. Pass it as route middleware to give individual routes
+// their own deadline:
+//
+//	app.Get("/reports/:id", buildReport, ghast.WithTimeout(10*time.Second))
+//	app.Get("/health", health, ghast.WithTimeout(200*time.Millisecond))
+//
+// The handler runs on its own goroutine with a copy of the request, and its response is buffered until it
+// returns, so streaming responses are delivered all at once. Handlers should still watch Request.Context and
+// return once it is done, since an abandoned handler keeps running until it does.
+func WithTimeout(d time.Duration) Middleware {
+	return func(next Handler) Handler {
+		return HandlerFunc(func(w ResponseWriter, r *Request) {
+			ctx, cancel := context.WithTimeout(r.Context(), d)
+			defer cancel()
+
+			// The handler gets its own copy of the request, since the server recycles the original once this
+			// middleware returns, possibly while an abandoned handler is still using it.
+			req := r.Clone().WithContext(ctx)
+			tw := &timeoutWriter{req: req, headers: make(map[string]string), statusCode: 200}
+			done := make(chan struct{})
+			panicked := make(chan any, 1)
+			go func() {
+				defer func() {
+					if p := recover(); p != nil {
+						panicked <- p
+					}
+				}()
+				next.ServeHTTP(tw, req)
+				close(done)
+			}()
+
+			select {
+			case p := <-panicked:
+				panic(p) // Re-panic on the serving goroutine, where recovery middleware can handle it
+			case <-done:
+				tw.mu.Lock()
+				defer tw.mu.Unlock()
+				tw.replay(w)
+			case <-ctx.Done():
+				tw.mu.Lock()
+				tw.timedOut = true
+				tw.mu.Unlock()
+				if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+					w.Plain(503, "503 Service Unavailable")
+				}
+			}
+		})
+	}
+}
+
+// timeoutWriter buffers the response of a handler running under WithTimeout, so it can be dropped if the
+// deadline passes first.
+type timeoutWriter struct {
+	mu         sync.Mutex
+	req        *Request
+	headers    map[string]string
+	statusCode int
+	body       bytes.Buffer
+	timedOut   bool
+}
+
+// Header returns the buffered response headers.
+func (tw *timeoutWriter) Header() map[string]string {
+	return tw.headers
+}
+
+// responseStarted reports whether any of the body has been buffered.
+func (tw *timeoutWriter) responseStarted() bool {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	return tw.body.Len() > 0
+}
+
+// Status buffers the status code and returns self for chaining.
+func (tw *timeoutWriter) Status(statusCode int) ResponseWriter {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	tw.statusCode = statusCode
+	return tw
+}
+
+// SetHeader buffers a response header and returns self for chaining.
+func (tw *timeoutWriter) SetHeader(key, value string) ResponseWriter {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	tw.headers[key] = value
+	return tw
+}
+
+// Send buffers data as part of the body.
+func (tw *timeoutWriter) Send(data []byte) (int, error) {
+	tw.mu.Lock()
+	defer tw.mu.Unlock()
+	// The deadline may have passed before the middleware has marked the writer, so the context is checked too.
+	if tw.timedOut || errors.Is(tw.req.Context().Err(), context.DeadlineExceeded) {
+		return 0, ErrHandlerTimeout
+	}
+	return tw.body.Write(data)
+}
+
+// SendString buffers a string as part of the body.
+func (tw *timeoutWriter) SendString(s string) (int, error) {
+	return tw.Send([]byte(s))
+}
+
+// JSON buffers data marshaled as JSON with the application/json content type.
+func (tw *timeoutWriter) JSON(statusCode int, data interface{}) error {
+	body, err := json.Marshal(data)
+	if err != nil {
+		return err
+	}
+	return tw.sendTyped(statusCode, "application/json", body)
+}
+
+// JSONPretty buffers data marshaled as indented JSON with the application/json content type.
+func (tw *timeoutWriter) JSONPretty(statusCode int, data interface{}) error {
+	body, err := json.MarshalIndent(data, "", "  ")
+	if err != nil {
+		return err
+	}
+	return tw.sendTyped(statusCode, "application/json", body)
+}
+
+// HTML buffers an HTML response.
+func (tw *timeoutWriter) HTML(statusCode int, html string) error {
+	return tw.sendTyped(statusCode, "text/html", []byte(html))
+}
+
+// Plain buffers a plain text response.
+func (tw *timeoutWriter) Plain(statusCode int, text string) error {
+	return tw.sendTyped(statusCode, "text/plain", []byte(text))
+}
+
+// Render buffers a view rendered with the Renderer of the application handling the request.
+func (tw *timeoutWriter) Render(statusCode int, name string, data any) error {
+	body, err := renderView(rendererFor(tw.req), name, data)
+	if err != nil {
+		return err
+	}
+	return tw.sendTyped(statusCode, "text/html; charset=utf-8", body)
+}
+
+// sendTyped buffers a status, content type and body.
+func (tw *timeoutWriter) sendTyped(statusCode int, contentType string, body []byte) error {
+	tw.Status(statusCode).SetHeader("Content-Type", contentType)
+	_, err := tw.Send(body)
+	return err
+}
+
+// replay writes the buffered response to w. Since the whole body is known, it is sent with its length.
+// The caller must hold tw.mu.
+func (tw *timeoutWriter) replay(w ResponseWriter) {
+	headers := maps.Clone(tw.headers)
+	w.Status(tw.statusCode)
+	for key, value := range headers {
+		w.SetHeader(key, value)
+	}
+	if tw.body.Len() == 0 {
+		return
+	}
+	if _, found := lookupHeader(headers, "Content-Length"); !found {
+		w.SetHeader("Content-Length", strconv.Itoa(tw.body.Len()))
+	}
+	w.Send(tw.body.Bytes())
+}
+
+// errRequestTimeout is the cause of the request contexts canceled by the server-wide RequestTimeout, which tells
+// them apart from contexts canceled because the request is over.
+var errRequestTimeout = fmt.Errorf("ghast: request timeout: %w", context.DeadlineExceeded)
+
+// withRequestTimeout returns middleware applying the server-wide RequestTimeout: the request context passes its
+// deadline after d, and if the handler has written nothing by then, the client gets 503 Service Unavailable once
+// it returns. Unlike WithTimeout, the response is not buffered, so streaming handlers keep flushing as they go.
+func withRequestTimeout(d time.Duration) Middleware {
+	return func(next Handler) Handler {
+		return HandlerFunc(func(w ResponseWriter, r *Request) {
+			ctx, cancel := context.WithTimeoutCause(r.Context(), d, errRequestTimeout)
+			defer cancel()
+
+			dw := &deadlineWriter{ResponseWriter: w, ctx: ctx}
+			next.ServeHTTP(dw, r.WithContext(ctx))
+			if !dw.written && isRequestTimeout(ctx) {
+				w.Plain(503, "503 Service Unavailable")
+			}
+		})
+	}
+}
+
+// isRequestTimeout reports whether ctx was canceled by the server-wide RequestTimeout.
+func isRequestTimeout(ctx context.Context) bool {
+	return ctx.Err() != nil && context.Cause(ctx) == errRequestTimeout
+}
+
+// deadlineWriter passes a handler's response straight through, but refuses to start one once the request has
+// passed its RequestTimeout, leaving the 503 to withRequestTimeout.
+type deadlineWriter struct {
+	ResponseWriter
+	ctx     context.Context
+	written bool // Whether the handler has started its response
+}
+
+// begin reports whether the handler may write, marking the response as started.
+func (dw *deadlineWriter) begin() bool {
+	if !dw.written {
+		if isRequestTimeout(dw.ctx) {
+			return false
+		}
+		dw.written = true
+	}
+	return true
+}
+
+func (dw *deadlineWriter) responseStarted() bool {
+	return responseStarted(dw.ResponseWriter)
+}
+
+func (dw *deadlineWriter) Status(statusCode int) ResponseWriter {
+	dw.ResponseWriter.Status(statusCode)
+	return dw
+}
+
+func (dw *deadlineWriter) SetHeader(key, value string) ResponseWriter {
+	dw.ResponseWriter.SetHeader(key, value)
+	return dw
+}
+
+func (dw *deadlineWriter) Send(data []byte) (int, error) {
+	if !dw.begin() {
+		return 0, ErrHandlerTimeout
+	}
+	return dw.ResponseWriter.Send(data)
+}
+
+func (dw *deadlineWriter) SendString(s string) (int, error) {
+	if !dw.begin() {
+		return 0, ErrHandlerTimeout
+	}
+	return dw.ResponseWriter.SendString(s)
+}
+
+func (dw *deadlineWriter) JSON(statusCode int, data interface{}) error {
+	if !dw.begin() {
+		return ErrHandlerTimeout
+	}
+	return dw.ResponseWriter.JSON(statusCode, data)
+}
+
+func (dw *deadlineWriter) JSONPretty(statusCode int, data interface{}) error {
+	if !dw.begin() {
+		return ErrHandlerTimeout
+	}
+	return dw.ResponseWriter.JSONPretty(statusCode, data)
+}
+
+func (dw *deadlineWriter) HTML(statusCode int, html string) error {
+	if !dw.begin() {
+		return ErrHandlerTimeout
+	}
+	return dw.ResponseWriter.HTML(statusCode, html)
+}
+
+func (dw *deadlineWriter) Plain(statusCode int, text string) error {
+	if !dw.begin() {
+		return ErrHandlerTimeout
+	}
+	return dw.ResponseWriter.Plain(statusCode, text)
+}
+
+func (dw *deadlineWriter) Render(statusCode int, name string, data any) error {
+	if !dw.begin() {
+		return ErrHandlerTimeout
+	}
+	return dw.ResponseWriter.Render(statusCode, name, data)
+}
+
+// Flush starts the response and forwards to the wrapped writer, so streaming handlers are delivered as they go.
+func (dw *deadlineWriter) Flush() error {
+	if !dw.begin() {
+		return ErrHandlerTimeout
+	}
+	if f, ok := dw.ResponseWriter.(Flusher); ok {
+		return f.Flush()
+	}
+	return nil
+}
@@ -0,0 +1,63 @@
+package ghast
+
+import (
+	"errors"
+	"io"
+	"testing"
+	"time"
+)
+
+// TestWithTimeoutFastHandler tests that a handler finishing in time gets its response through unchanged
+func TestWithTimeoutFastHandler(t *testing.T) {
+	app := New()
+	app.Get("/users/:id", HandlerFunc(func(w ResponseWriter, r *Request) {
+		w.SetHeader("X-User", r.Param("id"))
+		w.JSON(201, map[string]string{"id": r.Param("id")})
+	}), WithTimeout(time.Second))
+
+	res := app.Test(&Request{Method: GET, Path: "/users/7"})
+	if res.StatusCode != 201 || res.Body != `{"id":"7"}` {
+		t.Errorf("expected 201 with the handler's body, got %d %q", res.StatusCode, res.Body)
+	}
+	if res.Header("X-User") != "7" || res.Header("Content-Length") != "10" || res.Header("Content-Type") != "application/json" {
+		t.Errorf("unexpected headers: %v", res.Headers)
+	}
+}
+
+// TestWithTimeoutSlowHandler tests that a handler past its deadline is answered with a 503 and its writes fail
+func TestWithTimeoutSlowHandler(t *testing.T) {
+	writeErr := make(chan error, 1)
+	app := New()
+	app.Get("/slow", HandlerFunc(func(w ResponseWriter, r *Request) {
+		<-r.Context().Done()
+		_, err := w.SendString("too late")
+		writeErr <- err
+	}), WithTimeout(20*time.Millisecond))
+
+	res := app.Test(&Request{Method: GET, Path: "/slow"})
+	if res.StatusCode != 503 || res.Body != "503 Service Unavailable" {
+		t.Errorf("expected 503, got %d %q", res.StatusCode, res.Body)
+	}
+	select {
+	case err := <-writeErr:
+		if !errors.Is(err, ErrHandlerTimeout) {
+			t.Errorf("expected ErrHandlerTimeout from the abandoned handler, got %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("handler was not canceled")
+	}
+}
+
+// TestWithTimeoutPanic tests that a panic in the handler reaches the serving goroutine
+func TestWithTimeoutPanic(t *testing.T) {
+	handler := WithTimeout(time.Second)(HandlerFunc(func(w ResponseWriter, r *Request) {
+		panic("boom")
+	}))
+
+	defer func() {
+		if recover() != "boom" {
+			t.Error("expected the handler's panic to be re-raised")
+		}
+	}()
+	handler.ServeHTTP(newResponseWriter(io.Discard), &Request{Method: GET, Path: "/"})
+}
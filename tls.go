@@ -0,0 +1,54 @@
+package ghast
+
+import (
+	"crypto/tls"
+	"errors"
+	"slices"
+)
+
+// ListenTLS runs the start hooks and serves HTTPS on the given TCP address, with the certificate and private key
+// read from PEM files. The certificate file may hold the full chain, leaf first. Clients can negotiate HTTP/2
+// through ALPN unless it is disabled with WithDisableHTTP2. Like Listen, it blocks until Shutdown is called.
+//
+// Example:
+//
+//	log.Fatal(app.ListenTLS(":443", "/etc/ghast/cert.pem", "/etc/ghast/key.pem"))
+func (g *Ghast) ListenTLS(addr, certFile, keyFile string) error {
+	cert, err := tls.LoadX509KeyPair(certFile, keyFile)
+	if err != nil {
+		return err
+	}
+	return g.ListenTLSConfig(addr, &tls.Config{Certificates: []tls.Certificate{cert}})
+}
+
+// ListenTLSConfig is like ListenTLS but takes a TLS configuration, for certificates obtained at runtime (through
+// GetCertificate, e.g. from an ACME client), client certificate authentication or custom cipher suites. The
+// configuration must provide a certificate. It is cloned, so it can't be changed once serving has started.
+func (g *Ghast) ListenTLSConfig(addr string, config *tls.Config) error {
+	config, err := serverTLSConfig(config, !g.config.DisableHTTP2)
+	if err != nil {
+		return err
+	}
+	ln, err := g.server.listen(addr)
+	if err != nil {
+		return err
+	}
+	return g.Serve(tls.NewListener(ln, config))
+}
+
+// serverTLSConfig returns a copy of config ready for serving: it must have a certificate, and the connections
+// offer HTTP/2, if enabled, and HTTP/1.1 through ALPN. A config listing protocols of its own keeps them, with
+// HTTP/1.1 added last.
+func serverTLSConfig(config *tls.Config, http2 bool) (*tls.Config, error) {
+	if config == nil || (len(config.Certificates) == 0 && config.GetCertificate == nil && config.GetConfigForClient == nil) {
+		return nil, errors.New("ghast: TLS config has no certificate")
+	}
+	config = config.Clone()
+	if len(config.NextProtos) == 0 && http2 {
+		config.NextProtos = []string{"h2"}
+	}
+	if !slices.Contains(config.NextProtos, "http/1.1") {
+		config.NextProtos = append(config.NextProtos, "http/1.1")
+	}
+	return config, nil
+}
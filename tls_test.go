@@ -0,0 +1,140 @@
+package ghast
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"io"
+	"math/big"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// writeTestCertificate writes a self-signed certificate for 127.0.0.1 and its key to PEM files in a temporary
+// directory, returning their paths.
+func writeTestCertificate(t *testing.T) (certFile, keyFile string) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	dir := t.TempDir()
+	certFile, keyFile = filepath.Join(dir, "cert.pem"), filepath.Join(dir, "key.pem")
+	os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600)
+	os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0o600)
+	return certFile, keyFile
+}
+
+// startTLSTestApp starts app with ListenTLS on a random loopback port and returns the address it listens on.
+func startTLSTestApp(t *testing.T, app *Ghast) string {
+	t.Helper()
+	certFile, keyFile := writeTestCertificate(t)
+	addrs := make(chan string, 1)
+	app.server.onListen = func(addr net.Addr) { addrs <- addr.String() }
+	errs := make(chan error, 1)
+	go func() { errs <- app.ListenTLS("127.0.0.1:0", certFile, keyFile) }()
+
+	select {
+	case addr := <-addrs:
+		return addr
+	case err := <-errs:
+		t.Fatalf("ListenTLS failed: %v", err)
+	case <-time.After(2 * time.Second):
+		t.Fatal("server did not start listening")
+	}
+	return ""
+}
+
+// TestListenTLS tests that the app serves requests over TLS with a certificate loaded from files
+func TestListenTLS(t *testing.T) {
+	app := New()
+	app.Get("/", HandlerFunc(func(w ResponseWriter, r *Request) { w.Plain(200, "secure") }))
+	addr := startTLSTestApp(t, app)
+	defer app.Shutdown(context.Background())
+
+	conn, err := tls.Dial("tcp", addr, &tls.Config{InsecureSkipVerify: true})
+	if err != nil {
+		t.Fatalf("TLS dial failed: %v", err)
+	}
+	defer conn.Close()
+	if proto := conn.ConnectionState().NegotiatedProtocol; proto != "" && proto != "http/1.1" {
+		t.Errorf("unexpected ALPN protocol %q", proto)
+	}
+	conn.Write([]byte("GET / HTTP/1.1\r\nHost: localhost\r\nConnection: close\r\n\r\n"))
+	response, _ := io.ReadAll(conn)
+	if !strings.HasPrefix(string(response), "HTTP/1.1 200") || !strings.HasSuffix(string(response), "secure") {
+		t.Errorf("unexpected response over TLS: %q", response)
+	}
+}
+
+// TestListenTLSHTTP2 tests that clients negotiating HTTP/2 are served by the same routes, unless it is disabled
+func TestListenTLSHTTP2(t *testing.T) {
+	for _, disabled := range []bool{false, true} {
+		var opts []Option
+		if disabled {
+			opts = append(opts, WithDisableHTTP2())
+		}
+		app := New(opts...)
+		app.Get("/users/:id", HandlerFunc(func(w ResponseWriter, r *Request) {
+			w.SetHeader("X-Id", r.Param("id")).Plain(200, "hello")
+		}))
+		addr := startTLSTestApp(t, app)
+
+		client := &http.Client{Transport: &http.Transport{
+			TLSClientConfig:   &tls.Config{InsecureSkipVerify: true},
+			ForceAttemptHTTP2: true,
+		}}
+		res, err := client.Get("https://" + addr + "/users/7")
+		if err != nil {
+			t.Fatalf("request failed: %v", err)
+		}
+		body, _ := io.ReadAll(res.Body)
+		res.Body.Close()
+		client.CloseIdleConnections()
+
+		wantProto := "HTTP/2.0"
+		if disabled {
+			wantProto = "HTTP/1.1"
+		}
+		if res.Proto != wantProto || res.StatusCode != 200 || string(body) != "hello" || res.Header.Get("X-Id") != "7" {
+			t.Errorf("disabled=%v: expected %s 200 hello, got %s %d %q %v", disabled, wantProto, res.Proto, res.StatusCode, body, res.Header)
+		}
+
+		ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+		if err := app.Shutdown(ctx); err != nil {
+			t.Errorf("disabled=%v: expected clean shutdown, got %v", disabled, err)
+		}
+		cancel()
+	}
+}
+
+// TestListenTLSConfigWithoutCertificate tests that a TLS config without a certificate is rejected up front
+func TestListenTLSConfigWithoutCertificate(t *testing.T) {
+	if err := New().ListenTLSConfig("127.0.0.1:0", &tls.Config{}); err == nil {
+		t.Error("expected an error for a config without a certificate")
+	}
+}
@@ -0,0 +1,238 @@
+package ghast
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// node is a node of the routing tree. Each level of the tree matches one "/"-separated segment of the path,
+// so a lookup costs one step per segment however many routes are registered.
+type node struct {
+	static     map[string]*node  // Children for literal segments, keyed by the segment
+	params     []*node           // Children for ":name" segments, constrained ones first
+	wildcard   *node             // Child for a trailing "*name" segment, which matches the rest of the path
+	routes     map[string]*route // Routes whose template ends at this node, keyed by method
+	pattern    string            // Constraint of a parameter node as written in the template, "" if unconstrained
+	constraint *regexp.Regexp    // Compiled constraint of a parameter node, matched against the whole segment
+}
+
+// segmentKind identifies the kind of a path template segment.
+type segmentKind int
+
+const (
+	literalSegment  segmentKind = iota // Matches the segment text exactly
+	paramSegment                       // ":name" or ":name(pattern)", matches one non-empty segment; optional with a trailing "?"
+	wildcardSegment                    // "*name", matches the rest of the path
+)
+
+// templateSegment is a parsed segment of a path template.
+type templateSegment struct {
+	kind       segmentKind
+	text       string         // Literal text, or the parameter name
+	pattern    string         // Constraint of a parameter as written, e.g. `\d+` for ":id(\d+)"
+	constraint *regexp.Regexp // Compiled constraint, anchored to the whole segment
+	optional   bool           // Whether the parameter may be left out, e.g. ":month?"
+}
+
+// parseTemplate splits a path template into segments, compiling parameter constraints.
+func parseTemplate(template string) ([]templateSegment, error) {
+	parts := strings.Split(strings.TrimPrefix(template, "/"), "/")
+	segments := make([]templateSegment, 0, len(parts))
+	for i, part := range parts {
+		switch {
+		case strings.HasPrefix(part, "*"):
+			if i != len(parts)-1 || part == "*" {
+				return nil, fmt.Errorf("ghast: invalid route %q: a wildcard must be a named final segment such as *filepath", template)
+			}
+			if len(segments) > 0 && segments[len(segments)-1].optional {
+				return nil, fmt.Errorf("ghast: invalid route %q: only the final segments can be optional", template)
+			}
+			segments = append(segments, templateSegment{kind: wildcardSegment, text: part[1:]})
+		case strings.HasPrefix(part, ":"):
+			seg := templateSegment{kind: paramSegment, text: part[1:]}
+			if strings.HasSuffix(part, "?") {
+				seg.optional = true
+				part = strings.TrimSuffix(part, "?")
+				seg.text = part[1:]
+			} else if len(segments) > 0 && segments[len(segments)-1].optional {
+				return nil, fmt.Errorf("ghast: invalid route %q: only the final segments can be optional", template)
+			}
+			if open := strings.IndexByte(part, '('); open >= 0 {
+				if !strings.HasSuffix(part, ")") {
+					return nil, fmt.Errorf("ghast: invalid route %q: unterminated constraint in %q", template, part)
+				}
+				seg.text, seg.pattern = part[1:open], part[open+1:len(part)-1]
+				constraint, err := regexp.Compile(`^(?:` + seg.pattern + `)$`)
+				if err != nil {
+					return nil, fmt.Errorf("ghast: invalid route %q: bad constraint for %q: %w", template, seg.text, err)
+				}
+				seg.constraint = constraint
+			}
+			if seg.text == "" {
+				return nil, fmt.Errorf("ghast: invalid route %q: parameter without a name", template)
+			}
+			segments = append(segments, seg)
+		default:
+			if len(segments) > 0 && segments[len(segments)-1].optional {
+				return nil, fmt.Errorf("ghast: invalid route %q: only the final segments can be optional", template)
+			}
+			segments = append(segments, templateSegment{kind: literalSegment, text: part})
+		}
+	}
+	return segments, nil
+}
+
+// templateVariants returns the templates a parsed template matches: the full one and, for each trailing
+// optional parameter, the template without it. "/articles/:year/:month?" yields "/articles/:year/:month" and
+// "/articles/:year".
+func templateVariants(segments []templateSegment) [][]templateSegment {
+	variants := [][]templateSegment{segments}
+	for n := len(segments); n > 0 && segments[n-1].optional; n-- {
+		variants = append(variants, segments[:n-1])
+	}
+	return variants
+}
+
+// ValidateRoute reports whether a path template is valid, returning an error that describes the problem if
+// not. Handle panics with the same error, so tools that build routes dynamically can check templates first.
+func ValidateRoute(template string) error {
+	_, err := parseTemplate(template)
+	return err
+}
+
+// paramNames returns the names of the parameters of a parsed template, in order.
+func paramNames(segments []templateSegment) []string {
+	var names []string
+	for _, seg := range segments {
+		if seg.kind != literalSegment {
+			names = append(names, seg.text)
+		}
+	}
+	return names
+}
+
+// insert returns the node for a parsed path template, creating the nodes along the way. If foldCase is set,
+// literal segments are stored in lower case for case-insensitive lookups.
+func (n *node) insert(segments []templateSegment, foldCase bool) *node {
+	for _, seg := range segments {
+		switch seg.kind {
+		case wildcardSegment:
+			if n.wildcard == nil {
+				n.wildcard = &node{}
+			}
+			n = n.wildcard
+		case paramSegment:
+			n = n.paramChild(seg)
+		default:
+			if n.static == nil {
+				n.static = make(map[string]*node)
+			}
+			text := seg.text
+			if foldCase {
+				text = strings.ToLower(text)
+			}
+			child, ok := n.static[text]
+			if !ok {
+				child = &node{}
+				n.static[text] = child
+			}
+			n = child
+		}
+	}
+	return n
+}
+
+// find returns the node for a parsed path template, or nil if no route was ever inserted for it.
+func (n *node) find(segments []templateSegment, foldCase bool) *node {
+	for _, seg := range segments {
+		var child *node
+		switch seg.kind {
+		case wildcardSegment:
+			child = n.wildcard
+		case paramSegment:
+			for _, param := range n.params {
+				if param.pattern == seg.pattern {
+					child = param
+					break
+				}
+			}
+		default:
+			text := seg.text
+			if foldCase {
+				text = strings.ToLower(text)
+			}
+			child = n.static[text]
+		}
+		if child == nil {
+			return nil
+		}
+		n = child
+	}
+	return n
+}
+
+// paramChild returns the child for a parameter segment, shared by every parameter with the same constraint.
+// Constrained children are kept ahead of the unconstrained one so they are tried first, in the order they were
+// registered.
+func (n *node) paramChild(seg templateSegment) *node {
+	for _, child := range n.params {
+		if child.pattern == seg.pattern {
+			return child
+		}
+	}
+	child := &node{pattern: seg.pattern, constraint: seg.constraint}
+	if seg.constraint == nil {
+		n.params = append(n.params, child)
+	} else {
+		i := 0
+		for i < len(n.params) && n.params[i].constraint != nil {
+			i++
+		}
+		n.params = slices.Insert(n.params, i, child)
+	}
+	return child
+}
+
+// walk calls visit for each node with routes that matches path, most specific first: at every segment a
+// literal match is tried before a parameter, and a parameter before a wildcard. values holds the segments
+// captured by parameters on the way, in order. If foldCase is set, literal segments are looked up in lower case,
+// as inserted by case-insensitive routers; captured values keep their case. The walk stops as soon as visit
+// returns true, and walk reports whether it did.
+func (n *node) walk(path string, values []string, foldCase bool, visit func(n *node, values []string) bool) bool {
+	return n.walkSegments(strings.TrimPrefix(path, "/"), values, foldCase, visit)
+}
+
+func (n *node) walkSegments(rest string, values []string, foldCase bool, visit func(n *node, values []string) bool) bool {
+	segment, next, more := strings.Cut(rest, "/")
+	literal := segment
+	if foldCase {
+		literal = strings.ToLower(segment)
+	}
+	if child, ok := n.static[literal]; ok && child.walkChild(next, more, values, foldCase, visit) {
+		return true
+	}
+	if segment != "" {
+		for _, child := range n.params {
+			if child.constraint != nil && !child.constraint.MatchString(segment) {
+				continue
+			}
+			if child.walkChild(next, more, append(values, segment), foldCase, visit) {
+				return true
+			}
+		}
+	}
+	if n.wildcard != nil && len(n.wildcard.routes) > 0 && visit(n.wildcard, append(values, rest)) {
+		return true
+	}
+	return false
+}
+
+// walkChild continues a walk at a child node, visiting it if the path ends there.
+func (n *node) walkChild(rest string, more bool, values []string, foldCase bool, visit func(n *node, values []string) bool) bool {
+	if !more {
+		return len(n.routes) > 0 && visit(n, values)
+	}
+	return n.walkSegments(rest, values, foldCase, visit)
+}
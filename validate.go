@@ -0,0 +1,101 @@
+package ghast
+
+import "errors"
+
+// Validator checks values decoded from requests, such as the input structs of Request.Bind. Validate returns
+// nil for a valid value and otherwise an error describing what is wrong with it.
+//
+// Any validation library can be plugged in; for example, with github.com/go-playground/validator:
+//
+//	validate := validator.New()
+//	app.SetValidator(ghast.ValidatorFunc(validate.Struct))
+type Validator interface {
+	Validate(v any) error
+}
+
+// ValidatorFunc adapts a function to the Validator interface.
+type ValidatorFunc func(v any) error
+
+// Validate calls f(v).
+func (f ValidatorFunc) Validate(v any) error {
+	return f(v)
+}
+
+// ValidationError is returned by Request.Validate and Request.Bind when a value fails validation. It wraps the
+// validator's error, and DefaultErrorHandler sends it as 422 Unprocessable Entity with that error's message.
+type ValidationError struct {
+	Err error
+}
+
+// Error implements the error interface.
+func (e *ValidationError) Error() string {
+	return e.Err.Error()
+}
+
+// Unwrap returns the validator's error, so errors.As can reach library-specific error types.
+func (e *ValidationError) Unwrap() error {
+	return e.Err
+}
+
+// DefaultValidator is the validator used when none is configured with SetValidator. Values with a
+// Validate() error method validate themselves; any other value is valid.
+func DefaultValidator(v any) error {
+	if self, ok := v.(interface{ Validate() error }); ok {
+		return self.Validate()
+	}
+	return nil
+}
+
+// SetValidator sets the validator used by Request.Validate and Request.Bind. Mounted sub-applications without
+// their own validator use their parent's. Returns the app for chaining.
+func (g *Ghast) SetValidator(validator Validator) *Ghast {
+	g.validator = validator
+	return g
+}
+
+// validatorFor resolves the validator configured on the application handling the request, falling back
+// through parent applications to DefaultValidator.
+func validatorFor(req *Request) Validator {
+	for app := req.app; app != nil; app = app.parent {
+		if app.validator != nil {
+			return app.validator
+		}
+	}
+	return ValidatorFunc(DefaultValidator)
+}
+
+// Validate checks v with the validator of the application handling the request. A failure is returned as a
+// *ValidationError, so error-returning handlers can return it directly to send a 422 response.
+//
+// Example:
+//
+//	if err := r.Validate(&input); err != nil {
+//		return err
+//	}
+func (r *Request) Validate(v any) error {
+	if err := validatorFor(r).Validate(v); err != nil {
+		var validationErr *ValidationError
+		if errors.As(err, &validationErr) {
+			return err
+		}
+		return &ValidationError{Err: err}
+	}
+	return nil
+}
+
+// Bind decodes the JSON request body into v and validates it with Validate. A body that isn't valid JSON for v
+// is returned as a 400 HTTPError and a value that fails validation as a *ValidationError, so error-returning
+// handlers can return either directly.
+//
+// Example:
+//
+//	var input CreateUserInput
+//	if err := r.Bind(&input); err != nil {
+//		return err
+//	}
+func (r *Request) Bind(v any) error {
+	if err := r.JSON(v); err != nil {
+		return NewHTTPError(400, "invalid JSON body")
+	}
+	return r.Validate(v)
+}
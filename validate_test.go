@@ -0,0 +1,90 @@
+package ghast
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+type signupInput struct {
+	Email string `json:"email"`
+}
+
+func (in *signupInput) Validate() error {
+	if !strings.Contains(in.Email, "@") {
+		return errors.New("email is invalid")
+	}
+	return nil
+}
+
+func newSignupApp() *Ghast {
+	app := New()
+	app.Post("/signup", HandlerE(func(w ResponseWriter, r *Request) error {
+		var input signupInput
+		if err := r.Bind(&input); err != nil {
+			return err
+		}
+		return w.JSON(201, input)
+	}))
+	return app
+}
+
+// TestBindDefaultValidator tests that Bind decodes the body and runs the value's own Validate method
+func TestBindDefaultValidator(t *testing.T) {
+	app := newSignupApp()
+
+	res := app.Test(&Request{Method: POST, Path: "/signup", Body: []byte(`{"email":"a@example.com"}`)})
+	if res.StatusCode != 201 || res.Body != `{"email":"a@example.com"}` {
+		t.Errorf("expected 201 with the input, got %d %q", res.StatusCode, res.Body)
+	}
+
+	res = app.Test(&Request{Method: POST, Path: "/signup", Body: []byte(`{"email":"nope"}`)})
+	if res.StatusCode != 422 || !strings.Contains(res.Body, "email is invalid") {
+		t.Errorf("expected 422 with the validation message, got %d %q", res.StatusCode, res.Body)
+	}
+
+	res = app.Test(&Request{Method: POST, Path: "/signup", Body: []byte(`{`)})
+	if res.StatusCode != 400 {
+		t.Errorf("expected 400 for malformed JSON, got %d", res.StatusCode)
+	}
+}
+
+// TestSetValidator tests that a configured validator replaces the default and is inherited by mounted apps
+func TestSetValidator(t *testing.T) {
+	var validated []any
+	app := New()
+	app.SetValidator(ValidatorFunc(func(v any) error {
+		validated = append(validated, v)
+		return errors.New("rejected")
+	}))
+	app.Mount("/v1", newSignupApp())
+
+	res := app.Test(&Request{Method: POST, Path: "/v1/signup", Body: []byte(`{"email":"a@example.com"}`)})
+	if res.StatusCode != 422 || !strings.Contains(res.Body, "rejected") {
+		t.Errorf("expected 422 from the parent's validator, got %d %q", res.StatusCode, res.Body)
+	}
+	if len(validated) != 1 {
+		t.Fatalf("expected the validator to run once, ran %d times", len(validated))
+	}
+	if _, ok := validated[0].(*signupInput); !ok {
+		t.Errorf("expected the bound value to be validated, got %T", validated[0])
+	}
+}
+
+// TestValidateErrorUnwraps tests that the validator's error stays reachable through the ValidationError
+func TestValidateErrorUnwraps(t *testing.T) {
+	errEmpty := errors.New("empty")
+	app := New()
+	app.SetValidator(ValidatorFunc(func(v any) error { return errEmpty }))
+
+	var err error
+	app.Get("/", HandlerFunc(func(w ResponseWriter, r *Request) {
+		err = r.Validate("anything")
+	}))
+	app.Test(&Request{Method: GET, Path: "/"})
+
+	var validationErr *ValidationError
+	if !errors.As(err, &validationErr) || !errors.Is(err, errEmpty) {
+		t.Errorf("expected a ValidationError wrapping the validator's error, got %#v", err)
+	}
+}
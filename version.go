@@ -0,0 +1,284 @@
+package ghast
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// httpTimeFormat is the IMF-fixdate format used for HTTP date headers such as Sunset.
+const httpTimeFormat = "Mon, 02 Jan 2006 15:04:05 GMT"
+
+// VersionOptions configures the lifecycle of an API version created with Ghast.Version or Router.Version.
+type VersionOptions struct {
+	Deprecated time.Time    // When the version was (or will be) deprecated; adds a Deprecation header (RFC 9745)
+	Sunset     time.Time    // When the version stops being served; adds a Sunset header (RFC 8594)
+	Link       string       // Migration guide or successor documentation, sent as a Link header with rel="deprecation"
+	Middleware []Middleware // Middleware applied to every route of the version
+}
+
+// Version creates a route group for an API version under the "/<version>" prefix, so version lifecycle is managed
+// in one place instead of string prefixes scattered around. Deprecation and sunset dates from the options are
+// announced on every response of the version.
+//
+// Example:
+//
+//	v1 := app.Version("v1", ghast.VersionOptions{
+//	    Deprecated: time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC),
+//	    Sunset:     time.Date(2026, 7, 1, 0, 0, 0, 0, time.UTC),
+//	    Link:       "https://example.com/docs/migrate-to-v2",
+//	})
+//	v1.Get("/users", listUsersV1)
+//
+//	v2 := app.Version("v2")
+//	v2.Get("/users", listUsersV2)
+func (g *Ghast) Version(version string, opts ...VersionOptions) *Group {
+	version, middlewares := g.addVersion(version, opts)
+	return g.Group("/"+version, middlewares...)
+}
+
+// MountVersion serves an existing router as an API version under the "/<version>" prefix, like Route, with the
+// version lifecycle and selection of Version. This suits versions built as separate routers or packages.
+//
+// Example:
+//
+//	app.MountVersion("v1", v1.Router(), ghast.VersionOptions{Sunset: sunset})
+//	app.MountVersion("v2", v2.Router())
+func (g *Ghast) MountVersion(version string, router Router, opts ...VersionOptions) *Ghast {
+	version, middlewares := g.addVersion(version, opts)
+	return g.Route("/"+version, router, middlewares...)
+}
+
+// addVersion registers a version and returns its normalized name and the middleware its routes are wrapped in.
+func (g *Ghast) addVersion(version string, opts []VersionOptions) (string, []Middleware) {
+	version = strings.Trim(version, "/")
+	var options VersionOptions
+	if len(opts) > 0 {
+		options = opts[0]
+	}
+	g.versions = append(g.versions, version)
+	return version, versionMiddlewares(options)
+}
+
+// versionMiddlewares returns the middleware the routes of a version are wrapped in, outermost first.
+func versionMiddlewares(options VersionOptions) []Middleware {
+	middlewares := []Middleware{}
+	if lifecycle := versionLifecycleMiddleware(options); lifecycle != nil {
+		middlewares = append(middlewares, lifecycle)
+	}
+	return append(middlewares, options.Middleware...)
+}
+
+// versionRoutes are the versions registered with Router.Version on one router or group.
+type versionRoutes struct {
+	mu       sync.RWMutex
+	names    []string
+	handlers map[string]Handler // Handler serving each version's router, with its middleware applied
+}
+
+// registerVersion registers the routes of a version on r: one for the version prefix and one for the paths below
+// it, both serving router. The first version registered on r also gets a catch-all route sending other
+// unmatched paths to the requested or latest version. root and prefix identify r, which is root itself or one
+// of its groups.
+func registerVersion(r Router, root *router, prefix, version string, router Router, opts []VersionOptions) {
+	version = strings.Trim(version, "/")
+	var options VersionOptions
+	if len(opts) > 0 {
+		options = opts[0]
+	}
+	handler := Handler(HandlerFunc(func(w ResponseWriter, req *Request) { serveVersion(w, req, router) }))
+	for _, middleware := range slices.Backward(versionMiddlewares(options)) {
+		handler = middleware(handler)
+	}
+
+	root.mu.Lock()
+	routes, found := root.versions[prefix]
+	if !found {
+		routes = &versionRoutes{handlers: map[string]Handler{}}
+		if root.versions == nil {
+			root.versions = map[string]*versionRoutes{}
+		}
+		root.versions[prefix] = routes
+	}
+	root.mu.Unlock()
+
+	routes.mu.Lock()
+	routes.names = append(routes.names, version)
+	routes.handlers[version] = handler
+	routes.mu.Unlock()
+
+	r.Any("/"+version, handler)
+	r.Any(joinPaths(version, "*path"), handler)
+	if !found {
+		r.Any("/*path", HandlerFunc(routes.serveRequested))
+	}
+}
+
+// serveRequested serves the request with the version named in the request, or the latest version.
+func (vr *versionRoutes) serveRequested(w ResponseWriter, req *Request) {
+	vr.mu.RLock()
+	handler := vr.handlers[req.app.requestedVersion(req, vr.names)]
+	vr.mu.RUnlock()
+	handler.ServeHTTP(w, req)
+}
+
+// serveVersion serves the request with a version's router, for the path below the version prefix held by the
+// "path" wildcard.
+func serveVersion(w ResponseWriter, req *Request, router Router) {
+	path := "/" + req.Param("path")
+	originalPath, originalMountPath := req.Path, req.mountPath
+	req.mountPath += strings.TrimSuffix(req.Path, path)
+	req.Path = path
+	router.ServeHTTP(w, req)
+	req.Path, req.mountPath = originalPath, originalMountPath
+}
+
+// SetVersionHeader enables header-based version selection: requests whose path does not start with a version
+// prefix are routed to the version named in the given header (e.g., "API-Version: v2"), or to the latest version
+// when the header is absent or names no known version. The latest version is the highest, with numbers in
+// version names compared numerically ("v10" is later than "v9"). Path prefixes keep working. Returns the app for
+// chaining.
+func (g *Ghast) SetVersionHeader(header string) *Ghast {
+	g.versionHeader = header
+	return g
+}
+
+// SetVersionFromAccept enables version selection from the Accept header for requests whose path does not start
+// with a version prefix, through either a version parameter ("Accept: application/json; version=v2") or a vendor
+// media type ("Accept: application/vnd.example.v2+json"). A known version named in the header set with
+// SetVersionHeader takes precedence, and requests naming no known version are routed to the latest one. Returns
+// the app for chaining.
+func (g *Ghast) SetVersionFromAccept() *Ghast {
+	g.acceptVersion = true
+	return g
+}
+
+// selectVersion rewrites an unversioned request path to the version requested in the version header or the
+// Accept header, or to the latest version.
+func (g *Ghast) selectVersion(req *Request) {
+	if (g.versionHeader == "" && !g.acceptVersion) || len(g.versions) == 0 {
+		return
+	}
+	for _, version := range g.versions {
+		if req.Path == "/"+version || strings.HasPrefix(req.Path, "/"+version+"/") {
+			return
+		}
+	}
+
+	req.Path = joinPaths("/"+g.requestedVersion(req, g.versions), req.Path)
+}
+
+// requestedVersion returns the known version named in the version header or the Accept header, as enabled on g
+// with SetVersionHeader and SetVersionFromAccept, or the latest known version if the request names none. g may be
+// nil, for routers serving requests outside an application.
+func (g *Ghast) requestedVersion(req *Request, known []string) string {
+	if g != nil && g.versionHeader != "" {
+		if version := knownVersion(req.GetHeader(g.versionHeader), known); version != "" {
+			return version
+		}
+	}
+	if g != nil && g.acceptVersion {
+		if version := acceptedVersion(req.GetHeader("Accept"), known); version != "" {
+			return version
+		}
+	}
+	return latestVersion(known)
+}
+
+// knownVersion returns the known version matching name, compared case-insensitively and with an optional "v"
+// prefix ("2" names "v2"), or "" if there is none.
+func knownVersion(name string, known []string) string {
+	if name == "" {
+		return ""
+	}
+	for _, version := range known {
+		if strings.EqualFold(name, version) || strings.EqualFold("v"+name, version) {
+			return version
+		}
+	}
+	return ""
+}
+
+// latestVersion returns the highest of the known versions (see compareVersions).
+func latestVersion(known []string) string {
+	return slices.MaxFunc(known, compareVersions)
+}
+
+// compareVersions orders version names such as "v1", "v2" and "v10", or "1.9" and "1.10", by their dot-separated
+// parts after an optional "v" prefix. Parts that are both numbers compare numerically, others as strings, so
+// date versions such as "2024-06-01" are ordered too.
+func compareVersions(a, b string) int {
+	aParts := strings.Split(strings.TrimPrefix(strings.ToLower(a), "v"), ".")
+	bParts := strings.Split(strings.TrimPrefix(strings.ToLower(b), "v"), ".")
+	for i := range min(len(aParts), len(bParts)) {
+		aNum, aErr := strconv.Atoi(aParts[i])
+		bNum, bErr := strconv.Atoi(bParts[i])
+		var c int
+		if aErr == nil && bErr == nil {
+			c = cmp.Compare(aNum, bNum)
+		} else {
+			c = strings.Compare(aParts[i], bParts[i])
+		}
+		if c != 0 {
+			return c
+		}
+	}
+	return cmp.Compare(len(aParts), len(bParts))
+}
+
+// acceptedVersion returns the first of the known versions named in an Accept header, by a version parameter
+// ("version=v2", or "version=2" for a version named "v2") or a vendor media type ("application/vnd.example.v2+json"),
+// or "" if there is none.
+func acceptedVersion(accept string, known []string) string {
+	for mediaRange := range strings.SplitSeq(accept, ",") {
+		mediaType, params, _ := strings.Cut(mediaRange, ";")
+		for param := range strings.SplitSeq(params, ";") {
+			if key, value, ok := strings.Cut(param, "="); ok && strings.EqualFold(strings.TrimSpace(key), "version") {
+				if version := knownVersion(strings.Trim(strings.TrimSpace(value), `"`), known); version != "" {
+					return version
+				}
+			}
+		}
+
+		_, subtype, _ := strings.Cut(strings.TrimSpace(mediaType), "/")
+		if vendor, ok := strings.CutPrefix(subtype, "vnd."); ok {
+			vendor, _, _ = strings.Cut(vendor, "+")
+			if i := strings.LastIndexByte(vendor, '.'); i >= 0 {
+				if version := knownVersion(vendor[i+1:], known); version != "" {
+					return version
+				}
+			}
+		}
+	}
+	return ""
+}
+
+// versionLifecycleMiddleware returns middleware announcing deprecation and sunset, or nil if neither is set.
+func versionLifecycleMiddleware(options VersionOptions) Middleware {
+	headers := map[string]string{}
+	if !options.Deprecated.IsZero() {
+		headers["Deprecation"] = fmt.Sprintf("@%d", options.Deprecated.Unix())
+	}
+	if !options.Sunset.IsZero() {
+		headers["Sunset"] = options.Sunset.UTC().Format(httpTimeFormat)
+	}
+	if options.Link != "" {
+		headers["Link"] = fmt.Sprintf("<%s>; rel=\"deprecation\"", options.Link)
+	}
+	if len(headers) == 0 {
+		return nil
+	}
+
+	return func(next Handler) Handler {
+		return HandlerFunc(func(w ResponseWriter, r *Request) {
+			for key, value := range headers {
+				w.SetHeader(key, value)
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}